@@ -61,7 +61,26 @@ func main() {
 		os.Exit(1)
 	}
 	secretMngr := awssm.NewAWSSM()
-	configService := service.NewConfigService(&config, repo, repo, secretMngr)
+	configService, err := service.NewConfigService(&config, repo, repo, secretMngr)
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Can't initialize config service")
+		os.Exit(1)
+	}
+
+	err = configService.RebuildReferrers()
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Can't rebuild config set referrers")
+	}
+
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	if config.SchedulerInterval > 0 {
+		go configService.RunScheduler(backgroundCtx, time.Duration(config.SchedulerInterval)*time.Second)
+	}
+
+	if config.TrashSweepInterval > 0 {
+		go configService.RunTrashSweeper(backgroundCtx, time.Duration(config.TrashSweepInterval)*time.Second)
+	}
 
 	handler := handlers.NewConfigRESTHandler(&config, toggleRepo, configService)
 
@@ -100,6 +119,7 @@ func main() {
 
 	// Restore default behavior on the interrupt signal and notify user of shutdown.
 	stop()
+	stopBackground()
 	log.Info().Msg("shutting down gracefully, press Ctrl+C again to force")
 
 	// The context is used to inform the server it has 5 seconds to finish
@@ -30,7 +30,11 @@ func main() {
 		log.Error().Stack().Err(err).Msg("Can't initialize Redis DB")
 		os.Exit(1)
 	}
-	configService := service.NewConfigService(&config, repo, repo, &mocks.MockSecrets{})
+	configService, err := service.NewConfigService(&config, repo, repo, &mocks.MockSecrets{})
+	if err != nil {
+		log.Error().Stack().Err(err).Msg("Can't initialize config service")
+		os.Exit(1)
+	}
 
 	for set := 0; set < 10; set++ {
 		setName := fmt.Sprintf("sample%d", set)
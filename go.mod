@@ -3,7 +3,7 @@ module github.com/sy-software/minerva-olive
 go 1.16
 
 require (
-	github.com/aws/aws-sdk-go v1.41.3 // indirect
+	github.com/aws/aws-sdk-go v1.41.3
 	github.com/gin-gonic/gin v1.7.4
 	github.com/go-redis/redis/v8 v8.11.3
 	github.com/gofrs/uuid v4.0.0+incompatible
@@ -11,5 +11,6 @@ require (
 	github.com/rs/zerolog v1.24.0
 	github.com/stretchr/testify v1.7.0 // indirect
 	github.com/sy-software/minerva-go-utils v0.0.0-20210818225928-36f6fc1f86fb
+	github.com/xeipuuv/gojsonschema v1.2.0
 	golang.org/x/sync v0.0.0-20210220032951-036812b2e83c
 )
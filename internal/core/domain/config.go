@@ -46,6 +46,33 @@ type Config struct {
 	Host string `json:"host,omitempty"`
 	// Server bind port default 8080
 	Port int `json:"port,omitempty"`
+	// Maximum number of nested sets rendered inside each other, default 10. Zero or less disables the limit
+	MaxNestingDepth int `json:"maxNestingDepth,omitempty"`
+	// AES keys used to seal values of "sealed" items by key id, base64 encoded. Sealed items are disabled if empty
+	SealKeys map[string]string `json:"sealKeys,omitempty"`
+	// Id of the key used to seal new values, other keys are only used to open values sealed before a rotation
+	ActiveSealKey string `json:"activeSealKey,omitempty"`
+	// Seconds between runs of the scheduler applying due scheduled changes, default 10. Zero or less disables it
+	SchedulerInterval int `json:"schedulerInterval,omitempty"`
+	// Periods of time where no set can be changed through the API
+	FreezeWindows []FreezeWindow `json:"freezeWindows,omitempty"`
+	// Value of the X-Freeze-Override header that allows changes during freeze windows. Overrides are disabled if empty
+	FreezeOverrideToken string `json:"freezeOverrideToken,omitempty"`
+	// Hours deleted sets stay in the trash before they are purged, default 168. Zero or less keeps them until purged by hand
+	TrashRetention int `json:"trashRetention,omitempty"`
+	// Seconds between runs of the sweeper purging expired sets from the trash, default 600. Zero or less disables it
+	TrashSweepInterval int `json:"trashSweepInterval,omitempty"`
+}
+
+// ActiveFreezeWindow returns the freeze window containing the given time
+func (config *Config) ActiveFreezeWindow(now time.Time) (FreezeWindow, bool) {
+	for _, window := range config.FreezeWindows {
+		if window.Contains(now) {
+			return window, true
+		}
+	}
+
+	return FreezeWindow{}, false
 }
 
 // DefaultConfig returns a configuration object with the default values
@@ -61,9 +88,13 @@ func DefaultConfig() Config {
 			WriteTimeout:      time.Duration(1) * time.Second,
 			PoolSize:          10,
 		},
-		CacheTTL: time.Duration(InfiniteTTL),
-		Host:     "127.0.0.1",
-		Port:     8080,
+		CacheTTL:           time.Duration(InfiniteTTL),
+		Host:               "127.0.0.1",
+		Port:               8080,
+		MaxNestingDepth:    10,
+		SchedulerInterval:  10,
+		TrashRetention:     168,
+		TrashSweepInterval: 600,
 	}
 }
 
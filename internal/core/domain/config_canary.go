@@ -0,0 +1,142 @@
+package domain
+
+import (
+	"errors"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/sy-software/minerva-go-utils/datetime"
+)
+
+// ConfigVersion tells which version of a set was served to a client
+type ConfigVersion string
+
+// Available ConfigVersions
+const (
+	// The current items of the set
+	StableVersion ConfigVersion = "stable"
+	// The items staged by the canary of the set
+	CanaryVersion ConfigVersion = "canary"
+)
+
+// Number of buckets clients are distributed in, each bucket holds 1% of the clients
+const CanaryBuckets = 100
+
+// Possible errors during canary manipulation
+var (
+	// The canary percentage is out of range
+	ErrInvalidCanaryPercent = errors.New("invalid canary percent, expected a value between 0 and 100")
+)
+
+// CanaryStep records a change of the canary percentage
+type CanaryStep struct {
+	// The percentage of clients served the staged items from this step on
+	Percent int `json:"percent"`
+	// When was the percentage set
+	Date time.Time `json:"date"`
+}
+
+// Canary is a staged version of a set served only to a percentage of the clients
+// Clients are bucketed by their ID so each client keeps getting the same version
+// while the percentage grows. Only the staged changes are kept, they are applied over the current set items
+// when serving canary clients and when the canary is promoted, so writes to other items are never lost
+type Canary struct {
+	// The name of the set this canary stages changes for
+	Set string `json:"set"`
+	// The items added or changed for the clients in the canary buckets
+	Items ConfigItemMap `json:"items"`
+	// The keys of the set items removed for the clients in the canary buckets
+	Removed []string `json:"removed,omitempty"`
+	// The percentage of clients served the staged items
+	Percent int `json:"percent"`
+	// Every percentage the canary went through, oldest first
+	History []CanaryStep `json:"history"`
+	// When was this canary staged
+	CreateDate time.Time `json:"createDate"`
+	// When was this canary last updated
+	UpdateDate time.Time `json:"updateDate"`
+}
+
+// NewCanary creates a canary serving items to the given percentage of clients
+func NewCanary(set string, items ConfigItemMap, percent int) (*Canary, error) {
+	now := datetime.UnixUTCNow()
+	canary := &Canary{
+		Set:        set,
+		Items:      items,
+		CreateDate: now,
+	}
+
+	err := canary.SetPercent(percent)
+	if err != nil {
+		return nil, err
+	}
+
+	return canary, nil
+}
+
+// SetPercent changes the percentage of clients served the staged items and records it in the history
+func (canary *Canary) SetPercent(percent int) error {
+	if percent < 0 || percent > CanaryBuckets {
+		return ErrInvalidCanaryPercent
+	}
+
+	now := datetime.UnixUTCNow()
+	canary.Percent = percent
+	canary.UpdateDate = now
+	canary.History = append(canary.History, CanaryStep{Percent: percent, Date: now})
+	return nil
+}
+
+// Staged returns a copy of the set with the staged changes applied over its items
+func (canary Canary) Staged(set ConfigSet) ConfigSet {
+	staged := set.Copy()
+	for _, key := range canary.Removed {
+		delete(staged.Items, key)
+	}
+
+	for key, item := range canary.Items {
+		staged.Items[key] = item
+	}
+
+	return staged
+}
+
+// CanaryChanges returns the staged items that differ from the set items
+// and the sorted keys of the set items missing from the staged ones
+func CanaryChanges(set ConfigSet, staged ConfigItemMap) (ConfigItemMap, []string) {
+	changed := ConfigItemMap{}
+	for key, item := range staged {
+		current, ok := set.Items[key]
+		if !ok || !sameValue(current, item) {
+			changed[key] = item
+		}
+	}
+
+	removed := []string{}
+	for key := range set.Items {
+		if _, ok := staged[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+
+	return changed, removed
+}
+
+// VersionFor returns the version of the set served to the client with the given ID
+// Clients without an ID are always served the stable version
+func (canary Canary) VersionFor(clientID string) ConfigVersion {
+	if clientID != "" && ClientBucket(clientID) < canary.Percent {
+		return CanaryVersion
+	}
+
+	return StableVersion
+}
+
+// ClientBucket returns the bucket between 0 and CanaryBuckets - 1 a client ID falls in
+func ClientBucket(clientID string) int {
+	hash := fnv.New32a()
+	hash.Write([]byte(clientID))
+	return int(hash.Sum32() % CanaryBuckets)
+}
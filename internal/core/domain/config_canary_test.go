@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCanary(t *testing.T) {
+	t.Run("Test clients are bucketed consistently", func(t *testing.T) {
+		for i := 0; i < 1000; i++ {
+			id := fmt.Sprintf("client-%d", i)
+			bucket := ClientBucket(id)
+			if bucket < 0 || bucket >= CanaryBuckets {
+				t.Fatalf("Expected a bucket between 0 and %d for %q, got: %d", CanaryBuckets-1, id, bucket)
+			}
+
+			if ClientBucket(id) != bucket {
+				t.Fatalf("Expected %q to always fall in bucket %d", id, bucket)
+			}
+		}
+	})
+
+	t.Run("Test widening a canary keeps its clients", func(t *testing.T) {
+		canary, err := NewCanary("app", ConfigItemMap{}, 0)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		served := map[string]bool{}
+		for _, percent := range []int{0, 10, 50, 100} {
+			canary.SetPercent(percent)
+			count := 0
+			for i := 0; i < 1000; i++ {
+				id := fmt.Sprintf("client-%d", i)
+				if canary.VersionFor(id) != CanaryVersion {
+					if served[id] {
+						t.Errorf("Expected %q to stay in the canary at %d%%", id, percent)
+					}
+					continue
+				}
+
+				served[id] = true
+				count++
+			}
+
+			if percent == 0 && count != 0 || percent == 100 && count != 1000 {
+				t.Errorf("Expected every client to follow a %d%% canary, got: %d", percent, count)
+			}
+		}
+
+		if canary.VersionFor("") != StableVersion {
+			t.Errorf("Expected clients without ID to get the stable version")
+		}
+
+		if len(canary.History) != 5 {
+			t.Errorf("Expected every percent change in the history, got: %+v", canary.History)
+		}
+
+		if err := canary.SetPercent(-1); err != ErrInvalidCanaryPercent {
+			t.Errorf("Expected error: %v, got: %v", ErrInvalidCanaryPercent, err)
+		}
+	})
+
+	t.Run("Test only staged changes are applied over the set items", func(t *testing.T) {
+		stable := NewConfigSet(
+			"app",
+			*NewConfigItem("timeout", "10s", Plain),
+			*NewConfigItem("retries", 3, Plain),
+			*NewConfigItem("legacy", true, Plain),
+		)
+
+		changed, removed := CanaryChanges(*stable, ConfigItemMap{
+			"timeout": *NewConfigItem("timeout", "30s", Plain),
+			"retries": *NewConfigItem("retries", float64(3), Plain),
+			"banner":  *NewConfigItem("banner", "on", Plain),
+		})
+		if len(changed) != 2 || changed["timeout"].Value != "30s" || changed["banner"].Value != "on" {
+			t.Errorf("Expected only the changed items, got: %+v", changed)
+		}
+
+		if len(removed) != 1 || removed[0] != "legacy" {
+			t.Errorf("Expected the missing keys to be removed, got: %+v", removed)
+		}
+
+		canary, _ := NewCanary("app", changed, 10)
+		canary.Removed = removed
+
+		// Written after the canary was staged
+		stable.Items["retries"] = *NewConfigItem("retries", 5, Plain)
+		staged := canary.Staged(*stable)
+		if len(staged.Items) != 3 || staged.Items["retries"].Value != 5 || staged.Items["timeout"].Value != "30s" {
+			t.Errorf("Expected the changes applied over the current items, got: %+v", staged.Items)
+		}
+
+		if _, ok := stable.Items["legacy"]; !ok {
+			t.Errorf("Expected the set items to be left untouched")
+		}
+	})
+}
@@ -0,0 +1,132 @@
+package domain
+
+import (
+	"encoding/json"
+	"sort"
+)
+
+// ItemPart names a part of a config item reported as changed by a diff
+type ItemPart string
+
+const (
+	ValuePart     ItemPart = "value"
+	TypePart      ItemPart = "type"
+	ValueTypePart ItemPart = "valueType"
+	GeneratorPart ItemPart = "generator"
+	VariantsPart  ItemPart = "variants"
+	MetadataPart  ItemPart = "metadata"
+)
+
+// ItemChange represents a config item present in both sides of a diff with different content
+type ItemChange struct {
+	// The key of the changed item
+	Key string `json:"key"`
+	// The item in the base set
+	From ConfigItem `json:"from"`
+	// The item in the compared set
+	To ConfigItem `json:"to"`
+	// True if the item type is different, e.g. plain -> secret
+	TypeChanged bool `json:"typeChanged"`
+	// The parts of the item that are different
+	Parts []ItemPart `json:"parts"`
+}
+
+// ConfigDiff represents the differences between two config sets
+type ConfigDiff struct {
+	// Items present only in the compared set
+	Added []ConfigItem `json:"added"`
+	// Items present only in the base set
+	Removed []ConfigItem `json:"removed"`
+	// Items present in both sets with different content
+	Changed []ItemChange `json:"changed"`
+}
+
+// Empty returns true if both sets have the same items
+func (diff ConfigDiff) Empty() bool {
+	return len(diff.Added) == 0 && len(diff.Removed) == 0 && len(diff.Changed) == 0
+}
+
+// DiffSets reports the changes needed to turn the base set items into the compared set items.
+// Every part of an item is compared: value, type, value type, generator, variants and metadata.
+// Values are compared as stored, so secrets and nested sets are compared by reference name
+// and never by their resolved value. Results are sorted by key.
+func DiffSets(base ConfigSet, compared ConfigSet) ConfigDiff {
+	diff := ConfigDiff{
+		Added:   []ConfigItem{},
+		Removed: []ConfigItem{},
+		Changed: []ItemChange{},
+	}
+
+	for key, to := range compared.Items {
+		from, ok := base.Items[key]
+		if !ok {
+			diff.Added = append(diff.Added, to)
+			continue
+		}
+
+		parts := changedParts(from, to)
+		if len(parts) > 0 {
+			diff.Changed = append(diff.Changed, ItemChange{
+				Key:         key,
+				From:        from,
+				To:          to,
+				TypeChanged: from.Type != to.Type,
+				Parts:       parts,
+			})
+		}
+	}
+
+	for key, from := range base.Items {
+		if _, ok := compared.Items[key]; !ok {
+			diff.Removed = append(diff.Removed, from)
+		}
+	}
+
+	sort.Slice(diff.Added, func(i, j int) bool { return diff.Added[i].Key < diff.Added[j].Key })
+	sort.Slice(diff.Removed, func(i, j int) bool { return diff.Removed[i].Key < diff.Removed[j].Key })
+	sort.Slice(diff.Changed, func(i, j int) bool { return diff.Changed[i].Key < diff.Changed[j].Key })
+	return diff
+}
+
+// changedParts returns the parts that differ between two versions of an item, in a fixed order
+func changedParts(from ConfigItem, to ConfigItem) []ItemPart {
+	parts := []ItemPart{}
+	if !sameValue(from.Value, to.Value) {
+		parts = append(parts, ValuePart)
+	}
+
+	if from.Type != to.Type {
+		parts = append(parts, TypePart)
+	}
+
+	if from.ValueType != to.ValueType {
+		parts = append(parts, ValueTypePart)
+	}
+
+	if !sameValue(from.Generator, to.Generator) {
+		parts = append(parts, GeneratorPart)
+	}
+
+	// No variants may be stored as null or as an empty list
+	if (len(from.Variants) > 0 || len(to.Variants) > 0) && !sameValue(from.Variants, to.Variants) {
+		parts = append(parts, VariantsPart)
+	}
+
+	if from.ItemMetadata != to.ItemMetadata {
+		parts = append(parts, MetadataPart)
+	}
+
+	return parts
+}
+
+// sameValue compares values by their JSON representation,
+// this way values read from storage (e.g. float64) match the ones written (e.g. int)
+func sameValue(a interface{}, b interface{}) bool {
+	aBytes, aErr := json.Marshal(a)
+	bBytes, bErr := json.Marshal(b)
+	if aErr != nil || bErr != nil {
+		return false
+	}
+
+	return string(aBytes) == string(bBytes)
+}
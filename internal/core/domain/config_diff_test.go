@@ -0,0 +1,111 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffSets(t *testing.T) {
+	t.Run("Test equal sets have an empty diff", func(t *testing.T) {
+		base := NewConfigSet("base", *NewConfigItem("int", 10, Plain))
+		compared := NewConfigSet("compared", *NewConfigItem("int", float64(10), Plain))
+
+		diff := DiffSets(*base, *compared)
+		if !diff.Empty() {
+			t.Errorf("Expected empty diff, got: %+v", diff)
+		}
+	})
+
+	t.Run("Test added, removed and changed items are reported", func(t *testing.T) {
+		base := NewConfigSet(
+			"base",
+			*NewConfigItem("removed", "value", Plain),
+			*NewConfigItem("changed", "old", Plain),
+			*NewConfigItem("password", "plain-password", Plain),
+			*NewConfigItem("same", true, Plain),
+		)
+		compared := NewConfigSet(
+			"compared",
+			*NewConfigItem("added", "value", Plain),
+			*NewConfigItem("changed", "new", Plain),
+			*NewConfigItem("password", "plain-password", Secret),
+			*NewConfigItem("same", true, Plain),
+		)
+
+		diff := DiffSets(*base, *compared)
+
+		expected := ConfigDiff{
+			Added:   []ConfigItem{*NewConfigItem("added", "value", Plain)},
+			Removed: []ConfigItem{*NewConfigItem("removed", "value", Plain)},
+			Changed: []ItemChange{
+				{
+					Key:   "changed",
+					From:  *NewConfigItem("changed", "old", Plain),
+					To:    *NewConfigItem("changed", "new", Plain),
+					Parts: []ItemPart{ValuePart},
+				},
+				{
+					Key:         "password",
+					From:        *NewConfigItem("password", "plain-password", Plain),
+					To:          *NewConfigItem("password", "plain-password", Secret),
+					TypeChanged: true,
+					Parts:       []ItemPart{TypePart},
+				},
+			},
+		}
+
+		if !cmp.Equal(diff, expected) {
+			t.Errorf("Expected diff: %+v, got: %+v", expected, diff)
+		}
+	})
+
+	t.Run("Test secrets are compared by reference name", func(t *testing.T) {
+		base := NewConfigSet("base", *NewConfigItem("password", "dev/db/password", Secret))
+		compared := NewConfigSet("compared", *NewConfigItem("password", "prod/db/password", Secret))
+
+		diff := DiffSets(*base, *compared)
+		if len(diff.Changed) != 1 || diff.Changed[0].TypeChanged {
+			t.Errorf("Expected one value change, got: %+v", diff)
+		}
+	})
+	t.Run("Test every part of an item is compared", func(t *testing.T) {
+		item := *NewConfigItem("token", "value", Plain)
+		item.Variants = []Variant{}
+
+		typed := item
+		typed.ValueType = StringValue
+		generated := item
+		generated.Generator = &Generator{Kind: UUIDGenerator}
+		variant := item
+		variant.Variants = []Variant{{When: VariantCondition{Regions: []string{"eu"}}, Value: "eu-value"}}
+		documented := item
+		documented.Description = "API token"
+		documented.Sensitive = true
+
+		tests := []struct {
+			to       ConfigItem
+			expected []ItemPart
+		}{
+			{*NewConfigItem("token", "value", Plain), nil},
+			{typed, []ItemPart{ValueTypePart}},
+			{generated, []ItemPart{GeneratorPart}},
+			{variant, []ItemPart{VariantsPart}},
+			{documented, []ItemPart{MetadataPart}},
+		}
+
+		for _, test := range tests {
+			diff := DiffSets(*NewConfigSet("base", item), *NewConfigSet("compared", test.to))
+			if test.expected == nil {
+				if !diff.Empty() {
+					t.Errorf("Expected empty diff, got: %+v", diff)
+				}
+				continue
+			}
+
+			if len(diff.Changed) != 1 || !cmp.Equal(diff.Changed[0].Parts, test.expected) {
+				t.Errorf("Expected changed parts: %v, got: %+v", test.expected, diff.Changed)
+			}
+		}
+	})
+}
@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Possible errors during set freezes
+var (
+	// The freeze would end in the past
+	ErrUntilInPast = errors.New("until must be in the future")
+)
+
+// SetFreeze marks a config set as read-only
+type SetFreeze struct {
+	// Why the set can't be changed
+	Reason string `json:"reason,omitempty"`
+	// When does the freeze end, the set stays frozen until it's unfrozen if empty
+	Until *time.Time `json:"until,omitempty"`
+	// When was the set frozen
+	CreateDate time.Time `json:"createDate"`
+}
+
+// Active returns true if the freeze is still in place at the given time
+func (freeze *SetFreeze) Active(now time.Time) bool {
+	return freeze != nil && (freeze.Until == nil || freeze.Until.After(now))
+}
+
+// FreezeWindow is a period of time where no config set can be changed
+type FreezeWindow struct {
+	// When does the window start
+	Start time.Time `json:"start"`
+	// When does the window end, excluded
+	End time.Time `json:"end"`
+	// Why sets can't be changed
+	Reason string `json:"reason,omitempty"`
+}
+
+// Contains returns true if the given time is inside the window
+func (window FreezeWindow) Contains(now time.Time) bool {
+	return !now.Before(window.Start) && now.Before(window.End)
+}
+
+// FrozenError is returned when a change is attempted on a frozen set or during a freeze window
+type FrozenError struct {
+	// The frozen set, empty for freeze windows
+	Set string
+	// Why changes are not allowed
+	Reason string
+	// When are changes allowed again, nil if unknown
+	Until *time.Time
+}
+
+// NewSetFrozenError returns the error for a change attempted on a frozen set
+func NewSetFrozenError(set string, freeze SetFreeze) *FrozenError {
+	return &FrozenError{Set: set, Reason: freeze.Reason, Until: freeze.Until}
+}
+
+// NewWindowFrozenError returns the error for a change attempted during a freeze window
+func NewWindowFrozenError(window FreezeWindow) *FrozenError {
+	end := window.End
+	return &FrozenError{Reason: window.Reason, Until: &end}
+}
+
+func (e *FrozenError) Error() string {
+	msg := "changes are frozen"
+	if e.Set != "" {
+		msg = fmt.Sprintf("config set %q is frozen", e.Set)
+	}
+
+	if e.Until != nil {
+		msg += " until " + e.Until.UTC().Format(time.RFC3339)
+	}
+
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+
+	return msg
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFreeze(t *testing.T) {
+	t.Run("Test freezes end at their until time", func(t *testing.T) {
+		now := time.Date(2021, 11, 26, 12, 0, 0, 0, time.UTC)
+		later := now.Add(time.Hour)
+		cases := []struct {
+			freeze   *SetFreeze
+			expected bool
+		}{
+			{nil, false},
+			{&SetFreeze{}, true},
+			{&SetFreeze{Until: &later}, true},
+			{&SetFreeze{Until: &now}, false},
+		}
+
+		for _, c := range cases {
+			if got := c.freeze.Active(now); got != c.expected {
+				t.Errorf("Expected active: %v for %+v, got: %v", c.expected, c.freeze, got)
+			}
+		}
+	})
+
+	t.Run("Test freeze windows exclude their end", func(t *testing.T) {
+		start := time.Date(2021, 11, 26, 0, 0, 0, 0, time.UTC)
+		config := Config{FreezeWindows: []FreezeWindow{{Start: start, End: start.Add(24 * time.Hour), Reason: "Black Friday"}}}
+		cases := []struct {
+			at       time.Time
+			expected bool
+		}{
+			{start.Add(-time.Second), false},
+			{start, true},
+			{start.Add(12 * time.Hour), true},
+			{start.Add(24 * time.Hour), false},
+		}
+
+		for _, c := range cases {
+			if _, got := config.ActiveFreezeWindow(c.at); got != c.expected {
+				t.Errorf("Expected frozen: %v at %v, got: %v", c.expected, c.at, got)
+			}
+		}
+	})
+
+	t.Run("Test frozen errors explain the freeze", func(t *testing.T) {
+		until := time.Date(2021, 11, 30, 0, 0, 0, 0, time.UTC)
+		cases := []struct {
+			err      *FrozenError
+			expected string
+		}{
+			{NewSetFrozenError("app", SetFreeze{}), `config set "app" is frozen`},
+			{NewSetFrozenError("app", SetFreeze{Reason: "release", Until: &until}), `config set "app" is frozen until 2021-11-30T00:00:00Z: release`},
+			{NewWindowFrozenError(FreezeWindow{End: until, Reason: "Black Friday"}), "changes are frozen until 2021-11-30T00:00:00Z: Black Friday"},
+		}
+
+		for _, c := range cases {
+			if got := c.err.Error(); got != c.expected {
+				t.Errorf("Expected message: %q, got: %q", c.expected, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,128 @@
+package domain
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"math/big"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/sy-software/minerva-go-utils/datetime"
+)
+
+// GeneratorKind represents the kind of value a generated config item produces
+type GeneratorKind string
+
+// Available GeneratorKinds
+const (
+	// A random UUID v4
+	UUIDGenerator GeneratorKind = "uuid"
+	// A random string of Length letters and digits
+	AlphanumericGenerator GeneratorKind = "alphanumeric"
+	// Length random bytes encoded as standard base64
+	BytesGenerator GeneratorKind = "bytes"
+	// The generation time formatted as RFC3339 in UTC
+	TimestampGenerator GeneratorKind = "timestamp"
+)
+
+// Maximum length accepted by generators with a length
+const MaxGeneratorLength = 4096
+
+const alphanumericChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// Possible errors during value generation
+var (
+	// The generator kind is unknown or its length is out of range
+	ErrInvalidGenerator = errors.New("invalid generator, expected uuid, timestamp or alphanumeric and bytes with a length between 1 and 4096")
+	// A config item of type "generated" has no generator
+	ErrMissingGenerator = errors.New("generated config items need a generator")
+	// The config item can't be regenerated because it's not of type "generated"
+	ErrNotGenerated = errors.New("config item is not generated")
+)
+
+// Generator describes how the value of a generated config item is produced
+type Generator struct {
+	// The kind of value produced
+	Kind GeneratorKind `json:"kind"`
+	// Number of characters or bytes for kinds that need it
+	Length int `json:"length,omitempty"`
+}
+
+// Validate checks the generator kind is known and has the length it needs
+func (generator Generator) Validate() error {
+	switch generator.Kind {
+	case UUIDGenerator, TimestampGenerator:
+		if generator.Length != 0 {
+			return ErrInvalidGenerator
+		}
+	case AlphanumericGenerator, BytesGenerator:
+		if generator.Length < 1 || generator.Length > MaxGeneratorLength {
+			return ErrInvalidGenerator
+		}
+	default:
+		return ErrInvalidGenerator
+	}
+
+	return nil
+}
+
+// Generate produces a new random value, random data is read from crypto/rand
+func (generator Generator) Generate() (string, error) {
+	err := generator.Validate()
+	if err != nil {
+		return "", err
+	}
+
+	switch generator.Kind {
+	case UUIDGenerator:
+		id, err := uuid.NewV4()
+		if err != nil {
+			return "", err
+		}
+
+		return id.String(), nil
+	case AlphanumericGenerator:
+		value := make([]byte, generator.Length)
+		max := big.NewInt(int64(len(alphanumericChars)))
+		for i := range value {
+			index, err := rand.Int(rand.Reader, max)
+			if err != nil {
+				return "", err
+			}
+
+			value[i] = alphanumericChars[index.Int64()]
+		}
+
+		return string(value), nil
+	case BytesGenerator:
+		value := make([]byte, generator.Length)
+		_, err := rand.Read(value)
+		if err != nil {
+			return "", err
+		}
+
+		return base64.StdEncoding.EncodeToString(value), nil
+	default:
+		return datetime.UnixUTCNow().Format(time.RFC3339), nil
+	}
+}
+
+// Regenerate returns a copy of a generated item holding a new value
+func (item ConfigItem) Regenerate() (ConfigItem, error) {
+	if item.Type != Generated {
+		return item, ErrNotGenerated
+	}
+
+	if item.Generator == nil {
+		return item, ErrMissingGenerator
+	}
+
+	value, err := item.Generator.Generate()
+	if err != nil {
+		return item, err
+	}
+
+	item.Value = value
+	return item, nil
+}
@@ -0,0 +1,68 @@
+package domain
+
+import (
+	"encoding/base64"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestGenerator(t *testing.T) {
+	t.Run("Test generators produce values of their kind", func(t *testing.T) {
+		cases := []struct {
+			generator Generator
+			check     func(string) bool
+		}{
+			{Generator{Kind: UUIDGenerator}, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString},
+			{Generator{Kind: AlphanumericGenerator, Length: 32}, regexp.MustCompile(`^[A-Za-z0-9]{32}$`).MatchString},
+			{Generator{Kind: BytesGenerator, Length: 16}, func(value string) bool {
+				decoded, err := base64.StdEncoding.DecodeString(value)
+				return err == nil && len(decoded) == 16
+			}},
+			{Generator{Kind: TimestampGenerator}, func(value string) bool {
+				_, err := time.Parse(time.RFC3339, value)
+				return err == nil
+			}},
+		}
+
+		for _, c := range cases {
+			got, err := c.generator.Generate()
+			if err != nil {
+				t.Errorf("Expected no error for %+v, got: %v", c.generator, err)
+				continue
+			}
+
+			if !c.check(got) {
+				t.Errorf("Expected a valid %s value, got: %q", c.generator.Kind, got)
+			}
+		}
+	})
+
+	t.Run("Test invalid generators are rejected", func(t *testing.T) {
+		cases := []Generator{
+			{Kind: "password"},
+			{Kind: AlphanumericGenerator},
+			{Kind: BytesGenerator, Length: MaxGeneratorLength + 1},
+			{Kind: UUIDGenerator, Length: 10},
+		}
+
+		for _, generator := range cases {
+			_, err := generator.Generate()
+			if err != ErrInvalidGenerator {
+				t.Errorf("Expected error: %v for %+v, got: %v", ErrInvalidGenerator, generator, err)
+			}
+		}
+	})
+
+	t.Run("Test only generated items can be regenerated", func(t *testing.T) {
+		_, err := NewConfigItem("key", "value", Plain).Regenerate()
+		if err != ErrNotGenerated {
+			t.Errorf("Expected error: %v, got: %v", ErrNotGenerated, err)
+		}
+
+		_, err = NewConfigItem("key", nil, Generated).Regenerate()
+		if err != ErrMissingGenerator {
+			t.Errorf("Expected error: %v, got: %v", ErrMissingGenerator, err)
+		}
+	})
+}
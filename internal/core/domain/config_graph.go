@@ -0,0 +1,153 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GraphNodeKind represents what a node of the dependency graph stands for
+type GraphNodeKind string
+
+// Available GraphNodeKinds
+const (
+	// A config set
+	SetNode GraphNodeKind = "set"
+	// A value stored in the secret manager
+	SecretNode GraphNodeKind = "secret"
+)
+
+// GraphEdgeKind represents how a config set depends on another node
+type GraphEdgeKind string
+
+// Available GraphEdgeKinds
+const (
+	// A nested item embedding another set
+	NestedEdge GraphEdgeKind = "nested"
+	// The base set of an overlay
+	BaseEdge GraphEdgeKind = "base"
+	// A ref item or a ${set:name} reference inside a plain value
+	ReferenceEdge GraphEdgeKind = "reference"
+	// A secret item or a ${secret:name} reference inside a plain value
+	SecretEdge GraphEdgeKind = "secret"
+)
+
+// GraphNode is a config set or secret in the dependency graph
+type GraphNode struct {
+	// Unique node id, the kind and name joined by a colon, e.g. set:database
+	ID string `json:"id"`
+	// What the node stands for
+	Kind GraphNodeKind `json:"kind"`
+	// The set or secret name
+	Name string `json:"name"`
+	// True if the node is a set referenced by other sets but not stored
+	Missing bool `json:"missing,omitempty"`
+}
+
+// GraphEdge is a dependency from a config set to another node
+type GraphEdge struct {
+	// Id of the set with the dependency
+	From string `json:"from"`
+	// Id of the node the set depends on
+	To string `json:"to"`
+	// How the set depends on the node
+	Kind GraphEdgeKind `json:"kind"`
+	// The item key holding the dependency, empty for bases
+	Key string `json:"key,omitempty"`
+}
+
+// ConfigGraph is the directed graph of dependencies between config sets and secrets
+type ConfigGraph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+}
+
+// SetNodeID returns the graph node id of the set with the given name
+func SetNodeID(name string) string {
+	return string(SetNode) + ":" + name
+}
+
+// SecretNodeID returns the graph node id of the secret with the given name
+func SecretNodeID(name string) string {
+	return string(SecretNode) + ":" + name
+}
+
+// Related returns the subgraph with every node the given node depends on
+// and every node depending on it, directly or transitively
+func (graph ConfigGraph) Related(id string) ConfigGraph {
+	nodes := map[string]bool{id: true}
+	edges := map[int]bool{}
+	graph.walk(id, nodes, edges, func(edge GraphEdge) (string, string) { return edge.From, edge.To })
+	graph.walk(id, nodes, edges, func(edge GraphEdge) (string, string) { return edge.To, edge.From })
+
+	related := ConfigGraph{Nodes: []GraphNode{}, Edges: []GraphEdge{}}
+	for _, node := range graph.Nodes {
+		if nodes[node.ID] {
+			related.Nodes = append(related.Nodes, node)
+		}
+	}
+
+	for i, edge := range graph.Edges {
+		if edges[i] {
+			related.Edges = append(related.Edges, edge)
+		}
+	}
+
+	return related
+}
+
+// walk visits every node reachable from id following edges in the direction given by ends
+func (graph ConfigGraph) walk(id string, nodes map[string]bool, edges map[int]bool, ends func(GraphEdge) (string, string)) {
+	visited := map[string]bool{id: true}
+	pending := []string{id}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+
+		for i, edge := range graph.Edges {
+			from, to := ends(edge)
+			if from != current {
+				continue
+			}
+
+			edges[i] = true
+			nodes[to] = true
+			if !visited[to] {
+				visited[to] = true
+				pending = append(pending, to)
+			}
+		}
+	}
+}
+
+// DOT returns the graph in Graphviz DOT format
+func (graph ConfigGraph) DOT() string {
+	var out strings.Builder
+	out.WriteString("digraph config {\n")
+	for _, node := range graph.Nodes {
+		shape := "box"
+		if node.Kind == SecretNode {
+			shape = "ellipse"
+		}
+
+		style := ""
+		if node.Missing {
+			style = ", style=dashed"
+		}
+
+		fmt.Fprintf(&out, "  %q [label=%q, shape=%s%s];\n", node.ID, node.Name, shape, style)
+	}
+
+	for _, edge := range graph.Edges {
+		label := edge.Key
+		style := ""
+		if edge.Kind == BaseEdge {
+			label = string(BaseEdge)
+			style = ", style=dashed"
+		}
+
+		fmt.Fprintf(&out, "  %q -> %q [label=%q%s];\n", edge.From, edge.To, label, style)
+	}
+
+	out.WriteString("}\n")
+	return out.String()
+}
@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func testGraph() ConfigGraph {
+	return ConfigGraph{
+		Nodes: []GraphNode{
+			{ID: "secret:db/password", Kind: SecretNode, Name: "db/password"},
+			{ID: "set:app", Kind: SetNode, Name: "app"},
+			{ID: "set:app-prod", Kind: SetNode, Name: "app-prod"},
+			{ID: "set:db", Kind: SetNode, Name: "db"},
+			{ID: "set:other", Kind: SetNode, Name: "other"},
+		},
+		Edges: []GraphEdge{
+			{From: "set:app", To: "set:db", Kind: NestedEdge, Key: "database"},
+			{From: "set:app-prod", To: "set:app", Kind: BaseEdge},
+			{From: "set:db", To: "secret:db/password", Kind: SecretEdge, Key: "password"},
+			{From: "set:other", To: "secret:db/password", Kind: SecretEdge, Key: "password"},
+		},
+	}
+}
+
+func TestGraphRelated(t *testing.T) {
+	t.Run("Test related returns dependencies and dependents", func(t *testing.T) {
+		got := testGraph().Related(SetNodeID("app"))
+
+		expected := ConfigGraph{
+			Nodes: []GraphNode{
+				{ID: "secret:db/password", Kind: SecretNode, Name: "db/password"},
+				{ID: "set:app", Kind: SetNode, Name: "app"},
+				{ID: "set:app-prod", Kind: SetNode, Name: "app-prod"},
+				{ID: "set:db", Kind: SetNode, Name: "db"},
+			},
+			Edges: []GraphEdge{
+				{From: "set:app", To: "set:db", Kind: NestedEdge, Key: "database"},
+				{From: "set:app-prod", To: "set:app", Kind: BaseEdge},
+				{From: "set:db", To: "secret:db/password", Kind: SecretEdge, Key: "password"},
+			},
+		}
+
+		if !cmp.Equal(got, expected) {
+			t.Errorf("Expected graph: %+v, got: %+v", expected, got)
+		}
+	})
+}
+
+func TestGraphDOT(t *testing.T) {
+	t.Run("Test graph is formatted as DOT", func(t *testing.T) {
+		graph := ConfigGraph{
+			Nodes: []GraphNode{
+				{ID: "secret:key", Kind: SecretNode, Name: "key"},
+				{ID: "set:app", Kind: SetNode, Name: "app"},
+				{ID: "set:base", Kind: SetNode, Name: "base", Missing: true},
+			},
+			Edges: []GraphEdge{
+				{From: "set:app", To: "secret:key", Kind: SecretEdge, Key: "token"},
+				{From: "set:app", To: "set:base", Kind: BaseEdge},
+			},
+		}
+
+		expected := `digraph config {
+  "secret:key" [label="key", shape=ellipse];
+  "set:app" [label="app", shape=box];
+  "set:base" [label="base", shape=box, style=dashed];
+  "set:app" -> "secret:key" [label="token"];
+  "set:app" -> "set:base" [label="base", style=dashed];
+}
+`
+		got := graph.DOT()
+		if got != expected {
+			t.Errorf("Expected DOT:\n%s\ngot:\n%s", expected, got)
+		}
+	})
+}
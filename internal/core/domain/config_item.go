@@ -1,7 +1,10 @@
 package domain
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/sy-software/minerva-go-utils/datetime"
@@ -18,6 +21,14 @@ const (
 	Plain ConfigType = "plain"
 	// A config value containing a nested config set
 	Nested ConfigType = "nested"
+	// A config value pointing at a single key of a config set, e.g. "database:credentials.user"
+	Ref ConfigType = "ref"
+	// A config value produced once by a generator and then stored, e.g. a random token
+	Generated ConfigType = "generated"
+	// A config value encrypted by the server before it's stored and decrypted only when rendered
+	Sealed ConfigType = "sealed"
+	// An expression evaluated at render time against the other keys of the set, e.g. "workers * 2"
+	Computed ConfigType = "computed"
 )
 
 // Possible errors during config manipulation
@@ -29,8 +40,45 @@ var (
 	ErrInvalidNestedKeyValue = errors.New("invalid key value for nested config")
 	// A config item of type "secret" does not contain a string as value
 	ErrSecretKeyValue = errors.New("invalid key value for secret")
+	// A config item of type "ref" does not contain a "set:path" string as value
+	ErrRefKeyValue = errors.New("invalid key value for ref, expected set:path")
 )
 
+// CycleError is returned when config sets reference each other in a loop
+type CycleError struct {
+	// The set names forming the cycle, the first and last names are the same
+	Chain []string
+}
+
+// NewCycleError creates a CycleError for a path of set names that reaches name again
+func NewCycleError(path []string, name string) *CycleError {
+	chain := []string{}
+	for i, current := range path {
+		if current == name {
+			chain = append(chain, path[i:]...)
+			break
+		}
+	}
+
+	return &CycleError{Chain: append(chain, name)}
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("config cycle detected: %s", strings.Join(e.Chain, " -> "))
+}
+
+// DepthError is returned when nested sets are deeper than the configured maximum
+type DepthError struct {
+	// The nested set names from the rendered set to the first set past the limit
+	Chain []string
+	// The configured maximum depth
+	Max int
+}
+
+func (e *DepthError) Error() string {
+	return fmt.Sprintf("config nesting deeper than %d levels: %s", e.Max, strings.Join(e.Chain, " -> "))
+}
+
 // ConfigItem represents a single config value
 type ConfigItem struct {
 	// The key to access this value inside a set
@@ -39,6 +87,14 @@ type ConfigItem struct {
 	Value interface{} `json:"value"`
 	// The type of config stored in this item
 	Type ConfigType `json:"type"`
+	// The declared type of a plain value, empty for untyped values
+	ValueType ValueType `json:"valueType,omitempty"`
+	// How the value of a "generated" item is produced
+	Generator *Generator `json:"generator,omitempty"`
+	// Alternative values served to matching callers, the first matching variant wins
+	Variants []Variant `json:"variants,omitempty"`
+	// Documentation of the item
+	ItemMetadata
 }
 
 func NewConfigItem(key string, value interface{}, cfgType ConfigType) *ConfigItem {
@@ -61,6 +117,14 @@ type ConfigSet struct {
 	UpdateDate time.Time `json:"updateDate"`
 	// The items contained in this set
 	Items ConfigItemMap `json:"items"`
+	// The name of the set this one overlays, its items are rendered below this set items
+	Base string `json:"base,omitempty"`
+	// JSON Schema the rendered set must satisfy
+	Schema json.RawMessage `json:"schema,omitempty"`
+	// Set if the set is read-only
+	Freeze *SetFreeze `json:"freeze,omitempty"`
+	// Description, owners and labels of the set
+	SetMetadata
 }
 
 // NewConfigSet creates a new config set with the given items
@@ -79,6 +143,17 @@ func NewConfigSet(name string, items ...ConfigItem) *ConfigSet {
 	}
 }
 
+// Copy returns a copy of this set that doesn't share the items map
+func (set ConfigSet) Copy() ConfigSet {
+	items := make(ConfigItemMap, len(set.Items))
+	for key, item := range set.Items {
+		items[key] = item
+	}
+
+	set.Items = items
+	return set
+}
+
 // Add saves the given item into this set
 func (set *ConfigSet) Add(item ConfigItem) error {
 	_, exists := set.Items[item.Key]
@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaskedValue replaces the values of sensitive items in raw set views
+const MaskedValue = "******"
+
+// A replacement key is set on an item that is not deprecated or points at the item itself
+var ErrInvalidReplacement = errors.New("replacedBy requires a deprecated item and a different key")
+
+// ItemMetadata documents a config item
+type ItemMetadata struct {
+	// What is the item used for
+	Description string `json:"description,omitempty"`
+	// True if clients should stop reading this item, it's still rendered
+	Deprecated bool `json:"deprecated,omitempty"`
+	// The key clients should read instead of a deprecated item
+	ReplacedBy string `json:"replacedBy,omitempty"`
+	// True if the value must not be shown in raw set views or logs
+	Sensitive bool `json:"sensitive,omitempty"`
+}
+
+// Validate checks the replacement key of the item with the given key
+func (metadata ItemMetadata) Validate(key string) error {
+	if metadata.ReplacedBy != "" && (!metadata.Deprecated || metadata.ReplacedBy == key) {
+		return ErrInvalidReplacement
+	}
+
+	return nil
+}
+
+// Masked returns a copy of the item with its value and variant values masked if it is sensitive
+func (item ConfigItem) Masked() ConfigItem {
+	if !item.Sensitive {
+		return item
+	}
+
+	item.Value = MaskedValue
+	if len(item.Variants) > 0 {
+		variants := make([]Variant, len(item.Variants))
+		for i, variant := range item.Variants {
+			variants[i] = Variant{When: variant.When, Value: MaskedValue}
+		}
+		item.Variants = variants
+	}
+
+	return item
+}
+
+// Masked returns a copy of the set with the values of its sensitive items masked
+func (set ConfigSet) Masked() ConfigSet {
+	items := make(ConfigItemMap, len(set.Items))
+	for key, item := range set.Items {
+		items[key] = item.Masked()
+	}
+
+	set.Items = items
+	return set
+}
+
+// Masked returns a copy of the revision with the values of its sensitive items masked
+func (revision ConfigRevision) Masked() ConfigRevision {
+	revision.Set = revision.Set.Masked()
+	return revision
+}
+
+// Masked returns a copy of the trashed set with the values of its sensitive items masked
+func (trashed TrashedSet) Masked() TrashedSet {
+	trashed.Set = trashed.Set.Masked()
+	return trashed
+}
+
+// Masked returns a copy of the diff with the values of its sensitive items masked, on either side of a change
+func (diff ConfigDiff) Masked() ConfigDiff {
+	added := make([]ConfigItem, len(diff.Added))
+	for i, item := range diff.Added {
+		added[i] = item.Masked()
+	}
+
+	removed := make([]ConfigItem, len(diff.Removed))
+	for i, item := range diff.Removed {
+		removed[i] = item.Masked()
+	}
+
+	changed := make([]ItemChange, len(diff.Changed))
+	for i, change := range diff.Changed {
+		change.From = change.From.Masked()
+		change.To = change.To.Masked()
+		changed[i] = change
+	}
+
+	diff.Added = added
+	diff.Removed = removed
+	diff.Changed = changed
+	return diff
+}
+
+// Masked returns a copy of the canary with the values of its sensitive items masked
+func (canary Canary) Masked() Canary {
+	items := make(ConfigItemMap, len(canary.Items))
+	for key, item := range canary.Items {
+		items[key] = item.Masked()
+	}
+
+	canary.Items = items
+	return canary
+}
+
+// Masked returns a copy of the change with its item value masked if it is sensitive
+func (change ScheduledChange) Masked() ScheduledChange {
+	change.Item = change.Item.Masked()
+	return change
+}
+
+// Masked returns a copy of the failed change with its item value masked if it is sensitive
+func (failed FailedChange) Masked() FailedChange {
+	failed.ScheduledChange = failed.ScheduledChange.Masked()
+	return failed
+}
+
+// Masked returns a copy of the override with the previous item value masked if it is sensitive
+func (override Override) Masked() Override {
+	override.Previous = override.Previous.Masked()
+	return override
+}
+
+// Deprecation is a deprecated item found while rendering a set
+type Deprecation struct {
+	// The path of the item in the rendered set, e.g. "database.host"
+	Key string `json:"key"`
+	// The path clients should read instead, empty if there is no replacement
+	ReplacedBy string `json:"replacedBy,omitempty"`
+}
+
+func (deprecation Deprecation) String() string {
+	if deprecation.ReplacedBy == "" {
+		return deprecation.Key
+	}
+
+	return fmt.Sprintf("%s (use %s)", deprecation.Key, deprecation.ReplacedBy)
+}
+
+// DeprecationWarning formats the deprecated items as the value of a Warning header
+func DeprecationWarning(deprecations []Deprecation) string {
+	keys := make([]string, len(deprecations))
+	for i, deprecation := range deprecations {
+		keys[i] = deprecation.String()
+	}
+
+	return fmt.Sprintf("299 - %q", "Deprecated config keys: "+strings.Join(keys, ", "))
+}
+
+// RenderedFormat is the version of the RenderedConfig layout saved in the cache,
+// bump it when the layout changes so older entries are rendered again
+const RenderedFormat = 1
+
+// RenderedConfig is a config set rendered as JSON with the deprecated items found rendering it
+type RenderedConfig struct {
+	// The layout version of a cached render, see RenderedFormat
+	Format int `json:"format"`
+	// The rendered values
+	Values json.RawMessage `json:"values"`
+	// The deprecated items rendered, sorted by key
+	Deprecations []Deprecation `json:"deprecations,omitempty"`
+	// The version of the set served to the caller, renders of the past have no version
+	Version ConfigVersion `json:"-"`
+}
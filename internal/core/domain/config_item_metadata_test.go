@@ -0,0 +1,83 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestItemMetadata(t *testing.T) {
+	t.Run("Test replacement keys need a deprecated item", func(t *testing.T) {
+		cases := []struct {
+			metadata ItemMetadata
+			expected error
+		}{
+			{ItemMetadata{Description: "Request timeout"}, nil},
+			{ItemMetadata{Deprecated: true}, nil},
+			{ItemMetadata{Deprecated: true, ReplacedBy: "requestTimeout"}, nil},
+			{ItemMetadata{ReplacedBy: "requestTimeout"}, ErrInvalidReplacement},
+			{ItemMetadata{Deprecated: true, ReplacedBy: "timeout"}, ErrInvalidReplacement},
+		}
+
+		for _, c := range cases {
+			item := NewConfigItem("timeout", "10s", Plain)
+			item.ItemMetadata = c.metadata
+			_, err := item.Normalize()
+			if err != c.expected {
+				t.Errorf("Expected error: %v for %+v, got: %v", c.expected, c.metadata, err)
+			}
+		}
+	})
+
+	t.Run("Test sensitive values are masked", func(t *testing.T) {
+		password := NewConfigItem("password", "hunter2", Plain)
+		password.Sensitive = true
+		password.Variants = []Variant{{When: VariantCondition{Regions: []string{"eu"}}, Value: "dev"}}
+		set := ConfigSet{Name: "db", Items: ConfigItemMap{
+			"host":     *NewConfigItem("host", "localhost", Plain),
+			"password": *password,
+		}}
+
+		masked := set.Masked()
+		if masked.Items["password"].Value != MaskedValue || masked.Items["password"].Variants[0].Value != MaskedValue {
+			t.Errorf("Expected the password to be masked, got: %+v", masked.Items["password"])
+		}
+
+		if masked.Items["host"].Value != "localhost" {
+			t.Errorf("Expected host: localhost, got: %v", masked.Items["host"].Value)
+		}
+
+		if set.Items["password"].Value != "hunter2" || set.Items["password"].Variants[0].Value != "dev" {
+			t.Errorf("Expected the original set to be unchanged, got: %+v", set.Items["password"])
+		}
+	})
+
+	t.Run("Test sensitive values are masked in diffs", func(t *testing.T) {
+		old := ConfigItem{Key: "password", Value: "hunter2", Type: Plain, ItemMetadata: ItemMetadata{Sensitive: true}}
+		current := ConfigItem{Key: "password", Value: "hunter3", Type: Plain, ItemMetadata: ItemMetadata{Sensitive: true}}
+		diff := DiffSets(*NewConfigSet("base", old), *NewConfigSet("compared", current, *NewConfigItem("host", "localhost", Plain)))
+
+		masked := diff.Masked()
+		if masked.Changed[0].From.Value != MaskedValue || masked.Changed[0].To.Value != MaskedValue {
+			t.Errorf("Expected both sides of the change to be masked, got: %+v", masked.Changed[0])
+		}
+
+		if masked.Added[0].Value != "localhost" {
+			t.Errorf("Expected host: localhost, got: %v", masked.Added[0].Value)
+		}
+
+		if diff.Changed[0].From.Value != "hunter2" {
+			t.Errorf("Expected the original diff to be unchanged, got: %+v", diff.Changed[0])
+		}
+	})
+
+	t.Run("Test deprecations are formatted as a warning", func(t *testing.T) {
+		got := DeprecationWarning([]Deprecation{
+			{Key: "timeout", ReplacedBy: "requestTimeout"},
+			{Key: "db.port"},
+		})
+
+		expected := `299 - "Deprecated config keys: timeout (use requestTimeout), db.port"`
+		if got != expected {
+			t.Errorf("Expected: %s, got: %s", expected, got)
+		}
+	})
+}
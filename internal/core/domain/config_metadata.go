@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"strings"
+)
+
+// Possible errors in set metadata
+var (
+	// A label key is empty or a label contains a separator
+	ErrInvalidLabel = errors.New("labels need a key and can't contain ',' or '=' in their key or ',' in their value")
+	// A label selector is not a comma separated list of key=value pairs
+	ErrInvalidLabelSelector = errors.New("label selectors must be comma separated key=value pairs")
+)
+
+// SetMetadata describes a config set and who owns it
+type SetMetadata struct {
+	// What is the set used for
+	Description string `json:"description,omitempty"`
+	// The team owning the set
+	Owner string `json:"owner,omitempty"`
+	// How to reach the owners, e.g. an email or chat channel
+	Contact string `json:"contact,omitempty"`
+	// Arbitrary key/value pairs used to select sets
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Validate checks every label can be used in a selector
+func (metadata SetMetadata) Validate() error {
+	for key, value := range metadata.Labels {
+		if key == "" || strings.ContainsAny(key, ",=") || strings.Contains(value, ",") {
+			return ErrInvalidLabel
+		}
+	}
+
+	return nil
+}
+
+// HasLabels returns true if every given label is set to the same value in the metadata
+func (metadata SetMetadata) HasLabels(labels map[string]string) bool {
+	for key, value := range labels {
+		if current, ok := metadata.Labels[key]; !ok || current != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseLabelSelector reads a selector such as "team=payments,env=prod" into the labels to match
+func ParseLabelSelector(selector string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range strings.Split(selector, ",") {
+		parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, ErrInvalidLabelSelector
+		}
+
+		labels[parts[0]] = parts[1]
+	}
+
+	return labels, nil
+}
+
+// LabelPairs returns the given labels as sorted key=value strings
+func LabelPairs(labels map[string]string) []string {
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+
+	sort.Strings(pairs)
+	return pairs
+}
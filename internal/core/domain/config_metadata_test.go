@@ -0,0 +1,70 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSetMetadata(t *testing.T) {
+	t.Run("Test label selectors are parsed", func(t *testing.T) {
+		cases := []struct {
+			selector string
+			expected map[string]string
+			err      error
+		}{
+			{"team=payments", map[string]string{"team": "payments"}, nil},
+			{"team=payments, env=prod", map[string]string{"team": "payments", "env": "prod"}, nil},
+			{"tier=", map[string]string{"tier": ""}, nil},
+			{"team", nil, ErrInvalidLabelSelector},
+			{"=prod", nil, ErrInvalidLabelSelector},
+			{"team=payments,", nil, ErrInvalidLabelSelector},
+		}
+
+		for _, c := range cases {
+			got, err := ParseLabelSelector(c.selector)
+			if err != c.err || !cmp.Equal(got, c.expected) {
+				t.Errorf("Expected %v, %v for %q, got: %v, %v", c.expected, c.err, c.selector, got, err)
+			}
+		}
+	})
+
+	t.Run("Test labels must be selectable", func(t *testing.T) {
+		cases := []struct {
+			labels   map[string]string
+			expected error
+		}{
+			{map[string]string{"team": "payments", "env": "prod=eu"}, nil},
+			{map[string]string{"": "payments"}, ErrInvalidLabel},
+			{map[string]string{"team=x": "payments"}, ErrInvalidLabel},
+			{map[string]string{"teams": "payments,billing"}, ErrInvalidLabel},
+		}
+
+		for _, c := range cases {
+			err := SetMetadata{Labels: c.labels}.Validate()
+			if err != c.expected {
+				t.Errorf("Expected error: %v for %v, got: %v", c.expected, c.labels, err)
+			}
+		}
+	})
+
+	t.Run("Test sets match every selected label", func(t *testing.T) {
+		metadata := SetMetadata{Labels: map[string]string{"team": "payments", "env": "prod"}}
+		cases := []struct {
+			labels   map[string]string
+			expected bool
+		}{
+			{map[string]string{}, true},
+			{map[string]string{"team": "payments"}, true},
+			{map[string]string{"team": "payments", "env": "prod"}, true},
+			{map[string]string{"team": "payments", "env": "dev"}, false},
+			{map[string]string{"region": "eu"}, false},
+		}
+
+		for _, c := range cases {
+			if got := metadata.HasLabels(c.labels); got != c.expected {
+				t.Errorf("Expected %v for %v, got: %v", c.expected, c.labels, got)
+			}
+		}
+	})
+}
@@ -0,0 +1,42 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Possible errors during item overrides
+var (
+	// The override would expire in the past
+	ErrExpiresAtInPast = errors.New("expiresAt must be in the future")
+	// The scheduled change reverts an override, overrides end by writing the item
+	ErrRevertNotCancellable = errors.New("the revert of an override can't be cancelled, update or remove the item to keep its value")
+)
+
+// Override is an item temporarily replaced, the previous item is restored when the override expires
+type Override struct {
+	// Id of the scheduled change reverting the override
+	ID string `json:"id"`
+	// The name of the set the item belongs to
+	Set string `json:"set"`
+	// The key of the overridden item
+	Key string `json:"key"`
+	// The item restored when the override expires
+	Previous ConfigItem `json:"previous"`
+	// When will the previous item be restored
+	ExpiresAt time.Time `json:"expiresAt"`
+	// When was the override made
+	CreateDate time.Time `json:"createDate"`
+}
+
+// NewOverride returns the override reverted by the given change
+func NewOverride(change ScheduledChange) Override {
+	return Override{
+		ID:         change.ID,
+		Set:        change.Set,
+		Key:        change.Item.Key,
+		Previous:   change.Item,
+		ExpiresAt:  change.EffectiveAt,
+		CreateDate: change.CreateDate,
+	}
+}
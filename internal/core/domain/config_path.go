@@ -0,0 +1,23 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// PathNotFoundError is returned when a path inside a rendered config doesn't exist
+type PathNotFoundError struct {
+	// The full requested path
+	Path []string
+	// Position in Path of the first missing segment
+	Index int
+}
+
+// Segment returns the first missing segment
+func (e *PathNotFoundError) Segment() string {
+	return e.Path[e.Index]
+}
+
+func (e *PathNotFoundError) Error() string {
+	return fmt.Sprintf("path segment %q not found in %q", e.Segment(), strings.Join(e.Path, "/"))
+}
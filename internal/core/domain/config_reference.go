@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ReferenceError is returned when a reference inside a plain value points to a missing set or key
+type ReferenceError struct {
+	// The references being resolved, the last one is the dangling reference.
+	// Each reference is formatted as "set:path"
+	Chain []string
+}
+
+func (e *ReferenceError) Error() string {
+	return fmt.Sprintf("dangling reference: %s", strings.Join(e.Chain, " -> "))
+}
+
+// ComputedError is returned when the expression of a computed item is not valid or can't be evaluated
+type ComputedError struct {
+	// The key of the computed item
+	Key string
+	// The problem found in the expression
+	Err error
+}
+
+func (e *ComputedError) Error() string {
+	return fmt.Sprintf("invalid expression for key %q: %v", e.Key, e.Err)
+}
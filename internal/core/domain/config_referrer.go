@@ -0,0 +1,28 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SetReferrer describes a config set that references another set
+type SetReferrer struct {
+	// The referencing set name
+	Name string `json:"name"`
+	// The keys of the nested or ref items pointing at the referenced set
+	Keys []string `json:"keys,omitempty"`
+	// True if the referenced set is the base of this set
+	Base bool `json:"base,omitempty"`
+}
+
+// ReferencedError is returned when an operation would leave other sets pointing at a missing set
+type ReferencedError struct {
+	// The referenced set
+	Name string
+	// The names of the sets referencing it
+	Referrers []string
+}
+
+func (e *ReferencedError) Error() string {
+	return fmt.Sprintf("config set %q is referenced by: %s", e.Name, strings.Join(e.Referrers, ", "))
+}
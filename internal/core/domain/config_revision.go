@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// RevisionAction represents the operation that produced a revision
+type RevisionAction string
+
+// Available RevisionActions
+const (
+	// The set was created
+	CreateAction RevisionAction = "create"
+	// The set was created as a copy of another set
+	CloneAction RevisionAction = "clone"
+	// An item was added to the set
+	AddItemAction RevisionAction = "add"
+	// An item was updated in the set
+	UpdateItemAction RevisionAction = "update"
+	// An item was removed from the set
+	RemoveItemAction RevisionAction = "remove"
+	// The set was renamed
+	RenameAction RevisionAction = "rename"
+	// The set was rolled back to a previous revision
+	RollbackAction RevisionAction = "rollback"
+	// The base set of an overlay was changed
+	SetBaseAction RevisionAction = "base"
+	// The schema of the set was changed
+	SetSchemaAction RevisionAction = "schema"
+	// References to a renamed set were updated
+	RewriteAction RevisionAction = "rewrite"
+	// The value of a generated item was produced again
+	RegenerateAction RevisionAction = "regenerate"
+	// Sealed items were encrypted again with the active seal key
+	ResealAction RevisionAction = "reseal"
+	// The items staged by a canary replaced the set items
+	PromoteCanaryAction RevisionAction = "promote"
+	// An item was replaced by a temporary override
+	OverrideAction RevisionAction = "override"
+	// An expired override was reverted to the previous item
+	RevertAction RevisionAction = "revert"
+	// The description, owners or labels of the set changed
+	MetadataAction RevisionAction = "metadata"
+	// The set was made read-only
+	FreezeAction RevisionAction = "freeze"
+	// The set can be changed again
+	UnfreezeAction RevisionAction = "unfreeze"
+	// The set was deleted
+	DeleteAction RevisionAction = "delete"
+	// The set was restored from the trash
+	RestoreAction RevisionAction = "restore"
+)
+
+// Possible errors during revision manipulation
+var (
+	// The revision records a deleted set and has no items to restore
+	ErrDeletedRevision = errors.New("can't restore a deleted revision")
+)
+
+// ConfigRevision is an immutable snapshot of a ConfigSet after a write
+type ConfigRevision struct {
+	// Monotonic revision number, starting at 1 for each set name
+	Revision int `json:"revision"`
+	// When was this revision recorded
+	Date time.Time `json:"date"`
+	// The operation that produced this revision
+	Action RevisionAction `json:"action"`
+	// Extra information about the operation, like the affected item key
+	Detail string `json:"detail,omitempty"`
+	// True if after this revision the set no longer exists under this name
+	Deleted bool `json:"deleted,omitempty"`
+	// The set state after the operation
+	Set ConfigSet `json:"set"`
+}
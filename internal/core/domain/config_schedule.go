@@ -0,0 +1,80 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// ChangeOperation represents the item write a scheduled change performs
+type ChangeOperation string
+
+// Available ChangeOperations
+const (
+	// The item is added to the set
+	AddChange ChangeOperation = "add"
+	// The item replaces the set item with the same key
+	UpdateChange ChangeOperation = "update"
+	// The set item with the same key is removed
+	RemoveChange ChangeOperation = "remove"
+)
+
+// Possible errors during change scheduling
+var (
+	// The change operation is not add, update or remove
+	ErrInvalidChangeOperation = errors.New("invalid change operation, expected add, update or remove")
+	// The change item has no key
+	ErrMissingChangeKey = errors.New("scheduled changes need an item key")
+	// The change would be applied in the past
+	ErrEffectiveAtInPast = errors.New("effectiveAt must be in the future")
+)
+
+// ScheduledChange is an item write applied automatically once its effective time is reached
+type ScheduledChange struct {
+	// Unique id of the change, assigned when it's scheduled
+	ID string `json:"id"`
+	// The name of the set the change is applied to
+	Set string `json:"set"`
+	// The item write to perform
+	Operation ChangeOperation `json:"operation"`
+	// The item to write, only the key is used to remove items
+	Item ConfigItem `json:"item"`
+	// When should the change be applied
+	EffectiveAt time.Time `json:"effectiveAt"`
+	// When was the change scheduled
+	CreateDate time.Time `json:"createDate"`
+	// True if the change reverts a temporary override, Item holds the value before the override
+	Revert bool `json:"revert,omitempty"`
+}
+
+// Validate checks the change can be scheduled at the given time
+func (change ScheduledChange) Validate(now time.Time) error {
+	switch change.Operation {
+	case AddChange, UpdateChange, RemoveChange:
+	default:
+		return ErrInvalidChangeOperation
+	}
+
+	if change.Item.Key == "" {
+		return ErrMissingChangeKey
+	}
+
+	if !change.EffectiveAt.After(now) {
+		return ErrEffectiveAtInPast
+	}
+
+	return nil
+}
+
+// FailedChange is a scheduled change the scheduler couldn't apply, kept so it can be reviewed
+type FailedChange struct {
+	ScheduledChange
+	// The error returned while applying the change
+	Error string `json:"error"`
+	// When was the change attempted
+	FailDate time.Time `json:"failDate"`
+}
+
+// NewFailedChange returns the record of a change that failed with the given error
+func NewFailedChange(change ScheduledChange, err error, failDate time.Time) FailedChange {
+	return FailedChange{ScheduledChange: change, Error: err.Error(), FailDate: failDate}
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Possible errors during schema manipulation
+var (
+	// The provided document is not a valid JSON Schema
+	ErrInvalidSchema = errors.New("invalid json schema")
+)
+
+// ValidationError is returned when a rendered config set doesn't satisfy its schema
+type ValidationError struct {
+	// Every violation found, prefixed by the path of the offending value
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("config does not match schema: %s", strings.Join(e.Violations, "; "))
+}
+
+// SetValidation is the result of validating a config set against its schema
+type SetValidation struct {
+	// The validated set name
+	Name string `json:"name"`
+	// The set whose schema was used, it can be a base of the validated set
+	SchemaSet string `json:"schemaSet"`
+	// True if the rendered set satisfies the schema
+	Valid bool `json:"valid"`
+	// Every violation found, prefixed by the path of the offending value
+	Violations []string `json:"violations,omitempty"`
+}
@@ -0,0 +1,125 @@
+package domain
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// Prefix of every sealed value, the full format is sealed:<key id>:<base64 nonce and ciphertext>
+const SealedPrefix = "sealed:"
+
+// Possible errors during value sealing
+var (
+	// A seal key is not valid base64 or is not 16, 24 or 32 bytes long
+	ErrInvalidSealKey = errors.New("invalid seal key, expected a base64 AES key of 16, 24 or 32 bytes and an id without colons")
+	// The active seal key id is not one of the configured keys
+	ErrUnknownActiveSealKey = errors.New("active seal key is not configured")
+	// No seal keys are configured so sealed items can't be stored
+	ErrSealingDisabled = errors.New("sealed values are not enabled, configure sealKeys and activeSealKey")
+	// A config item of type "sealed" does not contain a string as value
+	ErrSealedKeyValue = errors.New("invalid key value for sealed")
+	// The sealed value is malformed, was sealed with an unknown key or was tampered with
+	ErrInvalidSealedValue = errors.New("can't open sealed value")
+)
+
+// Sealer encrypts values with AES-GCM. New values are sealed with the active key,
+// values sealed with any other configured key can still be opened to support key rotation
+type Sealer struct {
+	keys   map[string]cipher.AEAD
+	active string
+}
+
+// NewSealer creates a Sealer from base64 encoded keys by id
+func NewSealer(keys map[string]string, active string) (*Sealer, error) {
+	sealer := Sealer{keys: map[string]cipher.AEAD{}, active: active}
+	for id, encoded := range keys {
+		if id == "" || strings.Contains(id, ":") {
+			return nil, ErrInvalidSealKey
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, ErrInvalidSealKey
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, ErrInvalidSealKey
+		}
+
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, err
+		}
+
+		sealer.keys[id] = aead
+	}
+
+	if _, ok := sealer.keys[active]; !ok {
+		return nil, ErrUnknownActiveSealKey
+	}
+
+	return &sealer, nil
+}
+
+// ActiveKey returns the id of the key used to seal new values
+func (sealer *Sealer) ActiveKey() string {
+	return sealer.active
+}
+
+// Seal encrypts the value with the active key, the key id is authenticated along the value
+func (sealer *Sealer) Seal(value string) (string, error) {
+	aead := sealer.keys[sealer.active]
+	nonce := make([]byte, aead.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		return "", err
+	}
+
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(sealer.active))
+	return SealedPrefix + sealer.active + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a value returned by Seal with any of the configured keys
+func (sealer *Sealer) Open(value string) (string, error) {
+	id, ok := SealedKeyID(value)
+	if !ok {
+		return "", ErrInvalidSealedValue
+	}
+
+	aead, ok := sealer.keys[id]
+	if !ok {
+		return "", ErrInvalidSealedValue
+	}
+
+	data, err := base64.StdEncoding.DecodeString(value[len(SealedPrefix)+len(id)+1:])
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", ErrInvalidSealedValue
+	}
+
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	opened, err := aead.Open(nil, nonce, ciphertext, []byte(id))
+	if err != nil {
+		return "", ErrInvalidSealedValue
+	}
+
+	return string(opened), nil
+}
+
+// SealedKeyID returns the id of the key a value was sealed with, ok is false if the value is not sealed
+func SealedKeyID(value string) (id string, ok bool) {
+	if !strings.HasPrefix(value, SealedPrefix) {
+		return "", false
+	}
+
+	parts := strings.SplitN(value[len(SealedPrefix):], ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", false
+	}
+
+	return parts[0], true
+}
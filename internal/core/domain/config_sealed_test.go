@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+const (
+	testSealKey  = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	otherSealKey = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func TestSealer(t *testing.T) {
+	t.Run("Test sealed values can be opened", func(t *testing.T) {
+		sealer, err := NewSealer(map[string]string{"k1": testSealKey}, "k1")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		sealed, err := sealer.Seal("s3cr3t")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if !strings.HasPrefix(sealed, "sealed:k1:") || strings.Contains(sealed, "s3cr3t") {
+			t.Errorf("Expected a sealed value with the key id, got: %s", sealed)
+		}
+
+		got, err := sealer.Open(sealed)
+		if err != nil || got != "s3cr3t" {
+			t.Errorf("Expected value: s3cr3t, got: %q, %v", got, err)
+		}
+	})
+
+	t.Run("Test values sealed with a rotated key can be opened", func(t *testing.T) {
+		old, _ := NewSealer(map[string]string{"k1": testSealKey}, "k1")
+		sealed, _ := old.Seal("s3cr3t")
+
+		rotated, err := NewSealer(map[string]string{"k1": testSealKey, "k2": otherSealKey}, "k2")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		got, err := rotated.Open(sealed)
+		if err != nil || got != "s3cr3t" {
+			t.Errorf("Expected value: s3cr3t, got: %q, %v", got, err)
+		}
+
+		resealed, _ := rotated.Seal(got)
+		if id, _ := SealedKeyID(resealed); id != "k2" {
+			t.Errorf("Expected value sealed with k2, got: %s", resealed)
+		}
+	})
+
+	t.Run("Test tampered values are rejected", func(t *testing.T) {
+		sealer, _ := NewSealer(map[string]string{"k1": testSealKey, "k2": otherSealKey}, "k1")
+		sealed, _ := sealer.Seal("s3cr3t")
+
+		cases := []string{
+			"s3cr3t",
+			"sealed:k3:" + sealed[len("sealed:k1:"):],
+			"sealed:k2:" + sealed[len("sealed:k1:"):],
+			sealed[:len(sealed)-4] + "AAA=",
+		}
+
+		for _, value := range cases {
+			_, err := sealer.Open(value)
+			if err != ErrInvalidSealedValue {
+				t.Errorf("Expected error: %v for %q, got: %v", ErrInvalidSealedValue, value, err)
+			}
+		}
+	})
+
+	t.Run("Test invalid keys are rejected", func(t *testing.T) {
+		_, err := NewSealer(map[string]string{"k1": "c2hvcnQ="}, "k1")
+		if err != ErrInvalidSealKey {
+			t.Errorf("Expected error: %v, got: %v", ErrInvalidSealKey, err)
+		}
+
+		_, err = NewSealer(map[string]string{"k:1": testSealKey}, "k:1")
+		if err != ErrInvalidSealKey {
+			t.Errorf("Expected error: %v, got: %v", ErrInvalidSealKey, err)
+		}
+
+		_, err = NewSealer(map[string]string{"k1": testSealKey}, "k2")
+		if err != ErrUnknownActiveSealKey {
+			t.Errorf("Expected error: %v, got: %v", ErrUnknownActiveSealKey, err)
+		}
+	})
+}
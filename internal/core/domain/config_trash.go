@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// TrashedSet is a deleted config set kept until it's restored or purged
+type TrashedSet struct {
+	// The set as it was when deleted
+	Set ConfigSet `json:"set"`
+	// When was the set deleted
+	DeleteDate time.Time `json:"deleteDate"`
+	// When will the set be purged, it's kept until purged by hand if empty
+	PurgeDate *time.Time `json:"purgeDate,omitempty"`
+}
+
+// Expired returns true if the set must be purged at the given time
+func (trashed TrashedSet) Expired(now time.Time) bool {
+	return trashed.PurgeDate != nil && !trashed.PurgeDate.After(now)
+}
@@ -0,0 +1,222 @@
+package domain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// ValueType represents the declared type of a plain or sealed config value
+type ValueType string
+
+// Available ValueTypes, an empty ValueType keeps the value as it was written
+const (
+	StringValue   ValueType = "string"
+	IntValue      ValueType = "int"
+	FloatValue    ValueType = "float"
+	BoolValue     ValueType = "bool"
+	DurationValue ValueType = "duration"
+	ListValue     ValueType = "list"
+	MapValue      ValueType = "map"
+	JSONValue     ValueType = "json"
+)
+
+// Valid returns true if this is one of the known value types
+func (valueType ValueType) Valid() bool {
+	switch valueType {
+	case StringValue, IntValue, FloatValue, BoolValue, DurationValue, ListValue, MapValue, JSONValue:
+		return true
+	default:
+		return false
+	}
+}
+
+// Placeholder returns a value of the type standing for a value that is not resolved, e.g. a sealed value
+// Untyped and json values have no placeholder and return nil
+func (valueType ValueType) Placeholder() interface{} {
+	switch valueType {
+	case StringValue:
+		return ""
+	case IntValue:
+		return int64(0)
+	case FloatValue:
+		return float64(0)
+	case BoolValue:
+		return false
+	case DurationValue:
+		return time.Duration(0).String()
+	case ListValue:
+		return []interface{}{}
+	case MapValue:
+		return map[string]interface{}{}
+	default:
+		return nil
+	}
+}
+
+// ValueTypeError is returned when a config item value doesn't match its declared type
+type ValueTypeError struct {
+	// The key of the offending item
+	Key string
+	// The declared type
+	Type ValueType
+	// The item type when it doesn't accept a value type, e.g. "nested"
+	ConfigType ConfigType
+}
+
+func (e *ValueTypeError) Error() string {
+	if e.ConfigType != "" {
+		return fmt.Sprintf("items of type %q can't declare a value type, key %q", e.ConfigType, e.Key)
+	}
+
+	if !e.Type.Valid() {
+		return fmt.Sprintf("unknown value type %q for key %q", e.Type, e.Key)
+	}
+
+	return fmt.Sprintf("value of key %q is not a valid %s", e.Key, e.Type)
+}
+
+// Normalize validates the item value against its declared type
+// and returns a copy of the item holding the canonical value, e.g. "90s" -> "1m30s" for durations
+func (item ConfigItem) Normalize() (ConfigItem, error) {
+	err := item.ItemMetadata.Validate(item.Key)
+	if err != nil {
+		return item, err
+	}
+
+	err = item.validateVariants()
+	if err != nil {
+		return item, err
+	}
+
+	if item.ValueType == "" {
+		return item, nil
+	}
+
+	switch item.Type {
+	case Nested, Secret, Ref, Generated, Computed:
+		return item, &ValueTypeError{Key: item.Key, Type: item.ValueType, ConfigType: item.Type}
+	case Sealed:
+		// The value may be sealed already, it's checked against the type when it's sealed
+		if !item.ValueType.Valid() {
+			return item, &ValueTypeError{Key: item.Key, Type: item.ValueType}
+		}
+
+		return item, nil
+	}
+
+	value, err := item.TypedValue()
+	if err != nil {
+		return item, err
+	}
+
+	item.Value = value
+	// Variant values share the declared type of the item
+	variants := make([]Variant, len(item.Variants))
+	for i, variant := range item.Variants {
+		typed := item
+		typed.Value = variant.Value
+		value, err := typed.TypedValue()
+		if err != nil {
+			return item, err
+		}
+
+		variants[i] = Variant{When: variant.When, Value: value}
+	}
+
+	if len(variants) > 0 {
+		item.Variants = variants
+	}
+	return item, nil
+}
+
+// TypedValue converts the item value to the Go type matching its declared type:
+// int -> int64, float -> float64, bool -> bool, string and duration -> string,
+// list -> []interface{}, map -> map[string]interface{} and json -> any JSON value.
+// Numbers inside lists, maps and json values are kept as json.Number to avoid precision loss.
+// Items without a declared type return their value unchanged
+func (item ConfigItem) TypedValue() (interface{}, error) {
+	if item.ValueType == "" {
+		return item.Value, nil
+	}
+
+	typeErr := &ValueTypeError{Key: item.Key, Type: item.ValueType}
+	if !item.ValueType.Valid() {
+		return nil, typeErr
+	}
+
+	value, err := jsonValue(item.Value)
+	if err != nil {
+		return nil, typeErr
+	}
+
+	switch item.ValueType {
+	case StringValue:
+		if str, ok := value.(string); ok {
+			return str, nil
+		}
+	case IntValue:
+		if number, ok := value.(json.Number); ok {
+			if i, err := strconv.ParseInt(number.String(), 10, 64); err == nil {
+				return i, nil
+			}
+
+			// Integral numbers written as floats, e.g. 1e3
+			f, err := number.Float64()
+			if err == nil && f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+				return int64(f), nil
+			}
+		}
+	case FloatValue:
+		if number, ok := value.(json.Number); ok {
+			if f, err := number.Float64(); err == nil {
+				return f, nil
+			}
+		}
+	case BoolValue:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case DurationValue:
+		if str, ok := value.(string); ok {
+			if duration, err := time.ParseDuration(str); err == nil {
+				return duration.String(), nil
+			}
+		}
+	case ListValue:
+		if list, ok := value.([]interface{}); ok {
+			return list, nil
+		}
+	case MapValue:
+		if m, ok := value.(map[string]interface{}); ok {
+			return m, nil
+		}
+	case JSONValue:
+		return value, nil
+	}
+
+	return nil, typeErr
+}
+
+// jsonValue converts any Go value into its generic JSON representation keeping numbers as json.Number
+func jsonValue(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	var out interface{}
+	err = DecodeJSON(data, &out)
+	return out, err
+}
+
+// DecodeJSON works like json.Unmarshal but keeps numbers as json.Number
+// so big integers are not truncated to float64
+func DecodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	return decoder.Decode(v)
+}
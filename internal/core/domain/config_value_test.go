@@ -0,0 +1,93 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalize(t *testing.T) {
+	t.Run("Test values are converted to their declared type", func(t *testing.T) {
+		cases := []struct {
+			valueType ValueType
+			value     interface{}
+			expected  interface{}
+		}{
+			{StringValue, "text", "text"},
+			{IntValue, 10, int64(10)},
+			{IntValue, float64(10), int64(10)},
+			{IntValue, json.Number("9007199254740993"), int64(9007199254740993)},
+			{FloatValue, json.Number("1.5"), 1.5},
+			{FloatValue, 2, float64(2)},
+			{BoolValue, true, true},
+			{DurationValue, "90s", "1m30s"},
+			{DurationValue, 2 * time.Second, nil},
+			{ListValue, []string{"a", "b"}, []interface{}{"a", "b"}},
+			{MapValue, map[string]int{"a": 1}, map[string]interface{}{"a": json.Number("1")}},
+			{JSONValue, []int{1}, []interface{}{json.Number("1")}},
+		}
+
+		for _, c := range cases {
+			item, err := NewConfigItem("key", c.value, Plain).withValueType(c.valueType).Normalize()
+			if c.expected == nil {
+				if err == nil {
+					t.Errorf("Expected %v to be an invalid %s, got: %v", c.value, c.valueType, item.Value)
+				}
+
+				continue
+			}
+
+			if err != nil {
+				t.Errorf("Expected %v to be a valid %s, got: %v", c.value, c.valueType, err)
+			}
+
+			if !cmp.Equal(item.Value, c.expected) {
+				t.Errorf("Expected %s value: %#v, got: %#v", c.valueType, c.expected, item.Value)
+			}
+		}
+	})
+
+	t.Run("Test values not matching their declared type are rejected", func(t *testing.T) {
+		cases := []struct {
+			valueType ValueType
+			value     interface{}
+		}{
+			{StringValue, 10},
+			{IntValue, "10"},
+			{IntValue, 1.5},
+			{IntValue, json.Number("1e30")},
+			{FloatValue, "1.5"},
+			{BoolValue, "true"},
+			{DurationValue, "ten seconds"},
+			{ListValue, map[string]interface{}{}},
+			{MapValue, []interface{}{}},
+			{"date", "2021-01-01"},
+		}
+
+		for _, c := range cases {
+			_, err := NewConfigItem("key", c.value, Plain).withValueType(c.valueType).Normalize()
+			if _, ok := err.(*ValueTypeError); !ok {
+				t.Errorf("Expected %v to be an invalid %s, got: %v", c.value, c.valueType, err)
+			}
+		}
+	})
+
+	t.Run("Test nested and secret items can't declare a value type", func(t *testing.T) {
+		_, err := NewConfigItem("key", "other", Nested).withValueType(StringValue).Normalize()
+		if _, ok := err.(*ValueTypeError); !ok {
+			t.Errorf("Expected a value type error, got: %v", err)
+		}
+
+		_, err = NewConfigItem("key", "db/password", Secret).withValueType(StringValue).Normalize()
+		if _, ok := err.(*ValueTypeError); !ok {
+			t.Errorf("Expected a value type error, got: %v", err)
+		}
+	})
+}
+
+func (item *ConfigItem) withValueType(valueType ValueType) ConfigItem {
+	item.ValueType = valueType
+	return *item
+}
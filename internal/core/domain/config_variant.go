@@ -0,0 +1,206 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Possible errors during variant validation
+var (
+	// Variants are only supported by plain and secret config items
+	ErrVariantsNotSupported = errors.New("variants are only supported by plain and secret config items")
+	// A variant condition has no attribute to match or an invalid version range
+	ErrInvalidVariant = errors.New("invalid variant, its condition needs at least one attribute and versions must be dotted numbers")
+)
+
+// RequestAttributes describes the caller of a config read, used to pick item variants
+type RequestAttributes struct {
+	// The name of the client application
+	Client string `json:"client,omitempty"`
+	// The region the client runs in
+	Region string `json:"region,omitempty"`
+	// The version of the client as dotted numbers, e.g. 1.4.2
+	Version string `json:"version,omitempty"`
+	// Free form labels of the client
+	Labels map[string]string `json:"labels,omitempty"`
+	// A stable ID of the client instance, used to bucket clients during canary rollouts
+	ClientID string `json:"clientId,omitempty"`
+}
+
+// Empty returns true if no attribute is set
+func (attrs RequestAttributes) Empty() bool {
+	return attrs.Client == "" && attrs.Region == "" && attrs.Version == "" && len(attrs.Labels) == 0 && attrs.ClientID == ""
+}
+
+// String returns a stable representation of the attributes, labels are sorted by key
+// Client IDs are represented by their canary bucket since clients in the same bucket get the same values
+func (attrs RequestAttributes) String() string {
+	labels := make([]string, 0, len(attrs.Labels))
+	for key, value := range attrs.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+
+	bucket := ""
+	if attrs.ClientID != "" {
+		bucket = strconv.Itoa(ClientBucket(attrs.ClientID))
+	}
+
+	return strings.Join([]string{attrs.Client, attrs.Region, attrs.Version, strings.Join(labels, ","), bucket}, ";")
+}
+
+// VariantCondition describes which callers receive a variant, all the given attributes must match
+type VariantCondition struct {
+	// The variant matches any of these client names
+	Clients []string `json:"clients,omitempty"`
+	// The variant matches any of these regions
+	Regions []string `json:"regions,omitempty"`
+	// The variant matches client versions greater or equal than this one
+	MinVersion string `json:"minVersion,omitempty"`
+	// The variant matches client versions lower than this one
+	MaxVersion string `json:"maxVersion,omitempty"`
+	// The variant matches clients having all these labels
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// Validate checks the condition matches at least one attribute and its versions are valid
+func (cond VariantCondition) Validate() error {
+	if len(cond.Clients) == 0 && len(cond.Regions) == 0 && cond.MinVersion == "" && cond.MaxVersion == "" && len(cond.Labels) == 0 {
+		return ErrInvalidVariant
+	}
+
+	for _, version := range []string{cond.MinVersion, cond.MaxVersion} {
+		if _, ok := parseVersion(version); version != "" && !ok {
+			return ErrInvalidVariant
+		}
+	}
+
+	return nil
+}
+
+// Matches returns true if the attributes satisfy every part of the condition
+func (cond VariantCondition) Matches(attrs RequestAttributes) bool {
+	if len(cond.Clients) > 0 && !containsValue(cond.Clients, attrs.Client) {
+		return false
+	}
+
+	if len(cond.Regions) > 0 && !containsValue(cond.Regions, attrs.Region) {
+		return false
+	}
+
+	if cond.MinVersion != "" || cond.MaxVersion != "" {
+		version, ok := parseVersion(attrs.Version)
+		if !ok {
+			return false
+		}
+
+		if min, _ := parseVersion(cond.MinVersion); cond.MinVersion != "" && compareVersions(version, min) < 0 {
+			return false
+		}
+
+		if max, _ := parseVersion(cond.MaxVersion); cond.MaxVersion != "" && compareVersions(version, max) >= 0 {
+			return false
+		}
+	}
+
+	for key, value := range cond.Labels {
+		if current, ok := attrs.Labels[key]; !ok || current != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Variant is an alternative value of a config item served to the callers matching its condition
+type Variant struct {
+	// Which callers receive this value
+	When VariantCondition `json:"when"`
+	// The value served instead of the item value, it follows the same rules as the item value
+	Value interface{} `json:"value"`
+}
+
+// ValueFor returns the value of the first variant matching the attributes or the item value if none match
+func (item ConfigItem) ValueFor(attrs RequestAttributes) interface{} {
+	for _, variant := range item.Variants {
+		if variant.When.Matches(attrs) {
+			return variant.Value
+		}
+	}
+
+	return item.Value
+}
+
+// validateVariants checks the variants are supported by the item type and have valid conditions
+func (item ConfigItem) validateVariants() error {
+	if len(item.Variants) == 0 {
+		return nil
+	}
+
+	if item.Type != Plain && item.Type != Secret {
+		return ErrVariantsNotSupported
+	}
+
+	for _, variant := range item.Variants {
+		err := variant.When.Validate()
+		if err != nil {
+			return err
+		}
+
+		if _, ok := variant.Value.(string); item.Type == Secret && !ok {
+			return ErrSecretKeyValue
+		}
+	}
+
+	return nil
+}
+
+// parseVersion splits a version like 1.4.2 or v1.4.2 into its numbers
+func parseVersion(version string) ([]int, bool) {
+	parts := strings.Split(strings.TrimPrefix(version, "v"), ".")
+	numbers := make([]int, len(parts))
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return nil, false
+		}
+
+		numbers[i] = number
+	}
+
+	return numbers, true
+}
+
+// compareVersions returns -1, 0 or 1, missing numbers are treated as zeros so 1.2 equals 1.2.0
+func compareVersions(a []int, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var left, right int
+		if i < len(a) {
+			left = a[i]
+		}
+		if i < len(b) {
+			right = b[i]
+		}
+
+		if left != right {
+			if left < right {
+				return -1
+			}
+			return 1
+		}
+	}
+
+	return 0
+}
+
+func containsValue(list []string, value string) bool {
+	for _, current := range list {
+		if current == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,106 @@
+package domain
+
+import (
+	"testing"
+)
+
+func TestVariants(t *testing.T) {
+	t.Run("Test conditions match every given attribute", func(t *testing.T) {
+		cond := VariantCondition{
+			Clients:    []string{"web", "ios"},
+			Regions:    []string{"eu"},
+			MinVersion: "1.2",
+			MaxVersion: "2.0.0",
+			Labels:     map[string]string{"tier": "beta"},
+		}
+		beta := map[string]string{"tier": "beta", "team": "core"}
+
+		cases := []struct {
+			attrs    RequestAttributes
+			expected bool
+		}{
+			{RequestAttributes{Client: "ios", Region: "eu", Version: "v1.10.3", Labels: beta}, true},
+			{RequestAttributes{Client: "android", Region: "eu", Version: "1.10.3", Labels: beta}, false},
+			{RequestAttributes{Client: "ios", Version: "1.10.3", Labels: beta}, false},
+			{RequestAttributes{Client: "web", Region: "eu", Version: "1.2", Labels: beta}, true},
+			{RequestAttributes{Client: "web", Region: "eu", Version: "1.1.9", Labels: beta}, false},
+			{RequestAttributes{Client: "web", Region: "eu", Version: "2.0", Labels: beta}, false},
+			{RequestAttributes{Client: "web", Region: "eu", Version: "latest", Labels: beta}, false},
+			{RequestAttributes{Client: "web", Region: "eu", Version: "1.5", Labels: map[string]string{"tier": "ga"}}, false},
+		}
+
+		for _, c := range cases {
+			if got := cond.Matches(c.attrs); got != c.expected {
+				t.Errorf("Expected %+v to match: %v, got: %v", c.attrs, c.expected, got)
+			}
+		}
+	})
+
+	t.Run("Test the first matching variant wins", func(t *testing.T) {
+		item := NewConfigItem("timeout", 10, Plain)
+		item.Variants = []Variant{
+			{When: VariantCondition{Regions: []string{"eu"}}, Value: 20},
+			{When: VariantCondition{Clients: []string{"web"}}, Value: 30},
+		}
+
+		cases := []struct {
+			attrs    RequestAttributes
+			expected interface{}
+		}{
+			{RequestAttributes{Client: "web", Region: "eu"}, 20},
+			{RequestAttributes{Client: "web", Region: "us"}, 30},
+			{RequestAttributes{Client: "ios"}, 10},
+			{RequestAttributes{}, 10},
+		}
+
+		for _, c := range cases {
+			if got := item.ValueFor(c.attrs); got != c.expected {
+				t.Errorf("Expected value: %v for %+v, got: %v", c.expected, c.attrs, got)
+			}
+		}
+	})
+
+	t.Run("Test invalid variants are rejected", func(t *testing.T) {
+		cases := []struct {
+			item     ConfigItem
+			expected error
+		}{
+			{ConfigItem{Key: "key", Value: "set", Type: Nested, Variants: []Variant{{When: VariantCondition{Regions: []string{"eu"}}, Value: "other"}}}, ErrVariantsNotSupported},
+			{ConfigItem{Key: "key", Value: "value", Type: Plain, Variants: []Variant{{Value: "other"}}}, ErrInvalidVariant},
+			{ConfigItem{Key: "key", Value: "value", Type: Plain, Variants: []Variant{{When: VariantCondition{MinVersion: "one"}, Value: "other"}}}, ErrInvalidVariant},
+			{ConfigItem{Key: "key", Value: "name", Type: Secret, Variants: []Variant{{When: VariantCondition{Regions: []string{"eu"}}, Value: 1}}}, ErrSecretKeyValue},
+		}
+
+		for _, c := range cases {
+			_, err := c.item.Normalize()
+			if err != c.expected {
+				t.Errorf("Expected error: %v for %+v, got: %v", c.expected, c.item.Variants, err)
+			}
+		}
+	})
+
+	t.Run("Test variant values follow the item value type", func(t *testing.T) {
+		item := ConfigItem{
+			Key:       "timeout",
+			Value:     "90s",
+			Type:      Plain,
+			ValueType: DurationValue,
+			Variants:  []Variant{{When: VariantCondition{Regions: []string{"eu"}}, Value: "120s"}},
+		}
+
+		normalized, err := item.Normalize()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if normalized.Variants[0].Value != "2m0s" {
+			t.Errorf("Expected variant value: 2m0s, got: %v", normalized.Variants[0].Value)
+		}
+
+		item.Variants[0].Value = "soon"
+		_, err = item.Normalize()
+		if _, ok := err.(*ValueTypeError); !ok {
+			t.Errorf("Expected a value type error, got: %v", err)
+		}
+	})
+}
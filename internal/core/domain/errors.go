@@ -15,6 +15,8 @@ type RestError struct {
 	Message string `json:"message"`
 	// The error HTTP status code
 	HTTPStatus int `json:"-"`
+	// Optional list of detailed problems, e.g. every schema violation
+	Details []string `json:"details,omitempty"`
 }
 
 func (e *RestError) Error() string {
@@ -29,6 +31,14 @@ const (
 	InvalidParams
 	BadRequest
 	Timeout
+	ConfigCycle
+	InvalidConfig
+	DanglingReference
+	NestingTooDeep
+	SetReferenced
+	InvalidExpression
+	ConfigFrozen
+	TrashConflict
 )
 
 // Return this for any unknown/unhandled error
@@ -80,3 +90,77 @@ func ErrBadRequest(message string) *RestError {
 		HTTPStatus: http.StatusBadRequest,
 	}
 }
+
+// Creates a new error for config sets referencing each other in a loop
+func ErrConfigCycle(err *CycleError) *RestError {
+	return &RestError{
+		Code:       ConfigCycle,
+		Message:    err.Error(),
+		HTTPStatus: http.StatusUnprocessableEntity,
+	}
+}
+
+// Creates a new error for a config set that doesn't satisfy its schema
+func ErrInvalidConfig(err *ValidationError) *RestError {
+	return &RestError{
+		Code:       InvalidConfig,
+		Message:    "config does not match schema",
+		HTTPStatus: http.StatusUnprocessableEntity,
+		Details:    err.Violations,
+	}
+}
+
+// Creates a new error for a reference to a missing set or key
+func ErrDanglingReference(err *ReferenceError) *RestError {
+	return &RestError{
+		Code:       DanglingReference,
+		Message:    err.Error(),
+		HTTPStatus: http.StatusUnprocessableEntity,
+	}
+}
+
+// Creates a new error for nested sets deeper than the configured maximum
+func ErrNestingTooDeep(err *DepthError) *RestError {
+	return &RestError{
+		Code:       NestingTooDeep,
+		Message:    err.Error(),
+		HTTPStatus: http.StatusUnprocessableEntity,
+	}
+}
+
+// Creates a new error for a config set that can't be changed because other sets reference it
+func ErrSetReferenced(err *ReferencedError) *RestError {
+	return &RestError{
+		Code:       SetReferenced,
+		Message:    err.Error(),
+		HTTPStatus: http.StatusConflict,
+		Details:    err.Referrers,
+	}
+}
+
+// Creates a new error for a computed item with an invalid expression
+func ErrInvalidExpression(err *ComputedError) *RestError {
+	return &RestError{
+		Code:       InvalidExpression,
+		Message:    err.Error(),
+		HTTPStatus: http.StatusUnprocessableEntity,
+	}
+}
+
+// Creates a new error for a change attempted on a frozen set or during a freeze window
+func ErrConfigFrozen(err *FrozenError) *RestError {
+	return &RestError{
+		Code:       ConfigFrozen,
+		Message:    err.Error(),
+		HTTPStatus: http.StatusLocked,
+	}
+}
+
+// Creates a new error for a set that can't be deleted while a deleted set with the same name is in the trash
+func ErrTrashConflict(name string) *RestError {
+	return &RestError{
+		Code:       TrashConflict,
+		Message:    fmt.Sprintf("a deleted config set named %q is already in the trash, restore or purge it first", name),
+		HTTPStatus: http.StatusConflict,
+	}
+}
@@ -0,0 +1,194 @@
+package expression
+
+import (
+	"fmt"
+)
+
+// valueType is the type of an expression known before it's evaluated
+type valueType string
+
+const (
+	// The type is only known at evaluation time, e.g. the result of a ternary with different branch types
+	anyType    valueType = "any"
+	nullType   valueType = "null"
+	boolType   valueType = "boolean"
+	intType    valueType = "integer"
+	floatType  valueType = "number"
+	stringType valueType = "string"
+	mapType    valueType = "map"
+	listType   valueType = "list"
+)
+
+// Sampler returns a value with the type a key path will have when the expression is evaluated,
+// ok is false if the key doesn't exist
+type Sampler func(path []string) (value interface{}, ok bool)
+
+// Check verifies every key used by the expression exists and every operator
+// receives values of the types it accepts, without evaluating the expression.
+// Both branches of ternaries and both sides of logical operators are checked
+func (expr *Expression) Check(sample Sampler) error {
+	_, err := check(expr.root, sample)
+	return err
+}
+
+func check(n node, sample Sampler) (valueType, error) {
+	switch n := n.(type) {
+	case literalNode:
+		return typeOf(n.value), nil
+	case pathNode:
+		value, ok := sample(n.path)
+		if !ok {
+			return anyType, &Error{Position: n.pos, Message: fmt.Sprintf("unknown key %q", joinPath(n.path))}
+		}
+
+		return typeOf(normalize(value)), nil
+	case unaryNode:
+		operand, err := check(n.operand, sample)
+		if err != nil {
+			return anyType, err
+		}
+
+		if n.operator == "!" {
+			return boolType, expectType(n, operand, boolType)
+		}
+
+		return operand, expectType(n, operand, intType, floatType)
+	case binaryNode:
+		left, err := check(n.left, sample)
+		if err != nil {
+			return anyType, err
+		}
+
+		right, err := check(n.right, sample)
+		if err != nil {
+			return anyType, err
+		}
+
+		return checkBinary(n, left, right)
+	case conditionalNode:
+		condition, err := check(n.condition, sample)
+		if err != nil {
+			return anyType, err
+		}
+
+		err = expectType(n, condition, boolType)
+		if err != nil {
+			return anyType, err
+		}
+
+		then, err := check(n.then, sample)
+		if err != nil {
+			return anyType, err
+		}
+
+		otherwise, err := check(n.otherwise, sample)
+		if err != nil {
+			return anyType, err
+		}
+
+		if then == otherwise {
+			return then, nil
+		}
+
+		if isNumeric(then) && isNumeric(otherwise) {
+			return floatType, nil
+		}
+
+		return anyType, nil
+	}
+
+	return anyType, &Error{Position: n.position(), Message: "unknown expression"}
+}
+
+func checkBinary(n binaryNode, left valueType, right valueType) (valueType, error) {
+	switch n.operator {
+	case "&&", "||":
+		err := expectType(n, left, boolType)
+		if err == nil {
+			err = expectType(n, right, boolType)
+		}
+		return boolType, err
+	case "==", "!=":
+		return boolType, nil
+	case "<", "<=", ">", ">=":
+		if (left == stringType || left == anyType) && (right == stringType || right == anyType) {
+			return boolType, nil
+		}
+
+		_, err := numericResult(n, left, right)
+		return boolType, err
+	case "+":
+		if (left == stringType || left == anyType) && (right == stringType || right == anyType) {
+			if left == anyType || right == anyType {
+				return anyType, nil
+			}
+			return stringType, nil
+		}
+	}
+
+	return numericResult(n, left, right)
+}
+
+// numericResult returns the type of an arithmetic operation, integer only if both sides are integers
+func numericResult(n binaryNode, left valueType, right valueType) (valueType, error) {
+	err := expectType(n, left, intType, floatType)
+	if err == nil {
+		err = expectType(n, right, intType, floatType)
+	}
+
+	switch {
+	case err != nil:
+		return anyType, err
+	case left == anyType || right == anyType:
+		return anyType, nil
+	case left == intType && right == intType:
+		return intType, nil
+	default:
+		return floatType, nil
+	}
+}
+
+// expectType fails if the type is known and is not one of the expected types
+func expectType(n node, got valueType, expected ...valueType) error {
+	if got == anyType {
+		return nil
+	}
+
+	for _, candidate := range expected {
+		if got == candidate {
+			return nil
+		}
+	}
+
+	name := string(expected[0])
+	if expected[0] == intType {
+		name = string(floatType)
+	}
+
+	return &Error{Position: n.position(), Message: fmt.Sprintf("expected a %s, got %s", name, got)}
+}
+
+func isNumeric(t valueType) bool {
+	return t == intType || t == floatType
+}
+
+func typeOf(value interface{}) valueType {
+	switch value.(type) {
+	case nil:
+		return nullType
+	case bool:
+		return boolType
+	case int64:
+		return intType
+	case float64:
+		return floatType
+	case string:
+		return stringType
+	case map[string]interface{}:
+		return mapType
+	case []interface{}:
+		return listType
+	default:
+		return anyType
+	}
+}
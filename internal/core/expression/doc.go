@@ -0,0 +1,5 @@
+// Package expression contains a small expression language used by computed config items
+// Expressions support numbers, strings, booleans, null, key paths like db.port,
+// arithmetic, comparison, logical operators and the ternary operator, e.g.
+// env == "prod" ? workers * 2 : 1
+package expression
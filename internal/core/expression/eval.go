@@ -0,0 +1,286 @@
+package expression
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Resolver returns the value of a key path used by an expression
+type Resolver func(path []string) (interface{}, error)
+
+// Eval evaluates the expression, numbers are returned as int64 or float64.
+// Errors returned by resolve are returned as they are, other problems are returned as *Error
+func (expr *Expression) Eval(resolve Resolver) (interface{}, error) {
+	value, err := eval(expr.root, resolve)
+	if err != nil {
+		return nil, err
+	}
+
+	if float, ok := value.(float64); ok && (math.IsInf(float, 0) || math.IsNaN(float)) {
+		return nil, &Error{Position: expr.root.position(), Message: "result is not a finite number"}
+	}
+
+	return value, nil
+}
+
+func eval(n node, resolve Resolver) (interface{}, error) {
+	switch n := n.(type) {
+	case literalNode:
+		return n.value, nil
+	case pathNode:
+		value, err := resolve(n.path)
+		if err != nil {
+			return nil, err
+		}
+
+		return normalize(value), nil
+	case unaryNode:
+		operand, err := eval(n.operand, resolve)
+		if err != nil {
+			return nil, err
+		}
+
+		return unary(n, operand)
+	case binaryNode:
+		left, err := eval(n.left, resolve)
+		if err != nil {
+			return nil, err
+		}
+
+		// Logical operators short circuit, so the right side may never be resolved
+		if n.operator == "&&" || n.operator == "||" {
+			leftBool, ok := left.(bool)
+			if !ok {
+				return nil, typeError(n, "boolean", left)
+			}
+
+			if leftBool == (n.operator == "||") {
+				return leftBool, nil
+			}
+		}
+
+		right, err := eval(n.right, resolve)
+		if err != nil {
+			return nil, err
+		}
+
+		return binary(n, left, right)
+	case conditionalNode:
+		condition, err := eval(n.condition, resolve)
+		if err != nil {
+			return nil, err
+		}
+
+		conditionBool, ok := condition.(bool)
+		if !ok {
+			return nil, typeError(n, "boolean", condition)
+		}
+
+		if conditionBool {
+			return eval(n.then, resolve)
+		}
+
+		return eval(n.otherwise, resolve)
+	}
+
+	return nil, &Error{Position: n.position(), Message: "unknown expression"}
+}
+
+func unary(n unaryNode, operand interface{}) (interface{}, error) {
+	switch n.operator {
+	case "!":
+		value, ok := operand.(bool)
+		if !ok {
+			return nil, typeError(n, "boolean", operand)
+		}
+
+		return !value, nil
+	default:
+		switch value := operand.(type) {
+		case int64:
+			return -value, nil
+		case float64:
+			return -value, nil
+		}
+
+		return nil, typeError(n, "number", operand)
+	}
+}
+
+func binary(n binaryNode, left interface{}, right interface{}) (interface{}, error) {
+	switch n.operator {
+	case "&&", "||":
+		value, ok := right.(bool)
+		if !ok {
+			return nil, typeError(n, "boolean", right)
+		}
+
+		return value, nil
+	case "==":
+		return equal(left, right), nil
+	case "!=":
+		return !equal(left, right), nil
+	case "+":
+		leftText, leftIsText := left.(string)
+		rightText, rightIsText := right.(string)
+		if leftIsText && rightIsText {
+			return leftText + rightText, nil
+		}
+	case "<", "<=", ">", ">=":
+		leftText, leftIsText := left.(string)
+		rightText, rightIsText := right.(string)
+		if leftIsText && rightIsText {
+			return compare(n.operator, leftText < rightText, leftText == rightText), nil
+		}
+	}
+
+	leftInt, leftIsInt := left.(int64)
+	rightInt, rightIsInt := right.(int64)
+	if leftIsInt && rightIsInt {
+		return intOperation(n, leftInt, rightInt)
+	}
+
+	leftFloat, leftIsNumber := toFloat(left)
+	rightFloat, rightIsNumber := toFloat(right)
+	if !leftIsNumber {
+		return nil, typeError(n, "number", left)
+	}
+
+	if !rightIsNumber {
+		return nil, typeError(n, "number", right)
+	}
+
+	return floatOperation(n, leftFloat, rightFloat)
+}
+
+func intOperation(n binaryNode, left int64, right int64) (interface{}, error) {
+	switch n.operator {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/", "%":
+		if right == 0 {
+			return nil, &Error{Position: n.pos, Message: "division by zero"}
+		}
+
+		if n.operator == "/" {
+			return left / right, nil
+		}
+		return left % right, nil
+	default:
+		return compare(n.operator, left < right, left == right), nil
+	}
+}
+
+func floatOperation(n binaryNode, left float64, right float64) (interface{}, error) {
+	switch n.operator {
+	case "+":
+		return left + right, nil
+	case "-":
+		return left - right, nil
+	case "*":
+		return left * right, nil
+	case "/":
+		if right == 0 {
+			return nil, &Error{Position: n.pos, Message: "division by zero"}
+		}
+		return left / right, nil
+	case "%":
+		if right == 0 {
+			return nil, &Error{Position: n.pos, Message: "division by zero"}
+		}
+		return math.Mod(left, right), nil
+	default:
+		return compare(n.operator, left < right, left == right), nil
+	}
+}
+
+func compare(operator string, less bool, equal bool) bool {
+	switch operator {
+	case "<":
+		return less
+	case "<=":
+		return less || equal
+	case ">":
+		return !less && !equal
+	default:
+		return !less
+	}
+}
+
+// equal compares numbers by value and any other values strictly
+func equal(left interface{}, right interface{}) bool {
+	leftFloat, leftIsNumber := toFloat(left)
+	rightFloat, rightIsNumber := toFloat(right)
+	if leftIsNumber && rightIsNumber {
+		return leftFloat == rightFloat
+	}
+
+	switch left.(type) {
+	case nil, bool, string:
+		return left == right
+	}
+
+	return false
+}
+
+// normalize converts numbers found in config values to int64 or float64
+func normalize(value interface{}) interface{} {
+	switch number := value.(type) {
+	case int:
+		return int64(number)
+	case int32:
+		return int64(number)
+	case float32:
+		return float64(number)
+	case json.Number:
+		if integer, err := number.Int64(); err == nil {
+			return integer
+		}
+
+		if float, err := number.Float64(); err == nil {
+			return float
+		}
+	}
+
+	return value
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case int64:
+		return float64(number), true
+	case float64:
+		return number, true
+	}
+
+	return 0, false
+}
+
+func typeError(n node, expected string, got interface{}) *Error {
+	return &Error{Position: n.position(), Message: fmt.Sprintf("expected a %s, got %s", expected, typeName(got))}
+}
+
+// typeName describes the type of a value in error messages
+func typeName(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case int64, float64:
+		return "number"
+	case string:
+		return "string"
+	case map[string]interface{}:
+		return "map"
+	case []interface{}:
+		return "list"
+	default:
+		return fmt.Sprintf("%T", value)
+	}
+}
@@ -0,0 +1,177 @@
+package expression
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var testValues = map[string]interface{}{
+	"workers": json.Number("4"),
+	"ratio":   1.5,
+	"env":     "prod",
+	"debug":   false,
+	"db": map[string]interface{}{
+		"port":     5432,
+		"replicas": []interface{}{"a", "b"},
+	},
+}
+
+func testResolver(path []string) (interface{}, error) {
+	value, ok := testSampler(path)
+	if !ok {
+		return nil, errors.New("missing " + joinPath(path))
+	}
+
+	return value, nil
+}
+
+func testSampler(path []string) (interface{}, bool) {
+	var current interface{} = testValues
+	for _, segment := range path {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[segment]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			if segment != "0" && segment != "1" {
+				return nil, false
+			}
+			current = node[segment[0]-'0']
+		default:
+			return nil, false
+		}
+	}
+
+	return current, true
+}
+
+func TestEval(t *testing.T) {
+	t.Run("Test valid expressions", func(t *testing.T) {
+		cases := []struct {
+			source   string
+			expected interface{}
+		}{
+			{"workers * 2", int64(8)},
+			{`env == "prod" ? 50 : 5`, int64(50)},
+			{`env != "prod" ? 50 : 5`, int64(5)},
+			{"workers * ratio", 6.0},
+			{"7 / 2", int64(3)},
+			{"7 / 2.0", 3.5},
+			{"7 % 4", int64(3)},
+			{"-workers + 1", int64(-3)},
+			{"(1 + 2) * 3", int64(9)},
+			{"1 + 2 * 3", int64(7)},
+			{"db.port + 1", int64(5433)},
+			{`db.replicas.1 + "-suffix"`, "b-suffix"},
+			{"!debug && workers >= 4", true},
+			{"debug || workers < 4", false},
+			{"workers == 4.0", true},
+			{`"a" < "b"`, true},
+			{"null == null", true},
+			{"1e3", 1000.0},
+			{`"quote \" inside"`, `quote " inside`},
+			{"debug ? 1 : workers > 2 ? 2 : 3", int64(2)},
+		}
+
+		for _, c := range cases {
+			expr, err := Parse(c.source)
+			if err != nil {
+				t.Errorf("Expected %q to parse, got: %v", c.source, err)
+				continue
+			}
+
+			err = expr.Check(testSampler)
+			if err != nil {
+				t.Errorf("Expected %q to check, got: %v", c.source, err)
+			}
+
+			got, err := expr.Eval(testResolver)
+			if err != nil {
+				t.Errorf("Expected %q to evaluate, got: %v", c.source, err)
+				continue
+			}
+
+			if !cmp.Equal(got, c.expected) {
+				t.Errorf("Expected %q to be: %v (%T), got: %v (%T)", c.source, c.expected, c.expected, got, got)
+			}
+		}
+	})
+
+	t.Run("Test syntax errors", func(t *testing.T) {
+		cases := []struct {
+			source   string
+			position int
+		}{
+			{"workers *", 10},
+			{"(workers", 9},
+			{"workers workers", 9},
+			{"env ? 1", 8},
+			{`"open`, 1},
+			{"db.", 4},
+			{"1.2.3", 1},
+			{"workers # 2", 9},
+		}
+
+		for _, c := range cases {
+			_, err := Parse(c.source)
+			exprErr, ok := err.(*Error)
+			if !ok {
+				t.Errorf("Expected %q to fail with an expression error, got: %v", c.source, err)
+				continue
+			}
+
+			if exprErr.Position != c.position {
+				t.Errorf("Expected %q to fail at: %d, got: %v", c.source, c.position, exprErr)
+			}
+		}
+	})
+
+	t.Run("Test type errors are found without evaluating", func(t *testing.T) {
+		cases := []string{
+			`workers * "2"`,
+			"env - 1",
+			"!workers",
+			"workers && true",
+			`debug ? 1 : env * 2`,
+			"missing + 1",
+			"db * 2",
+			`env < 1`,
+		}
+
+		for _, source := range cases {
+			expr, err := Parse(source)
+			if err != nil {
+				t.Errorf("Expected %q to parse, got: %v", source, err)
+				continue
+			}
+
+			if _, ok := expr.Check(testSampler).(*Error); !ok {
+				t.Errorf("Expected %q to fail the type check", source)
+			}
+		}
+	})
+
+	t.Run("Test runtime errors", func(t *testing.T) {
+		cases := []string{"workers / 0", "ratio % 0", "1e308 * 10"}
+		for _, source := range cases {
+			expr, _ := Parse(source)
+			if _, err := expr.Eval(testResolver); err == nil {
+				t.Errorf("Expected %q to fail", source)
+			}
+		}
+	})
+
+	t.Run("Test paths", func(t *testing.T) {
+		expr, _ := Parse(`env == "prod" ? db.port : workers`)
+		expected := [][]string{{"env"}, {"db", "port"}, {"workers"}}
+		if !cmp.Equal(expr.Paths(), expected) {
+			t.Errorf("Expected paths: %v, got: %v", expected, expr.Paths())
+		}
+	})
+}
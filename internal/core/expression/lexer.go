@@ -0,0 +1,115 @@
+package expression
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	eofToken tokenKind = iota
+	numberToken
+	stringToken
+	identToken
+	operatorToken
+)
+
+type token struct {
+	kind tokenKind
+	// The source text of operators and identifiers, the unquoted text of strings
+	text string
+	// The value of number tokens, int64 or float64
+	number interface{}
+	// 1 based position of the token in the source
+	pos int
+}
+
+// Operators sorted so longer operators are matched first
+var operators = []string{"==", "!=", "<=", ">=", "&&", "||", "+", "-", "*", "/", "%", "<", ">", "!", "?", ":", "(", ")", "."}
+
+// tokenize splits the source into tokens, the last token is always eofToken
+func tokenize(source string) ([]token, error) {
+	tokens := []token{}
+	runes := []rune(source)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case unicode.IsDigit(r) && len(tokens) > 0 && tokens[len(tokens)-1].kind == operatorToken && tokens[len(tokens)-1].text == ".":
+			// List indexes inside paths, e.g. servers.0.host
+			start := i
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			tokens = append(tokens, token{kind: identToken, text: string(runes[start:i]), pos: start + 1})
+		case unicode.IsDigit(r):
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				((runes[i] == '+' || runes[i] == '-') && (runes[i-1] == 'e' || runes[i-1] == 'E'))) {
+				i++
+			}
+
+			text := string(runes[start:i])
+			number, err := parseNumber(text)
+			if err != nil {
+				return nil, &Error{Position: start + 1, Message: fmt.Sprintf("invalid number %q", text)}
+			}
+			tokens = append(tokens, token{kind: numberToken, text: text, number: number, pos: start + 1})
+		case r == '"':
+			start := i
+			i++
+			for i < len(runes) && runes[i] != '"' {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+
+			if i >= len(runes) {
+				return nil, &Error{Position: start + 1, Message: "unterminated string"}
+			}
+
+			i++
+			text, err := strconv.Unquote(string(runes[start:i]))
+			if err != nil {
+				return nil, &Error{Position: start + 1, Message: "invalid string"}
+			}
+			tokens = append(tokens, token{kind: stringToken, text: text, pos: start + 1})
+		case r == '_' || unicode.IsLetter(r):
+			start := i
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{kind: identToken, text: string(runes[start:i]), pos: start + 1})
+		default:
+			operator := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(string(runes[i:]), candidate) {
+					operator = candidate
+					break
+				}
+			}
+
+			if operator == "" {
+				return nil, &Error{Position: i + 1, Message: fmt.Sprintf("unexpected character %q", r)}
+			}
+			tokens = append(tokens, token{kind: operatorToken, text: operator, pos: i + 1})
+			i += len(operator)
+		}
+	}
+
+	return append(tokens, token{kind: eofToken, pos: len(runes) + 1}), nil
+}
+
+// parseNumber returns an int64 for integer literals and a float64 otherwise
+func parseNumber(text string) (interface{}, error) {
+	if !strings.ContainsAny(text, ".eE") {
+		return strconv.ParseInt(text, 10, 64)
+	}
+
+	return strconv.ParseFloat(text, 64)
+}
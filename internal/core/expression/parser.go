@@ -0,0 +1,289 @@
+package expression
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error is returned for invalid expressions, both when they are parsed and evaluated
+type Error struct {
+	// 1 based position in the source of the offending token
+	Position int
+	// A human friendly description of the problem
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Position, e.Message)
+}
+
+// Expression is a parsed expression ready to be checked and evaluated
+type Expression struct {
+	source string
+	root   node
+}
+
+type node interface {
+	position() int
+}
+
+type literalNode struct {
+	pos   int
+	value interface{}
+}
+
+type pathNode struct {
+	pos  int
+	path []string
+}
+
+type unaryNode struct {
+	pos      int
+	operator string
+	operand  node
+}
+
+type binaryNode struct {
+	pos      int
+	operator string
+	left     node
+	right    node
+}
+
+type conditionalNode struct {
+	pos       int
+	condition node
+	then      node
+	otherwise node
+}
+
+func (n literalNode) position() int     { return n.pos }
+func (n pathNode) position() int        { return n.pos }
+func (n unaryNode) position() int       { return n.pos }
+func (n binaryNode) position() int      { return n.pos }
+func (n conditionalNode) position() int { return n.pos }
+
+// Binary operators by precedence, from the lowest to the highest
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">="},
+	{"+", "-"},
+	{"*", "/", "%"},
+}
+
+// Parse returns the parsed expression or an *Error if the source is not valid
+func Parse(source string) (*Expression, error) {
+	tokens, err := tokenize(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{tokens: tokens}
+	root, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+
+	if next := p.peek(); next.kind != eofToken {
+		return nil, &Error{Position: next.pos, Message: fmt.Sprintf("unexpected %q", next.text)}
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the expression source
+func (expr *Expression) String() string {
+	return expr.source
+}
+
+// Paths returns every key path used by the expression, e.g. [["db", "port"]] for db.port * 2
+func (expr *Expression) Paths() [][]string {
+	paths := [][]string{}
+	var walk func(n node)
+	walk = func(n node) {
+		switch n := n.(type) {
+		case pathNode:
+			paths = append(paths, n.path)
+		case unaryNode:
+			walk(n.operand)
+		case binaryNode:
+			walk(n.left)
+			walk(n.right)
+		case conditionalNode:
+			walk(n.condition)
+			walk(n.then)
+			walk(n.otherwise)
+		}
+	}
+
+	walk(expr.root)
+	return paths
+}
+
+type parser struct {
+	tokens []token
+	index  int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.index]
+}
+
+func (p *parser) next() token {
+	current := p.tokens[p.index]
+	if current.kind != eofToken {
+		p.index++
+	}
+
+	return current
+}
+
+// accept consumes the next token if it's one of the given operators
+func (p *parser) accept(operators ...string) (token, bool) {
+	current := p.peek()
+	if current.kind != operatorToken {
+		return current, false
+	}
+
+	for _, operator := range operators {
+		if current.text == operator {
+			return p.next(), true
+		}
+	}
+
+	return current, false
+}
+
+func (p *parser) expect(operator string) error {
+	if _, ok := p.accept(operator); !ok {
+		return unexpected(p.peek(), fmt.Sprintf("expected %q", operator))
+	}
+
+	return nil
+}
+
+func (p *parser) conditional() (node, error) {
+	condition, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+
+	question, ok := p.accept("?")
+	if !ok {
+		return condition, nil
+	}
+
+	then, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.expect(":")
+	if err != nil {
+		return nil, err
+	}
+
+	otherwise, err := p.conditional()
+	if err != nil {
+		return nil, err
+	}
+
+	return conditionalNode{pos: question.pos, condition: condition, then: then, otherwise: otherwise}, nil
+}
+
+func (p *parser) binary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.unary()
+	}
+
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		operator, ok := p.accept(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+
+		left = binaryNode{pos: operator.pos, operator: operator.text, left: left, right: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	operator, ok := p.accept("!", "-")
+	if !ok {
+		return p.primary()
+	}
+
+	operand, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+
+	return unaryNode{pos: operator.pos, operator: operator.text, operand: operand}, nil
+}
+
+func (p *parser) primary() (node, error) {
+	current := p.next()
+	switch current.kind {
+	case numberToken:
+		return literalNode{pos: current.pos, value: current.number}, nil
+	case stringToken:
+		return literalNode{pos: current.pos, value: current.text}, nil
+	case identToken:
+		switch current.text {
+		case "true":
+			return literalNode{pos: current.pos, value: true}, nil
+		case "false":
+			return literalNode{pos: current.pos, value: false}, nil
+		case "null":
+			return literalNode{pos: current.pos, value: nil}, nil
+		}
+
+		path := []string{current.text}
+		for {
+			if _, ok := p.accept("."); !ok {
+				return pathNode{pos: current.pos, path: path}, nil
+			}
+
+			segment := p.next()
+			if segment.kind != identToken {
+				return nil, unexpected(segment, "expected a key after \".\"")
+			}
+			path = append(path, segment.text)
+		}
+	case operatorToken:
+		if current.text == "(" {
+			inner, err := p.conditional()
+			if err != nil {
+				return nil, err
+			}
+
+			return inner, p.expect(")")
+		}
+	}
+
+	return nil, unexpected(current, "expected a value")
+}
+
+func unexpected(current token, expected string) *Error {
+	if current.kind == eofToken {
+		return &Error{Position: current.pos, Message: "unexpected end of expression, " + expected}
+	}
+
+	return &Error{Position: current.pos, Message: fmt.Sprintf("unexpected %q, %s", current.text, expected)}
+}
+
+// joinPath formats a key path as written in expressions
+func joinPath(path []string) string {
+	return strings.Join(path, ".")
+}
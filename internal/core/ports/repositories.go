@@ -3,16 +3,22 @@ package ports
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/sy-software/minerva-olive/internal/core/domain"
 )
 
 // Errors
 var (
-	ErrSecretNoExists   = errors.New("secret does not exists")
-	ErrDuplicatedConfig = errors.New("config set already exists")
-	ErrConfigNotExists  = errors.New("config does not exists")
-	ErrOldValue         = errors.New("cached value is older than expected")
+	ErrSecretNoExists    = errors.New("secret does not exists")
+	ErrDuplicatedConfig  = errors.New("config set already exists")
+	ErrConfigNotExists   = errors.New("config does not exists")
+	ErrOldValue          = errors.New("cached value is older than expected")
+	ErrRevisionNotExists = errors.New("revision does not exists")
+	ErrCanaryNotExists   = errors.New("canary does not exists")
+	ErrChangeNotExists   = errors.New("scheduled change does not exists")
+	ErrTrashNotExists    = errors.New("trashed config does not exists")
+	ErrTrashExists       = errors.New("a deleted config set with the same name is already in the trash")
 )
 
 // Repo is an interface to apply CRUD operations over ConfigSet and ConfigItem
@@ -23,6 +29,10 @@ type Repo interface {
 	GetSet(name string) (*domain.ConfigSet, error)
 	// GetSetNames returns all stored ConfigSet names paginated
 	GetSetNames(limit int, skip int) ([]string, error)
+	// GetSetNamesByLabels returns the names of the ConfigSets with every given label paginated, in the same order as GetSetNames
+	GetSetNamesByLabels(labels map[string]string, limit int, skip int) ([]string, error)
+	// ListSets returns all stored ConfigSets paginated
+	ListSets(limit int, skip int) ([]domain.ConfigSet, error)
 	// DeleteSet removes the ConfigSet with the given name
 	DeleteSet(name string) (domain.ConfigSet, error)
 	// AddItem inserts the given ConfigItem into the ConfigSet with setName
@@ -31,6 +41,58 @@ type Repo interface {
 	UpdateItem(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
 	// RemoveItem removes the given ConfigItem from the ConfigSet with setName
 	RemoveItem(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
+	// ReplaceSet overwrites the stored ConfigSet with the same name as the given one
+	ReplaceSet(set domain.ConfigSet) (domain.ConfigSet, error)
+	// AddRevision appends a revision to the history of rev.Set.Name
+	// The revision number and date are assigned by the repository
+	AddRevision(rev domain.ConfigRevision) (domain.ConfigRevision, error)
+	// GetRevisions returns the revisions of the ConfigSet with the given name, newest first, paginated
+	GetRevisions(name string, limit int, skip int) ([]domain.ConfigRevision, error)
+	// GetRevision returns a single revision of the ConfigSet with the given name
+	GetRevision(name string, revision int) (domain.ConfigRevision, error)
+	// GetRevisionAt returns the latest revision of the ConfigSet with the given name recorded at or before the given time
+	GetRevisionAt(name string, at time.Time) (domain.ConfigRevision, error)
+	// AddReferrer records that the ConfigSet named referrer references the ConfigSet named target
+	AddReferrer(target string, referrer string) error
+	// RemoveReferrer removes a record added with AddReferrer
+	RemoveReferrer(target string, referrer string) error
+	// GetReferrers returns the names of the ConfigSets referencing target sorted by name
+	GetReferrers(target string) ([]string, error)
+	// SaveCanary creates or overwrites the canary of the ConfigSet named canary.Set
+	SaveCanary(canary domain.Canary) (domain.Canary, error)
+	// GetCanary returns the canary of the ConfigSet with the given name
+	GetCanary(name string) (domain.Canary, error)
+	// DeleteCanary removes the canary of the ConfigSet with the given name
+	DeleteCanary(name string) (domain.Canary, error)
+	// AddScheduledChange saves a change to be claimed once change.EffectiveAt is reached
+	AddScheduledChange(change domain.ScheduledChange) (domain.ScheduledChange, error)
+	// GetScheduledChanges returns the pending changes of the ConfigSet with the given name sorted by EffectiveAt
+	GetScheduledChanges(name string) ([]domain.ScheduledChange, error)
+	// RemoveScheduledChange removes a pending change of the ConfigSet with the given name
+	// A change can only be removed or claimed once, even by concurrent callers. Claimed changes can't be removed
+	RemoveScheduledChange(name string, id string) (domain.ScheduledChange, error)
+	// ClaimDueChanges leases until leaseUntil and returns up to limit changes with EffectiveAt at or before the given time
+	// and changes whose lease expired at that time. Claimed changes stay listed until they are acknowledged or failed.
+	// Each change is returned to a single caller until its lease expires, even if several replicas claim changes concurrently
+	ClaimDueChanges(at time.Time, leaseUntil time.Time, limit int) ([]domain.ScheduledChange, error)
+	// AckScheduledChange removes a claimed change of the ConfigSet with the given name once it was applied
+	AckScheduledChange(name string, id string) error
+	// FailScheduledChange removes a claimed change and keeps it among the failed changes of its ConfigSet
+	FailScheduledChange(change domain.FailedChange) error
+	// GetFailedChanges returns the failed changes of the ConfigSet with the given name, the latest failure first
+	GetFailedChanges(name string) ([]domain.FailedChange, error)
+	// TrashSet moves the ConfigSet with the given name to the trash, fails with ErrTrashExists if a trashed set has the same name
+	TrashSet(name string, purgeDate *time.Time) (domain.TrashedSet, error)
+	// GetTrashedSets returns the trashed ConfigSets paginated, the next to be purged first
+	GetTrashedSets(limit int, skip int) ([]domain.TrashedSet, error)
+	// RestoreSet moves the trashed ConfigSet with the given name back to the stored sets
+	// Fails with ErrDuplicatedConfig if a set with the same name was created since
+	RestoreSet(name string) (domain.ConfigSet, error)
+	// PurgeSet removes the trashed ConfigSet with the given name
+	// A trashed set can only be purged or restored once, even by concurrent callers
+	PurgeSet(name string) (domain.TrashedSet, error)
+	// PurgeExpiredSets removes and returns up to limit trashed ConfigSets with PurgeDate at or before the given time
+	PurgeExpiredSets(at time.Time, limit int) ([]domain.TrashedSet, error)
 }
 
 // CacheRepo can be implemented to provide manage JSON objects cache
@@ -1,6 +1,10 @@
 package ports
 
-import "github.com/sy-software/minerva-olive/internal/core/domain"
+import (
+	"time"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
 
 // ConfigService wraps the methods to handle configuration operations.
 type ConfigService interface {
@@ -12,18 +16,129 @@ type ConfigService interface {
 	// This includes all secrets as plain text. Ready to be uses by the client.
 	// If a maxAge is specified cache older than maxAge will be discarded.
 	GetSetJson(name string, maxAge int) ([]byte, error)
+	// GetSetJsonAt returns the configuration set as JSON bytes as it was at the given time.
+	// Nested sets are resolved at the same point in time. Cache is never used.
+	GetSetJsonAt(name string, at time.Time) ([]byte, error)
+	// GetSetJsonFor returns the configuration set as JSON bytes using the item variants matching the caller attributes
+	// and the version of the set served to the caller, callers in the buckets of a canary get the staged items.
+	// Cache is only used for the stable version when the attributes besides the client ID are empty.
+	GetSetJsonFor(name string, maxAge int, attrs domain.RequestAttributes) ([]byte, domain.ConfigVersion, error)
+	// RenderSetFor works like GetSetJsonFor and also returns the deprecated items found by the same render,
+	// keyed by their path in the rendered set. Cached renders keep the deprecations found when they were rendered.
+	RenderSetFor(name string, maxAge int, attrs domain.RequestAttributes) (domain.RenderedConfig, error)
+	// RenderSetAt works like GetSetJsonAt and also returns the deprecated items found by the same render.
+	RenderSetAt(name string, at time.Time) (domain.RenderedConfig, error)
+	// GetSetValue returns the value found walking the given path inside the rendered configuration set.
+	// Only secrets along the path or inside the returned value are resolved.
+	// An empty path returns the whole set.
+	GetSetValue(name string, path []string) (interface{}, error)
+	// GetSetValueFor works like GetSetValue picking the item variants matching the caller attributes.
+	// Callers in the buckets of a running canary are served the staged items, the version served is returned.
+	GetSetValueFor(name string, path []string, attrs domain.RequestAttributes) (interface{}, domain.ConfigVersion, error)
 	// GetSetNames returns the names of all configuration sets paginated.
-	GetSetNames(count int, skip int) ([]string, error)
+	// If labels is not empty only the sets with every given label are returned.
+	GetSetNames(count int, skip int, labels map[string]string) ([]string, error)
+	// UpdateMetadata replaces the description, owners and labels of a configuration set.
+	UpdateMetadata(name string, metadata domain.SetMetadata) (domain.ConfigSet, error)
 	// RenameSet renames a configuration set.
-	RenameSet(name string, newName string) (domain.ConfigSet, error)
-	// DeleteSet deletes a configuration set.
-	DeleteSet(name string) (domain.ConfigSet, error)
+	// Sets referencing it are rewritten to the new name if rewrite is true, otherwise the rename is refused.
+	// If any write fails the set keeps its name and no referrer is changed.
+	RenameSet(name string, newName string, rewrite bool) (domain.ConfigSet, error)
+	// CloneSet creates newName with a copy of the items of a configuration set.
+	// If prefix is not empty every nested set below it is cloned too, named with the prefix, and the copies point at each other
+	// through their nested and ref items, interpolations and bases. If any copy can't be created none is kept.
+	// Generated items get new values in the copies unless keepGenerated is true.
+	CloneSet(name string, newName string, prefix string, keepGenerated bool) (domain.ConfigSet, error)
+	// DeleteSet moves a configuration set to the trash, it's purged once the trash retention expires.
+	// The deletion is refused while other sets reference it unless force is true,
+	// and while the trash holds a set with the same name, which must be restored or purged first.
+	DeleteSet(name string, force bool) (domain.ConfigSet, error)
 	// AddItem adds a new item to the configuration set.
 	AddItem(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
 	// UpdateItem updates an existing item in the configuration set.
 	UpdateItem(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
 	// RemoveItem removes an item from the configuration set.
 	RemoveItem(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
+	// RegenerateItem produces a new value for a generated item.
+	RegenerateItem(setName string, key string) (domain.ConfigSet, error)
 	// SetToJson converts a configuration set to JSON bytes.
 	SetToJson(set domain.ConfigSet) ([]byte, error)
+	// GetRevisions returns the revisions of a configuration set, newest first, paginated.
+	GetRevisions(name string, count int, skip int) ([]domain.ConfigRevision, error)
+	// GetRevision returns a single revision of a configuration set.
+	GetRevision(name string, revision int) (domain.ConfigRevision, error)
+	// RollbackSet restores the items of a configuration set from a previous revision.
+	// The restored items go through the same checks as item writes and pending override reverts are cancelled.
+	// The rollback is recorded as a new revision.
+	RollbackSet(name string, revision int) (domain.ConfigSet, error)
+	// DiffSets compares a configuration set against another set or one of its own revisions.
+	// If against is a number it is used as a revision of the same set, otherwise as a set name.
+	DiffSets(name string, against string) (domain.ConfigDiff, error)
+	// SetBase makes a configuration set overlay the base set, an empty base removes the overlay.
+	SetBase(name string, base string) (domain.ConfigSet, error)
+	// GetSetOrigins returns a tree with the same shape as the rendered set
+	// where each value is the name of the set that defined it.
+	GetSetOrigins(name string) (map[string]interface{}, error)
+	// SetSchema attaches a JSON Schema to a configuration set, an empty schema removes it.
+	// Overlays without a schema of their own are validated with the schema of their base.
+	SetSchema(name string, schema []byte) (domain.ConfigSet, error)
+	// ValidateSets checks every configuration set with a schema against it, sets that fail to render are reported as invalid.
+	ValidateSets() ([]domain.SetValidation, error)
+	// GetReferrers returns the configuration sets referencing the given set as a nested or ref item, an interpolation or base.
+	GetReferrers(name string) ([]domain.SetReferrer, error)
+	// RebuildReferrers indexes the references of every stored configuration set.
+	RebuildReferrers() error
+	// ResealSets encrypts again with the active key every sealed item sealed with an older key, returns the changed sets.
+	ResealSets() ([]string, error)
+	// GetGraph returns the dependency graph of every stored configuration set.
+	GetGraph() (domain.ConfigGraph, error)
+	// GetSetGraph returns the part of the dependency graph the given set depends on or is depended by.
+	GetSetGraph(name string) (domain.ConfigGraph, error)
+	// StartCanary stages the changes turning the configuration set items into the given items for the given percentage of clients.
+	// The changes are applied over the current items, so later writes to other items are served too.
+	// Staging again replaces the previous canary of the set.
+	StartCanary(name string, items domain.ConfigItemMap, percent int) (domain.Canary, error)
+	// GetCanary returns the canary of a configuration set.
+	GetCanary(name string) (domain.Canary, error)
+	// SetCanaryPercent changes the percentage of clients served the staged items.
+	SetCanaryPercent(name string, percent int) (domain.Canary, error)
+	// PromoteCanary applies the staged changes over the current configuration set items and removes the canary.
+	PromoteCanary(name string) (domain.ConfigSet, error)
+	// AbortCanary removes the canary of a configuration set, every client gets the set items again.
+	AbortCanary(name string) (domain.Canary, error)
+	// ScheduleChange saves an item change to be applied to change.Set at change.EffectiveAt.
+	ScheduleChange(change domain.ScheduledChange) (domain.ScheduledChange, error)
+	// GetScheduledChanges returns the pending changes of a configuration set, the next one first.
+	GetScheduledChanges(name string) ([]domain.ScheduledChange, error)
+	// CancelScheduledChange removes a pending change of a configuration set.
+	// Override reverts can't be cancelled, later writes of the item cancel them.
+	CancelScheduledChange(name string, id string) (domain.ScheduledChange, error)
+	// GetFailedChanges returns the scheduled changes of a configuration set that couldn't be applied, the latest failure first.
+	GetFailedChanges(name string) ([]domain.FailedChange, error)
+	// ApplyDueChanges applies the pending changes whose effective time was reached and returns the applied ones.
+	// Changes are removed once applied, a replica stopping midway leaves them to be claimed again once their lease expires.
+	// Changes that fail, override reverts included, are kept as failed changes. Changes blocked by a freeze are retried.
+	// Override reverts are applied even to frozen sets.
+	ApplyDueChanges() ([]domain.ScheduledChange, error)
+	// OverrideItem updates an existing item until expiresAt, then the scheduler restores the previous item.
+	// Any later update or removal of the item cancels the revert. If the update fails no revert is left scheduled.
+	OverrideItem(item domain.ConfigItem, setName string, expiresAt time.Time) (domain.ConfigSet, error)
+	// GetTrashedSets returns the deleted configuration sets paginated, the next to be purged first.
+	GetTrashedSets(count int, skip int) ([]domain.TrashedSet, error)
+	// RestoreSet moves a deleted configuration set back from the trash.
+	RestoreSet(name string) (domain.ConfigSet, error)
+	// PurgeSet removes a deleted configuration set from the trash for good.
+	PurgeSet(name string) (domain.TrashedSet, error)
+	// PurgeExpiredSets removes the deleted configuration sets whose trash retention expired and returns them.
+	PurgeExpiredSets() ([]domain.TrashedSet, error)
+	// FreezeSet makes a configuration set read-only until it's unfrozen or until the given time if not nil.
+	// Changes to a frozen set, or to any set during a freeze window, fail with a domain.FrozenError.
+	FreezeSet(name string, reason string, until *time.Time) (domain.ConfigSet, error)
+	// UnfreezeSet allows changes to a frozen configuration set again.
+	UnfreezeSet(name string) (domain.ConfigSet, error)
+	// WithFreezeOverride returns a service allowed to change configuration sets during the freeze windows of the config.
+	// Frozen sets still refuse changes.
+	WithFreezeOverride() ConfigService
+	// GetOverrides returns the active overrides of every configuration set, the next to expire first.
+	GetOverrides() ([]domain.Override, error)
 }
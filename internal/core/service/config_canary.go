@@ -0,0 +1,211 @@
+package service
+
+import (
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+func (service *ConfigService) StartCanary(name string, items domain.ConfigItemMap, percent int) (domain.Canary, error) {
+	current, err := service.GetSet(name)
+	if err != nil {
+		return domain.Canary{}, err
+	}
+
+	err = service.checkFrozen(current)
+	if err != nil {
+		return domain.Canary{}, err
+	}
+
+	staged, err := service.stageItems(current, items)
+	if err != nil {
+		return domain.Canary{}, err
+	}
+
+	changed, removed := domain.CanaryChanges(current, staged)
+	canary, err := domain.NewCanary(name, changed, percent)
+	if err != nil {
+		return domain.Canary{}, err
+	}
+	canary.Removed = removed
+
+	return service.repo.SaveCanary(*canary)
+}
+
+func (service *ConfigService) GetCanary(name string) (domain.Canary, error) {
+	return service.repo.GetCanary(name)
+}
+
+func (service *ConfigService) SetCanaryPercent(name string, percent int) (domain.Canary, error) {
+	err := service.checkSetFrozen(name)
+	if err != nil {
+		return domain.Canary{}, err
+	}
+
+	canary, err := service.repo.GetCanary(name)
+	if err != nil {
+		return canary, err
+	}
+
+	err = canary.SetPercent(percent)
+	if err != nil {
+		return canary, err
+	}
+
+	return service.repo.SaveCanary(canary)
+}
+
+func (service *ConfigService) PromoteCanary(name string) (domain.ConfigSet, error) {
+	set, err := service.GetSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	err = service.checkFrozen(set)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	canary, err := service.repo.GetCanary(name)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	// The set may have changed since the canary was staged, so the result is checked again
+	staged := canary.Staged(set)
+	err = service.checkItems(staged)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	before := references(set)
+	staged.UpdateDate = datetime.UnixUTCNow()
+	set, err = service.repo.ReplaceSet(staged)
+	if err != nil {
+		return set, err
+	}
+
+	service.repo.DeleteCanary(name)
+	service.updateCache(set)
+	service.syncReferrers(name, before, references(set))
+	service.addRevision(set, domain.PromoteCanaryAction, "", false)
+	return set, nil
+}
+
+func (service *ConfigService) AbortCanary(name string) (domain.Canary, error) {
+	err := service.checkSetFrozen(name)
+	if err != nil {
+		return domain.Canary{}, err
+	}
+
+	return service.repo.DeleteCanary(name)
+}
+
+// GetSetJsonFor renders the set picking the item variants matching the caller attributes
+// Callers in the buckets of a running canary are served the staged items.
+// Only renders of the stable items for callers without variant attributes are cached
+func (service *ConfigService) GetSetJsonFor(name string, maxAge int, attrs domain.RequestAttributes) ([]byte, domain.ConfigVersion, error) {
+	rendered, err := service.RenderSetFor(name, maxAge, attrs)
+	return rendered.Values, rendered.Version, err
+}
+
+func (service *ConfigService) RenderSetFor(name string, maxAge int, attrs domain.RequestAttributes) (domain.RenderedConfig, error) {
+	canary, version, err := service.versionFor(name, attrs)
+	if err != nil {
+		return domain.RenderedConfig{Values: []byte{}, Version: version}, err
+	}
+
+	variantAttrs := attrs
+	variantAttrs.ClientID = ""
+	if version == domain.StableVersion && variantAttrs.Empty() {
+		rendered, err := service.renderCached(name, maxAge)
+		rendered.Version = version
+		return rendered, err
+	}
+
+	set, err := service.GetSet(name)
+	if err != nil {
+		return domain.RenderedConfig{Values: []byte{}, Version: version}, err
+	}
+
+	if version == domain.CanaryVersion {
+		set = canary.Staged(set)
+	}
+
+	rendered, err := service.renderJSON(set, &renderContext{attributes: &attrs})
+	rendered.Version = version
+	return rendered, err
+}
+
+func (service *ConfigService) GetSetValueFor(name string, path []string, attrs domain.RequestAttributes) (interface{}, domain.ConfigVersion, error) {
+	canary, version, err := service.versionFor(name, attrs)
+	if err != nil {
+		return nil, version, err
+	}
+
+	set, err := service.GetSet(name)
+	if err != nil {
+		return nil, version, err
+	}
+
+	if version == domain.CanaryVersion {
+		set = canary.Staged(set)
+	}
+
+	value, err := service.valueAt(set, path, &renderContext{attributes: &attrs})
+	return value, version, err
+}
+
+// versionFor picks the version of the set served to the caller, the canary is returned when there is one
+func (service *ConfigService) versionFor(name string, attrs domain.RequestAttributes) (domain.Canary, domain.ConfigVersion, error) {
+	canary, err := service.repo.GetCanary(name)
+	if err == ports.ErrCanaryNotExists {
+		return canary, domain.StableVersion, nil
+	}
+
+	if err != nil {
+		return canary, domain.StableVersion, err
+	}
+
+	return canary, canary.VersionFor(attrs.ClientID), nil
+}
+
+// moveCanary keeps the canary of a renamed set, the rename already happened so errors are ignored
+func (service *ConfigService) moveCanary(name string, newName string) {
+	canary, err := service.repo.DeleteCanary(name)
+	if err != nil {
+		return
+	}
+
+	canary.Set = newName
+	service.repo.SaveCanary(canary)
+}
+
+// stageItems prepares the items of a canary the same way AddItem prepares a single item
+func (service *ConfigService) stageItems(current domain.ConfigSet, items domain.ConfigItemMap) (domain.ConfigItemMap, error) {
+	staged := domain.ConfigItemMap{}
+	for _, key := range sortedKeys(items) {
+		item := items[key]
+		item.Key = key
+		item, err := item.Normalize()
+		if err != nil {
+			return nil, err
+		}
+
+		item, err = generateValue(item, current)
+		if err != nil {
+			return nil, err
+		}
+
+		item, err = service.sealValue(item)
+		if err != nil {
+			return nil, err
+		}
+
+		staged[key] = item
+	}
+
+	candidate := current.Copy()
+	candidate.Items = staged
+	return staged, service.checkItems(candidate)
+}
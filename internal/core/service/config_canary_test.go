@@ -0,0 +1,214 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+// clientInBuckets returns a client ID whose canary bucket is between from and to, to excluded
+func clientInBuckets(from int, to int) string {
+	for i := 0; ; i++ {
+		id := fmt.Sprintf("client-%d", i)
+		if bucket := domain.ClientBucket(id); bucket >= from && bucket < to {
+			return id
+		}
+	}
+}
+
+func TestCanaries(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test canary clients get the staged items", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+
+		items := domain.ConfigItemMap{
+			"timeout": *domain.NewConfigItem("timeout", "30s", domain.Plain),
+			"retries": *domain.NewConfigItem("retries", 3, domain.Plain),
+		}
+		_, err := service.StartCanary("app", items, 10)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		early := clientInBuckets(0, 10)
+		late := clientInBuckets(50, 60)
+		cases := []struct {
+			clientID string
+			version  domain.ConfigVersion
+			expected string
+		}{
+			{"", domain.StableVersion, `{"timeout":"10s"}`},
+			{early, domain.CanaryVersion, `{"retries":3,"timeout":"30s"}`},
+			{late, domain.StableVersion, `{"timeout":"10s"}`},
+		}
+
+		for _, c := range cases {
+			got, version, err := service.GetSetJsonFor("app", domain.AnyAge, domain.RequestAttributes{ClientID: c.clientID})
+			if err != nil {
+				t.Fatalf("Expected no error for %q, got: %v", c.clientID, err)
+			}
+
+			if version != c.version || string(got) != c.expected {
+				t.Errorf("Expected %s: %s for %q, got %s: %s", c.version, c.expected, c.clientID, version, got)
+			}
+		}
+
+		canary, err := service.SetCanaryPercent("app", 60)
+		if err != nil || len(canary.History) != 2 {
+			t.Fatalf("Expected the percent change to be recorded, got: %+v, %v", canary.History, err)
+		}
+
+		_, version, _ := service.GetSetJsonFor("app", domain.AnyAge, domain.RequestAttributes{ClientID: late})
+		if version != domain.CanaryVersion {
+			t.Errorf("Expected widened canary to include %q, got: %s", late, version)
+		}
+
+		_, version, _ = service.GetSetJsonFor("app", domain.AnyAge, domain.RequestAttributes{ClientID: early})
+		if version != domain.CanaryVersion {
+			t.Errorf("Expected %q to stay in the canary, got: %s", early, version)
+		}
+	})
+
+	t.Run("Test promoting a canary replaces the set items", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		service.StartCanary("app", domain.ConfigItemMap{"timeout": *domain.NewConfigItem("timeout", "30s", domain.Plain)}, 10)
+
+		set, err := service.PromoteCanary("app")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if set.Items["timeout"].Value != "30s" {
+			t.Errorf("Expected promoted value: 30s, got: %v", set.Items["timeout"].Value)
+		}
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		if string(got) != `{"timeout":"30s"}` {
+			t.Errorf("Expected every client to get the promoted items, got: %s", got)
+		}
+
+		revisions, _ := service.GetRevisions("app", 1, 0)
+		if len(revisions) != 1 || revisions[0].Action != domain.PromoteCanaryAction {
+			t.Errorf("Expected a promote revision, got: %+v", revisions)
+		}
+
+		_, err = service.GetCanary("app")
+		if err != ports.ErrCanaryNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrCanaryNotExists, err)
+		}
+	})
+
+	t.Run("Test promoting a canary keeps writes made during the rollout", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("legacy", true, domain.Plain), "app")
+		service.StartCanary("app", domain.ConfigItemMap{"timeout": *domain.NewConfigItem("timeout", "30s", domain.Plain)}, 10)
+
+		service.AddItem(*domain.NewConfigItem("retries", 3, domain.Plain), "app")
+		got, _, _ := service.GetSetJsonFor("app", domain.AnyAge, domain.RequestAttributes{ClientID: clientInBuckets(0, 10)})
+		if string(got) != `{"retries":3,"timeout":"30s"}` {
+			t.Errorf("Expected canary clients to get the new item, got: %s", got)
+		}
+
+		_, err := service.PromoteCanary("app")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		got, _ = service.GetSetJson("app", domain.AnyAge)
+		if string(got) != `{"retries":3,"timeout":"30s"}` {
+			t.Errorf("Expected the item written during the canary to be kept, got: %s", got)
+		}
+	})
+
+	t.Run("Test aborting a canary serves the stable items to everyone", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		service.StartCanary("app", domain.ConfigItemMap{"timeout": *domain.NewConfigItem("timeout", "30s", domain.Plain)}, 100)
+
+		_, err := service.AbortCanary("app")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		got, version, _ := service.GetSetJsonFor("app", domain.AnyAge, domain.RequestAttributes{ClientID: "client"})
+		if version != domain.StableVersion || string(got) != `{"timeout":"10s"}` {
+			t.Errorf("Expected stable items, got %s: %s", version, got)
+		}
+	})
+
+	t.Run("Test invalid canaries are rejected", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		_, err := service.StartCanary("app", domain.ConfigItemMap{}, 101)
+		if err != domain.ErrInvalidCanaryPercent {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrInvalidCanaryPercent, err)
+		}
+
+		_, err = service.StartCanary("app", domain.ConfigItemMap{"db": *domain.NewConfigItem("db", "missing:host", domain.Ref)}, 10)
+		if _, ok := err.(*domain.ReferenceError); !ok {
+			t.Errorf("Expected a reference error, got: %v", err)
+		}
+
+		_, err = service.StartCanary("missing", domain.ConfigItemMap{}, 10)
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+
+		_, err = service.SetCanaryPercent("app", 20)
+		if err != ports.ErrCanaryNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrCanaryNotExists, err)
+		}
+	})
+
+	t.Run("Test canaries follow renames and deletes of their set", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.StartCanary("app", domain.ConfigItemMap{"timeout": *domain.NewConfigItem("timeout", "30s", domain.Plain)}, 10)
+
+		service.RenameSet("app", "api", false)
+		canary, err := service.GetCanary("api")
+		if err != nil || canary.Set != "api" {
+			t.Errorf("Expected canary to be renamed, got: %+v, %v", canary, err)
+		}
+
+		service.DeleteSet("api", false)
+		_, err = service.GetCanary("api")
+		if err != ports.ErrCanaryNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrCanaryNotExists, err)
+		}
+	})
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+func (service *ConfigService) CloneSet(name string, newName string, prefix string, keepGenerated bool) (domain.ConfigSet, error) {
+	err := service.checkFreezeWindow()
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	source, err := service.GetSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	names := map[string]string{name: newName}
+	// Nested sets go first so every clone renders once it's created
+	sources := []domain.ConfigSet{}
+	if prefix != "" {
+		sources, err = service.collectNested(source, prefix, names, sources)
+		if err != nil {
+			return domain.ConfigSet{}, err
+		}
+	}
+	sources = append(sources, source)
+
+	taken := map[string]bool{}
+	for _, target := range names {
+		if taken[target] {
+			return domain.ConfigSet{}, ports.ErrDuplicatedConfig
+		}
+		taken[target] = true
+
+		_, err := service.repo.GetSet(target)
+		if err != ports.ErrConfigNotExists {
+			if err == nil {
+				err = ports.ErrDuplicatedConfig
+			}
+			return domain.ConfigSet{}, err
+		}
+	}
+
+	prepared := make([]domain.ConfigSet, 0, len(sources))
+	for _, set := range sources {
+		candidate, err := cloneSet(set, names, keepGenerated)
+		if err != nil {
+			return domain.ConfigSet{}, err
+		}
+
+		prepared = append(prepared, candidate)
+	}
+
+	clones := make([]domain.ConfigSet, 0, len(sources))
+	for _, candidate := range prepared {
+		clone, err := service.repo.CreateSet(candidate)
+		if err != nil {
+			service.undoClone(clones)
+			return domain.ConfigSet{}, err
+		}
+
+		clones = append(clones, clone)
+	}
+
+	for i, clone := range clones {
+		service.updateCache(clone)
+		service.syncReferrers(clone.Name, nil, references(clone))
+		service.addRevision(clone, domain.CloneAction, sources[i].Name, false)
+	}
+
+	return clones[len(clones)-1], nil
+}
+
+// undoClone removes the clones created before a clone failed
+// The clone error is returned to the caller so these errors are only logged
+func (service *ConfigService) undoClone(clones []domain.ConfigSet) {
+	for _, clone := range clones {
+		_, err := service.repo.DeleteSet(clone.Name)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Can't remove set %q after a failed clone", clone.Name)
+		}
+	}
+}
+
+// collectNested appends every set nested below the given one to sources, deepest first,
+// and names its clone with the prefix
+func (service *ConfigService) collectNested(set domain.ConfigSet, prefix string, names map[string]string, sources []domain.ConfigSet) ([]domain.ConfigSet, error) {
+	keys := make([]string, 0, len(set.Items))
+	for key := range set.Items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		item := set.Items[key]
+		if item.Type != domain.Nested {
+			continue
+		}
+
+		target, _ := item.Value.(string)
+		if _, seen := names[target]; seen {
+			continue
+		}
+
+		nested, err := service.GetSet(target)
+		if err != nil {
+			return sources, err
+		}
+
+		names[target] = prefix + target
+		sources, err = service.collectNested(nested, prefix, names, sources)
+		if err != nil {
+			return sources, err
+		}
+		sources = append(sources, nested)
+	}
+
+	return sources, nil
+}
+
+// cloneSet returns a copy of set named after names, with every reference to a cloned set pointing at its clone
+// Generated items get new values so clones don't share tokens and passwords, unless keepGenerated is true
+func cloneSet(set domain.ConfigSet, names map[string]string, keepGenerated bool) (domain.ConfigSet, error) {
+	clone := set.Copy()
+	for source, target := range names {
+		clone = retargetSet(clone, source, target)
+	}
+
+	if !keepGenerated {
+		for key, item := range clone.Items {
+			if item.Type != domain.Generated {
+				continue
+			}
+
+			item, err := item.Regenerate()
+			if err != nil {
+				return domain.ConfigSet{}, err
+			}
+			clone.Items[key] = item
+		}
+	}
+
+	now := datetime.UnixUTCNow()
+	clone.Name = names[set.Name]
+	clone.CreateDate = now
+	clone.UpdateDate = now
+	clone.Freeze = nil
+	return clone, nil
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestCloneSet(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test shallow clones share nested sets", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("db", "db", domain.Nested), "app")
+		service.AddItem(*domain.NewConfigItem("name", "app", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("alias", "app:name", domain.Ref), "app")
+
+		clone, err := service.CloneSet("app", "app-copy", "", false)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if clone.Items["db"].Value != "db" || clone.Items["alias"].Value != "app-copy:name" {
+			t.Errorf("Expected nested sets to be shared and self refs rewritten, got: %+v", clone.Items)
+		}
+
+		got, _ := service.GetSetJson("app-copy", domain.AnyAge)
+		expected := `{"alias":"app","db":{"host":"localhost"},"name":"app"}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+
+		referrers, _ := service.GetReferrers("db")
+		if len(referrers) != 2 {
+			t.Errorf("Expected the clone to reference the nested set, got: %+v", referrers)
+		}
+
+		revisions, _ := service.GetRevisions("app-copy", 10, 0)
+		if len(revisions) != 1 || revisions[0].Action != domain.CloneAction || revisions[0].Detail != "app" {
+			t.Errorf("Expected a clone revision, got: %+v", revisions)
+		}
+	})
+
+	t.Run("Test clones regenerate generated values unless asked to keep them", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		set, _ := service.AddItem(generatedItem("token", domain.AlphanumericGenerator, 24), "app")
+		token := set.Items["token"].Value
+
+		clone, err := service.CloneSet("app", "app-copy", "", false)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		value, _ := clone.Items["token"].Value.(string)
+		if value == token || len(value) != 24 {
+			t.Errorf("Expected a new generated value, got: %q from: %q", value, token)
+		}
+
+		clone, err = service.CloneSet("app", "app-kept", "", true)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if clone.Items["token"].Value != token {
+			t.Errorf("Expected the generated value: %q to be kept, got: %v", token, clone.Items["token"].Value)
+		}
+	})
+
+	t.Run("Test deep clones copy nested sets under the prefix", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("creds")
+		service.AddItem(*domain.NewConfigItem("user", "admin", domain.Plain), "creds")
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("creds", "creds", domain.Nested), "db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("db", "db", domain.Nested), "app")
+		service.AddItem(*domain.NewConfigItem("creds", "creds", domain.Nested), "app")
+		service.AddItem(*domain.NewConfigItem("user", "creds:user", domain.Ref), "app")
+
+		clone, err := service.CloneSet("app", "tenant-app", "tenant-", false)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if clone.Items["db"].Value != "tenant-db" || clone.Items["creds"].Value != "tenant-creds" || clone.Items["user"].Value != "tenant-creds:user" {
+			t.Errorf("Expected references to point at the clones, got: %+v", clone.Items)
+		}
+
+		db, err := service.GetSet("tenant-db")
+		if err != nil || db.Items["creds"].Value != "tenant-creds" {
+			t.Errorf("Expected nested sets to be cloned recursively, got: %+v, %v", db, err)
+		}
+
+		got, _ := service.GetSetJson("tenant-app", domain.AnyAge)
+		expected := `{"creds":{"user":"admin"},"db":{"creds":{"user":"admin"}},"user":"admin"}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+
+		service.UpdateItem(*domain.NewConfigItem("user", "tenant", domain.Plain), "tenant-creds")
+		got, _ = service.GetSetJson("app", domain.AnyAge)
+		expected = `{"creds":{"user":"admin"},"db":{"creds":{"user":"admin"}},"user":"admin"}`
+		if string(got) != expected {
+			t.Errorf("Expected the source to be independent of the clone, got: %s", got)
+		}
+	})
+
+	t.Run("Test clones never overwrite sets", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("db", "db", domain.Nested), "app")
+		service.CreateSet("tenant-db")
+
+		_, err := service.CloneSet("app", "tenant-app", "tenant-", false)
+		if err != ports.ErrDuplicatedConfig {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrDuplicatedConfig, err)
+		}
+
+		_, err = service.CloneSet("app", "tenant-app", "", false)
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+
+		_, err = service.GetSet("tenant-app")
+		if err != nil {
+			t.Errorf("Expected the shallow clone to exist, got: %v", err)
+		}
+
+		_, err = service.CloneSet("app", "tenant-app", "", false)
+		if err != ports.ErrDuplicatedConfig {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrDuplicatedConfig, err)
+		}
+
+		_, err = service.CloneSet("missing", "other", "", false)
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+	})
+
+	t.Run("Test deep clones rewrite bases and interpolations", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("defaults")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "defaults")
+		service.CreateSet("db")
+		service.SetBase("db", "defaults")
+		service.CreateSet("creds")
+		service.AddItem(*domain.NewConfigItem("user", "admin", domain.Plain), "creds")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("db", "db", domain.Nested), "app")
+		service.AddItem(*domain.NewConfigItem("creds", "creds", domain.Nested), "app")
+		service.AddItem(*domain.NewConfigItem("defaults", "defaults", domain.Nested), "app")
+		service.AddItem(*domain.NewConfigItem("dsn", "${set:creds:user}@${set:defaults:host}", domain.Plain), "app")
+
+		clone, err := service.CloneSet("app", "tenant-app", "tenant-", false)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if clone.Items["dsn"].Value != "${set:tenant-creds:user}@${set:tenant-defaults:host}" {
+			t.Errorf("Expected interpolations of cloned sets to be rewritten, got: %v", clone.Items["dsn"].Value)
+		}
+
+		db, _ := service.GetSet("tenant-db")
+		if db.Base != "tenant-defaults" {
+			t.Errorf("Expected the base to point at its clone, got: %q", db.Base)
+		}
+
+		service.UpdateItem(*domain.NewConfigItem("user", "tenant", domain.Plain), "tenant-creds")
+		service.UpdateItem(*domain.NewConfigItem("host", "tenant-host", domain.Plain), "tenant-defaults")
+		got, _ := service.GetSetJson("tenant-app", domain.AnyAge)
+		expected := `{"creds":{"user":"tenant"},"db":{"host":"tenant-host"},"defaults":{"host":"tenant-host"},"dsn":"tenant@tenant-host"}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+	})
+
+	t.Run("Test failed clones leave no sets behind", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("db", "db", domain.Nested), "app")
+
+		var failApp func(set domain.ConfigSet) (domain.ConfigSet, error)
+		failApp = func(set domain.ConfigSet) (domain.ConfigSet, error) {
+			if set.Name == "tenant-app" {
+				return domain.ConfigSet{}, errors.New("connection lost")
+			}
+
+			mockRepo.CreateSetInterceptor = nil
+			defer func() { mockRepo.CreateSetInterceptor = failApp }()
+			return mockRepo.CreateSet(set)
+		}
+		mockRepo.CreateSetInterceptor = failApp
+
+		_, err := service.CloneSet("app", "tenant-app", "tenant-", false)
+		if err == nil {
+			t.Fatalf("Expected the clone to fail")
+		}
+
+		_, err = service.GetSet("tenant-db")
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected the nested clone to be removed, got: %v", err)
+		}
+	})
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/expression"
+)
+
+// The value of a computed item is not an expression string
+var errComputedValue = errors.New("the expression must be a string")
+
+// parseComputed returns the parsed expression of a computed item
+func parseComputed(item domain.ConfigItem) (*expression.Expression, error) {
+	source, ok := item.Value.(string)
+	if !ok {
+		return nil, &domain.ComputedError{Key: item.Key, Err: errComputedValue}
+	}
+
+	expr, err := expression.Parse(source)
+	if err != nil {
+		return nil, &domain.ComputedError{Key: item.Key, Err: err}
+	}
+
+	return expr, nil
+}
+
+// evaluateComputed evaluates the expression of a computed item, each key it uses is rendered on its own
+// so values referencing computed items don't render the item again
+func (service *ConfigService) evaluateComputed(item domain.ConfigItem, self domain.ConfigSet, ctx *renderContext) (interface{}, error) {
+	expr, err := parseComputed(item)
+	if err != nil {
+		return nil, err
+	}
+
+	id := self.Name + ":" + item.Key
+	if containsString(ctx.references, id) {
+		return nil, domain.NewCycleError(ctx.references, id)
+	}
+
+	ctx.references = append(ctx.references, id)
+	defer func() { ctx.references = ctx.references[:len(ctx.references)-1] }()
+
+	value, err := expr.Eval(func(path []string) (interface{}, error) {
+		value, err := service.valueAt(self, path, ctx)
+		if _, missing := err.(*domain.PathNotFoundError); missing {
+			return nil, &domain.ReferenceError{Chain: append(copyStrings(ctx.references), self.Name+":"+strings.Join(path, referencePathSeparator))}
+		}
+
+		return value, err
+	})
+
+	if exprErr, ok := err.(*expression.Error); ok {
+		return nil, &domain.ComputedError{Key: item.Key, Err: exprErr}
+	}
+
+	return value, err
+}
+
+// checkComputed evaluates and type checks every computed item of a set about to be written,
+// so both broken expressions and writes breaking the inputs of an expression are rejected
+func (service *ConfigService) checkComputed(set domain.ConfigSet) error {
+	for _, key := range sortedKeys(set.Items) {
+		item := set.Items[key]
+		if item.Type != domain.Computed {
+			continue
+		}
+
+		ctx := &renderContext{secretPlaceholders: true, pending: &set}
+		_, err := service.evaluateComputed(item, set, ctx)
+		if err != nil {
+			return err
+		}
+
+		// Evaluation only follows one branch of conditionals, the type check covers all of them
+		expr, _ := parseComputed(item)
+		err = expr.Check(func(path []string) (interface{}, bool) {
+			value, err := service.valueAt(set, path, ctx)
+			return value, err == nil
+		})
+
+		if err != nil {
+			return &domain.ComputedError{Key: key, Err: err}
+		}
+	}
+
+	return nil
+}
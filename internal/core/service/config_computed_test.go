@@ -0,0 +1,139 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestComputedItems(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test computed values are evaluated against the set", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("workers", 4, domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("env", "prod", domain.Plain), "app")
+
+		for _, item := range []*domain.ConfigItem{
+			domain.NewConfigItem("threads", "workers * 2", domain.Computed),
+			domain.NewConfigItem("pool", `env == "prod" ? 50 : 5`, domain.Computed),
+			domain.NewConfigItem("total", "threads + pool", domain.Computed),
+			domain.NewConfigItem("summary", "${self:total} connections", domain.Plain),
+		} {
+			_, err := service.AddItem(*item, "app")
+			if err != nil {
+				t.Fatalf("Expected no error adding %q, got: %v", item.Key, err)
+			}
+		}
+
+		got, err := service.GetSetJson("app", domain.AnyAge)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := `{"env":"prod","pool":50,"summary":"58 connections","threads":8,"total":58,"workers":4}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+
+		value, _ := service.GetSetValue("app", []string{"total"})
+		if value != int64(58) {
+			t.Errorf("Expected value: 58, got: %v", value)
+		}
+	})
+
+	t.Run("Test computed values see overlay and nested values", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("port", 5432, domain.Plain), "db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("workers", 4, domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("db", "db", domain.Nested), "app")
+		service.AddItem(*domain.NewConfigItem("threads", "workers * 2", domain.Computed), "app")
+		service.AddItem(*domain.NewConfigItem("next", "db.port + 1", domain.Computed), "app")
+		service.CreateSet("app-prod")
+		service.AddItem(*domain.NewConfigItem("workers", 16, domain.Plain), "app-prod")
+		service.SetBase("app-prod", "app")
+
+		got, _ := service.GetSetJson("app-prod", domain.AnyAge)
+		expected := `{"db":{"port":5432},"next":5433,"threads":32,"workers":16}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+	})
+
+	t.Run("Test broken expressions are rejected on write", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("workers", 4, domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("env", "prod", domain.Plain), "app")
+
+		cases := []string{
+			"workers *",
+			`workers * "2"`,
+			`env == "dev" ? workers : env * 2`,
+			`env == "dev" ? missing : 1`,
+		}
+
+		for _, source := range cases {
+			_, err := service.AddItem(*domain.NewConfigItem("computed", source, domain.Computed), "app")
+			if _, ok := err.(*domain.ComputedError); !ok {
+				t.Errorf("Expected a computed error for %q, got: %v", source, err)
+			}
+		}
+
+		_, err := service.AddItem(*domain.NewConfigItem("computed", "missing + 1", domain.Computed), "app")
+		if _, ok := err.(*domain.ReferenceError); !ok {
+			t.Errorf("Expected a reference error for a missing key, got: %v", err)
+		}
+
+		_, err = service.AddItem(*domain.NewConfigItem("computed", 10, domain.Computed), "app")
+		if _, ok := err.(*domain.ComputedError); !ok {
+			t.Errorf("Expected a computed error for a non string expression, got: %v", err)
+		}
+
+		set, _ := service.GetSet("app")
+		if len(set.Items) != 2 {
+			t.Errorf("Expected set to not change, got: %+v", set.Items)
+		}
+	})
+
+	t.Run("Test writes breaking computed inputs are rejected", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("workers", 4, domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("threads", "workers * 2", domain.Computed), "app")
+
+		_, err := service.UpdateItem(*domain.NewConfigItem("workers", "many", domain.Plain), "app")
+		if _, ok := err.(*domain.ComputedError); !ok {
+			t.Errorf("Expected a computed error, got: %v", err)
+		}
+
+		_, err = service.RemoveItem(domain.ConfigItem{Key: "workers"}, "app")
+		if _, ok := err.(*domain.ReferenceError); !ok {
+			t.Errorf("Expected a reference error, got: %v", err)
+		}
+
+		_, err = service.UpdateItem(*domain.NewConfigItem("workers", "threads / 2", domain.Computed), "app")
+		if _, ok := err.(*domain.CycleError); !ok {
+			t.Errorf("Expected a cycle error, got: %v", err)
+		}
+	})
+}
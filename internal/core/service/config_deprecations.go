@@ -0,0 +1,46 @@
+package service
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+// recordDeprecation saves the deprecation of an item about to be rendered in the render context
+// Only the top definition of a key is reported, a nested item merged into a lower nested item
+// keeps the deprecations found below the lower one
+func (ctx *renderContext) recordDeprecation(item domain.ConfigItem, merged bool) {
+	if ctx.deprecations == nil {
+		return
+	}
+
+	key := ctx.keyPrefix + item.Key
+	delete(ctx.deprecations, key)
+	if !merged {
+		for path := range ctx.deprecations {
+			if strings.HasPrefix(path, key+".") {
+				delete(ctx.deprecations, path)
+			}
+		}
+	}
+
+	if item.Deprecated {
+		deprecation := domain.Deprecation{Key: key}
+		if item.ReplacedBy != "" {
+			deprecation.ReplacedBy = ctx.keyPrefix + item.ReplacedBy
+		}
+		ctx.deprecations[key] = deprecation
+	}
+}
+
+// sortedDeprecations lists the deprecations recorded by a render sorted by key
+func sortedDeprecations(deprecations map[string]domain.Deprecation) []domain.Deprecation {
+	list := make([]domain.Deprecation, 0, len(deprecations))
+	for _, deprecation := range deprecations {
+		list = append(list, deprecation)
+	}
+
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestDeprecations(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test deprecated items are found in bases and nested sets", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		deprecated := func(key string, value interface{}, replacedBy string) domain.ConfigItem {
+			item := *domain.NewConfigItem(key, value, domain.Plain)
+			item.Deprecated = true
+			item.ReplacedBy = replacedBy
+			return item
+		}
+
+		service.CreateSet("defaults")
+		service.AddItem(deprecated("retries", 3, ""), "defaults")
+		service.AddItem(deprecated("workers", 2, ""), "defaults")
+		service.CreateSet("db")
+		service.AddItem(deprecated("host", "localhost", "hostname"), "db")
+		service.AddItem(*domain.NewConfigItem("hostname", "localhost", domain.Plain), "db")
+		service.CreateSet("app")
+		service.SetBase("app", "defaults")
+		service.AddItem(*domain.NewConfigItem("workers", 4, domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+
+		got, err := service.RenderSetFor("app", domain.AnyAge, domain.RequestAttributes{})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := []domain.Deprecation{
+			{Key: "database.host", ReplacedBy: "database.hostname"},
+			{Key: "retries"},
+		}
+		if diff := cmp.Diff(expected, got.Deprecations); diff != "" {
+			t.Errorf("Deprecations mismatch (-want +got):\n%s", diff)
+		}
+
+		if string(got.Values) != `{"database":{"host":"localhost","hostname":"localhost"},"retries":3,"workers":4}` {
+			t.Errorf("Expected deprecated items to be rendered, got: %s", got.Values)
+		}
+
+		_, err = service.RenderSetFor("missing", domain.AnyAge, domain.RequestAttributes{})
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+	})
+
+	t.Run("Test cached renders keep their deprecations", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		item := *domain.NewConfigItem("timeout", "10s", domain.Plain)
+		item.Deprecated = true
+		service.CreateSet("app")
+		service.AddItem(item, "app")
+
+		// Cached reads never load the set
+		mockRepo.GetSetInterceptor = func(name string) (*domain.ConfigSet, error) {
+			return nil, errors.New("set loaded")
+		}
+
+		got, err := service.RenderSetFor("app", domain.AnyAge, domain.RequestAttributes{})
+		if err != nil || string(got.Values) != `{"timeout":"10s"}` || len(got.Deprecations) != 1 || got.Version != domain.StableVersion {
+			t.Errorf("Expected the cached render with its deprecations, got: %+v, %v", got, err)
+		}
+
+		json, _ := service.GetSetJson("app", domain.AnyAge)
+		if string(json) != `{"timeout":"10s"}` {
+			t.Errorf("Expected the cached values, got: %s", json)
+		}
+	})
+
+	t.Run("Test deprecations follow the canary, variants and time of the render", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		deprecated := *domain.NewConfigItem("host", "localhost", domain.Plain)
+		deprecated.Deprecated = true
+		service.CreateSet("legacy")
+		service.AddItem(deprecated, "legacy")
+		service.CreateSet("current")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "current")
+
+		region := *domain.NewConfigItem("region", "us", domain.Plain)
+		region.Deprecated = true
+		region.Variants = []domain.Variant{{When: domain.VariantCondition{Regions: []string{"eu"}}, Value: "eu"}}
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("database", "current", domain.Nested), "app")
+		service.AddItem(region, "app")
+
+		got, _ := service.RenderSetFor("app", domain.AnyAge, domain.RequestAttributes{Region: "eu"})
+		if string(got.Values) != `{"database":{"host":"localhost"},"region":"eu"}` || len(got.Deprecations) != 1 || got.Deprecations[0].Key != "region" {
+			t.Errorf("Expected the deprecations of the eu render, got: %s %+v", got.Values, got.Deprecations)
+		}
+
+		timeout := *domain.NewConfigItem("timeout", "10s", domain.Plain)
+		timeout.Deprecated = true
+		service.StartCanary("app", domain.ConfigItemMap{"timeout": timeout}, 10)
+
+		got, _ = service.RenderSetFor("app", domain.AnyAge, domain.RequestAttributes{ClientID: clientInBuckets(0, 10)})
+		if got.Version != domain.CanaryVersion || len(got.Deprecations) != 1 || got.Deprecations[0].Key != "timeout" {
+			t.Errorf("Expected the deprecations of the canary, got: %s %+v", got.Version, got.Deprecations)
+		}
+
+		got, _ = service.RenderSetFor("app", domain.AnyAge, domain.RequestAttributes{ClientID: clientInBuckets(50, 60)})
+		if got.Version != domain.StableVersion || len(got.Deprecations) != 1 {
+			t.Errorf("Expected no deprecations in the stable version, got: %s %+v", got.Version, got.Deprecations)
+		}
+
+		// Render a moment when app pointed at the legacy set
+		start := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+		for _, name := range []string{"legacy", "current", "app"} {
+			for i := range mockRepo.Revisions[name] {
+				mockRepo.Revisions[name][i].Date = start.Add(time.Duration(i*10) * time.Second)
+			}
+		}
+		mockRepo.Revisions["app"][1].Set.Items["database"] = *domain.NewConfigItem("database", "legacy", domain.Nested)
+
+		got, err := service.RenderSetAt("app", start.Add(15*time.Second))
+		if err != nil || len(got.Deprecations) != 1 || got.Deprecations[0].Key != "database.host" {
+			t.Errorf("Expected the deprecations of the past render, got: %s %+v, %v", got.Values, got.Deprecations, err)
+		}
+	})
+
+	t.Run("Test replaced items drop their deprecations", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		deprecated := *domain.NewConfigItem("host", "localhost", domain.Plain)
+		deprecated.Deprecated = true
+		service.CreateSet("legacy")
+		service.AddItem(deprecated, "legacy")
+		service.AddItem(*domain.NewConfigItem("port", 5432, domain.Plain), "legacy")
+		service.CreateSet("defaults")
+		service.AddItem(*domain.NewConfigItem("database", "legacy", domain.Nested), "defaults")
+		service.CreateSet("app")
+		service.SetBase("app", "defaults")
+		service.AddItem(*domain.NewConfigItem("database", "postgres://localhost", domain.Plain), "app")
+		// References read deprecated items without rendering them as themselves
+		service.AddItem(*domain.NewConfigItem("legacy", "defaults:database", domain.Ref), "app")
+
+		got, err := service.RenderSetFor("app", domain.AnyAge, domain.RequestAttributes{})
+		if err != nil || len(got.Deprecations) != 0 || !strings.Contains(string(got.Values), `"legacy":{"host":"localhost"`) {
+			t.Errorf("Expected no deprecations, got: %s %+v, %v", got.Values, got.Deprecations, err)
+		}
+	})
+
+	t.Run("Test replacement keys are validated", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		item := *domain.NewConfigItem("timeout", "10s", domain.Plain)
+		item.ReplacedBy = "requestTimeout"
+		_, err := service.AddItem(item, "app")
+		if err != domain.ErrInvalidReplacement {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrInvalidReplacement, err)
+		}
+	})
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"time"
+
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+func (service *ConfigService) FreezeSet(name string, reason string, until *time.Time) (domain.ConfigSet, error) {
+	now := datetime.UnixUTCNow()
+	if until != nil {
+		if !until.After(now) {
+			return domain.ConfigSet{}, domain.ErrUntilInPast
+		}
+
+		utc := until.UTC()
+		until = &utc
+	}
+
+	set, err := service.GetSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	// A frozen set can be frozen again to change the reason or the end
+	err = service.checkFreezeWindow()
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	set.Freeze = &domain.SetFreeze{Reason: reason, Until: until, CreateDate: now}
+	set.UpdateDate = now
+	set, err = service.repo.ReplaceSet(set)
+	if err != nil {
+		return set, err
+	}
+
+	service.addRevision(set, domain.FreezeAction, reason, false)
+	return set, nil
+}
+
+func (service *ConfigService) UnfreezeSet(name string) (domain.ConfigSet, error) {
+	set, err := service.GetSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	err = service.checkFreezeWindow()
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	set.Freeze = nil
+	set.UpdateDate = datetime.UnixUTCNow()
+	set, err = service.repo.ReplaceSet(set)
+	if err != nil {
+		return set, err
+	}
+
+	service.addRevision(set, domain.UnfreezeAction, "", false)
+	return set, nil
+}
+
+// WithFreezeOverride returns a copy of the service allowed to change sets during the freeze windows of the config
+// Frozen sets still refuse changes
+func (service *ConfigService) WithFreezeOverride() ports.ConfigService {
+	override := *service
+	override.freezeOverride = true
+	return &override
+}
+
+// checkFrozen returns an error if the set can't be changed now
+func (service *ConfigService) checkFrozen(set domain.ConfigSet) error {
+	if set.Freeze.Active(datetime.UnixUTCNow()) {
+		return domain.NewSetFrozenError(set.Name, *set.Freeze)
+	}
+
+	return service.checkFreezeWindow()
+}
+
+// checkSetFrozen returns an error if the set with the given name can't be changed now
+func (service *ConfigService) checkSetFrozen(name string) error {
+	set, err := service.GetSet(name)
+	if err != nil {
+		return err
+	}
+
+	return service.checkFrozen(set)
+}
+
+// checkFreezeWindow returns an error if a freeze window of the config is active and the service doesn't override it
+func (service *ConfigService) checkFreezeWindow() error {
+	if service.freezeOverride {
+		return nil
+	}
+
+	window, frozen := service.config.ActiveFreezeWindow(datetime.UnixUTCNow())
+	if frozen {
+		return domain.NewWindowFrozenError(window)
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestFreezes(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test frozen sets reject changes until unfrozen", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+
+		set, err := service.FreezeSet("app", "peak sales", nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if set.Freeze == nil || set.Freeze.Reason != "peak sales" {
+			t.Errorf("Expected the freeze to be saved, got: %+v", set.Freeze)
+		}
+
+		changes := map[string]func() error{
+			"add": func() error {
+				_, err := service.AddItem(*domain.NewConfigItem("retries", 3, domain.Plain), "app")
+				return err
+			},
+			"update": func() error {
+				_, err := service.UpdateItem(*domain.NewConfigItem("timeout", "30s", domain.Plain), "app")
+				return err
+			},
+			"override": func() error {
+				_, err := service.OverrideItem(*domain.NewConfigItem("timeout", "30s", domain.Plain), "app", datetime.UnixUTCNow().Add(time.Hour))
+				return err
+			},
+			"remove": func() error {
+				_, err := service.RemoveItem(domain.ConfigItem{Key: "timeout"}, "app")
+				return err
+			},
+			"rename": func() error {
+				_, err := service.RenameSet("app", "api", false)
+				return err
+			},
+			"delete": func() error {
+				_, err := service.DeleteSet("app", false)
+				return err
+			},
+			"rollback": func() error {
+				_, err := service.RollbackSet("app", 1)
+				return err
+			},
+			"base": func() error {
+				_, err := service.SetBase("app", "")
+				return err
+			},
+			"schema": func() error {
+				_, err := service.SetSchema("app", nil)
+				return err
+			},
+			"metadata": func() error {
+				_, err := service.UpdateMetadata("app", domain.SetMetadata{Description: "frozen"})
+				return err
+			},
+			"regenerate": func() error {
+				_, err := service.RegenerateItem("app", "timeout")
+				return err
+			},
+			"start canary": func() error {
+				_, err := service.StartCanary("app", domain.ConfigItemMap{}, 10)
+				return err
+			},
+			"canary percent": func() error {
+				_, err := service.SetCanaryPercent("app", 20)
+				return err
+			},
+			"abort canary": func() error {
+				_, err := service.AbortCanary("app")
+				return err
+			},
+			"schedule": func() error {
+				_, err := service.ScheduleChange(domain.ScheduledChange{
+					Set:         "app",
+					Operation:   domain.RemoveChange,
+					Item:        domain.ConfigItem{Key: "timeout"},
+					EffectiveAt: datetime.UnixUTCNow().Add(time.Hour),
+				})
+				return err
+			},
+			"cancel schedule": func() error {
+				_, err := service.CancelScheduledChange("app", "id")
+				return err
+			},
+		}
+
+		for name, change := range changes {
+			if _, ok := change().(*domain.FrozenError); !ok {
+				t.Errorf("Expected %s to fail with a frozen error", name)
+			}
+		}
+
+		_, err = service.UnfreezeSet("app")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		_, err = service.UpdateItem(*domain.NewConfigItem("timeout", "30s", domain.Plain), "app")
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+
+		revisions, _ := service.GetRevisions("app", 3, 0)
+		if len(revisions) != 3 || revisions[1].Action != domain.UnfreezeAction || revisions[2].Action != domain.FreezeAction {
+			t.Errorf("Expected freeze and unfreeze revisions, got: %+v", revisions)
+		}
+	})
+
+	t.Run("Test freezes end at their until time", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		past := datetime.UnixUTCNow().Add(-time.Second)
+		_, err := service.FreezeSet("app", "", &past)
+		if err != domain.ErrUntilInPast {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrUntilInPast, err)
+		}
+
+		_, err = service.FreezeSet("missing", "", nil)
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+
+		// Expired freezes stay saved but don't block changes
+		set, _ := service.GetSet("app")
+		set.Freeze = &domain.SetFreeze{Until: &past}
+		mockRepo.ReplaceSet(set)
+
+		_, err = service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Test freeze windows reject changes unless overridden", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		now := datetime.UnixUTCNow()
+		config.FreezeWindows = []domain.FreezeWindow{{Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "Black Friday"}}
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		_, err := service.CreateSet("app")
+		if frozen, ok := err.(*domain.FrozenError); !ok || frozen.Reason != "Black Friday" {
+			t.Errorf("Expected a frozen error, got: %v", err)
+		}
+
+		override := service.WithFreezeOverride()
+		_, err = override.CreateSet("app")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		_, err = service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		if _, ok := err.(*domain.FrozenError); !ok {
+			t.Errorf("Expected a frozen error, got: %v", err)
+		}
+
+		_, err = override.FreezeSet("app", "", nil)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		// The override only skips the windows
+		_, err = override.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		if frozen, ok := err.(*domain.FrozenError); !ok || frozen.Set != "app" {
+			t.Errorf("Expected the set freeze error, got: %v", err)
+		}
+	})
+
+	t.Run("Test due changes wait for the end of freeze windows", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		now := datetime.UnixUTCNow()
+		mockRepo.AddScheduledChange(domain.ScheduledChange{
+			ID:          "due",
+			Set:         "app",
+			Operation:   domain.AddChange,
+			Item:        *domain.NewConfigItem("timeout", "10s", domain.Plain),
+			EffectiveAt: now.Add(-time.Minute),
+		})
+
+		config.FreezeWindows = []domain.FreezeWindow{{Start: now.Add(-time.Hour), End: now.Add(time.Hour)}}
+		applied, err := service.ApplyDueChanges()
+		if err != nil || len(applied) != 0 {
+			t.Errorf("Expected nothing applied during the window, got: %v %+v", err, applied)
+		}
+
+		changes, _ := service.GetScheduledChanges("app")
+		if len(changes) != 1 {
+			t.Errorf("Expected the change to stay pending, got: %+v", changes)
+		}
+
+		// The change is claimed again once its lease expires
+		config.FreezeWindows = nil
+		mockRepo.Leases["due"] = now
+		applied, err = service.ApplyDueChanges()
+		if err != nil || len(applied) != 1 {
+			t.Errorf("Expected the change applied after the window, got: %v %+v", err, applied)
+		}
+	})
+}
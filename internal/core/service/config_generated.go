@@ -0,0 +1,69 @@
+package service
+
+import (
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+func (service *ConfigService) RegenerateItem(setName string, key string) (domain.ConfigSet, error) {
+	current, err := service.GetSet(setName)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.checkFrozen(current)
+	if err != nil {
+		return current, err
+	}
+
+	item, ok := current.Items[key]
+	if !ok {
+		return current, domain.ErrKeyNotExists
+	}
+
+	item, err = item.Regenerate()
+	if err != nil {
+		return current, err
+	}
+
+	candidate := current.Copy()
+	_, err = candidate.Update(item)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.validateSet(candidate)
+	if err != nil {
+		return current, err
+	}
+
+	set, err := service.repo.UpdateItem(item, setName)
+	if err != nil {
+		return set, err
+	}
+
+	service.updateCache(set)
+	service.addRevision(set, domain.RegenerateAction, key, false)
+	return set, nil
+}
+
+// generateValue fills the value of a generated item being written.
+// The stored value is kept while the item stays generated with the same generator,
+// values sent by clients for generated items are ignored
+func generateValue(item domain.ConfigItem, current domain.ConfigSet) (domain.ConfigItem, error) {
+	if item.Type != domain.Generated {
+		item.Generator = nil
+		return item, nil
+	}
+
+	if item.Generator == nil {
+		return item, domain.ErrMissingGenerator
+	}
+
+	stored, ok := current.Items[item.Key]
+	if ok && stored.Type == domain.Generated && stored.Generator != nil && *stored.Generator == *item.Generator {
+		item.Value = stored.Value
+		return item, nil
+	}
+
+	return item.Regenerate()
+}
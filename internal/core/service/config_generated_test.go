@@ -0,0 +1,101 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func generatedItem(key string, kind domain.GeneratorKind, length int) domain.ConfigItem {
+	item := domain.NewConfigItem(key, "ignored", domain.Generated)
+	item.Generator = &domain.Generator{Kind: kind, Length: length}
+	return *item
+}
+
+func TestGeneratedItems(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test generated values are produced once and kept", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		set, err := service.AddItem(generatedItem("token", domain.AlphanumericGenerator, 24), "app")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		token, _ := set.Items["token"].Value.(string)
+		if len(token) != 24 || token == "ignored" {
+			t.Fatalf("Expected a generated token, got: %q", token)
+		}
+
+		set, _ = service.UpdateItem(generatedItem("token", domain.AlphanumericGenerator, 24), "app")
+		if set.Items["token"].Value != token {
+			t.Errorf("Expected value to be kept on update, got: %v", set.Items["token"].Value)
+		}
+
+		set, _ = service.UpdateItem(generatedItem("token", domain.AlphanumericGenerator, 32), "app")
+		if value, _ := set.Items["token"].Value.(string); len(value) != 32 {
+			t.Errorf("Expected a new value when the generator changes, got: %q", value)
+		}
+	})
+
+	t.Run("Test regenerate produces a new value", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		set, _ := service.AddItem(generatedItem("salt", domain.BytesGenerator, 32), "app")
+		salt := set.Items["salt"].Value
+
+		set, err := service.RegenerateItem("app", "salt")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if set.Items["salt"].Value == salt {
+			t.Errorf("Expected a new value, got the same: %v", salt)
+		}
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		expected := `{"salt":"` + set.Items["salt"].Value.(string) + `"}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+
+		revs, _ := service.GetRevisions("app", 1, 0)
+		if revs[0].Action != domain.RegenerateAction || revs[0].Detail != "salt" {
+			t.Errorf("Expected a regenerate revision, got: %+v", revs[0])
+		}
+	})
+
+	t.Run("Test regenerate errors", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("plain", "value", domain.Plain), "app")
+
+		_, err := service.RegenerateItem("app", "plain")
+		if err != domain.ErrNotGenerated {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrNotGenerated, err)
+		}
+
+		_, err = service.RegenerateItem("app", "missing")
+		if err != domain.ErrKeyNotExists {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrKeyNotExists, err)
+		}
+
+		_, err = service.AddItem(*domain.NewConfigItem("token", nil, domain.Generated), "app")
+		if err != domain.ErrMissingGenerator {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrMissingGenerator, err)
+		}
+	})
+}
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+func (service *ConfigService) GetGraph() (domain.ConfigGraph, error) {
+	builder := graphBuilder{nodes: map[string]domain.GraphNode{}, seen: map[domain.GraphEdge]bool{}}
+	for skip := 0; ; skip += listPageSize {
+		sets, err := service.repo.ListSets(listPageSize, skip)
+		if err != nil {
+			return domain.ConfigGraph{}, err
+		}
+
+		for _, set := range sets {
+			builder.addSet(set)
+		}
+
+		if len(sets) < listPageSize {
+			return builder.graph(), nil
+		}
+	}
+}
+
+func (service *ConfigService) GetSetGraph(name string) (domain.ConfigGraph, error) {
+	_, err := service.GetSet(name)
+	if err != nil {
+		return domain.ConfigGraph{}, err
+	}
+
+	graph, err := service.GetGraph()
+	if err != nil {
+		return graph, err
+	}
+
+	return graph.Related(domain.SetNodeID(name)), nil
+}
+
+// graphBuilder collects the nodes and edges of the dependency graph from stored sets
+type graphBuilder struct {
+	nodes map[string]domain.GraphNode
+	edges []domain.GraphEdge
+	seen  map[domain.GraphEdge]bool
+}
+
+func (builder *graphBuilder) addSet(set domain.ConfigSet) {
+	id := domain.SetNodeID(set.Name)
+	builder.nodes[id] = domain.GraphNode{ID: id, Kind: domain.SetNode, Name: set.Name}
+
+	if set.Base != "" {
+		builder.addEdge(set.Name, domain.SetNode, set.Base, domain.BaseEdge, "")
+	}
+
+	// Set edges follow the referrers index, references into the same set are not edges
+	names := references(set)
+	for _, key := range sortedKeys(set.Items) {
+		item := set.Items[key]
+		kind := domain.ReferenceEdge
+		if item.Type == domain.Nested {
+			kind = domain.NestedEdge
+		}
+
+		for _, name := range itemTargets(item) {
+			if containsString(names, name) {
+				builder.addEdge(set.Name, domain.SetNode, name, kind, key)
+			}
+		}
+
+		for _, name := range itemSecrets(item) {
+			builder.addEdge(set.Name, domain.SecretNode, name, domain.SecretEdge, key)
+		}
+	}
+}
+
+// itemValues returns the item value followed by the values of its variants
+func itemValues(item domain.ConfigItem) []interface{} {
+	values := []interface{}{item.Value}
+	for _, variant := range item.Variants {
+		values = append(values, variant.Value)
+	}
+
+	return values
+}
+
+func (builder *graphBuilder) addEdge(from string, kind domain.GraphNodeKind, name string, edgeKind domain.GraphEdgeKind, key string) {
+	edge := domain.GraphEdge{
+		From: domain.SetNodeID(from),
+		Kind: edgeKind,
+		Key:  key,
+	}
+
+	if kind == domain.SecretNode {
+		edge.To = domain.SecretNodeID(name)
+	} else {
+		edge.To = domain.SetNodeID(name)
+	}
+
+	if builder.seen[edge] {
+		return
+	}
+
+	builder.seen[edge] = true
+	builder.edges = append(builder.edges, edge)
+
+	if _, ok := builder.nodes[edge.To]; !ok {
+		// Sets are marked as missing until they are added
+		builder.nodes[edge.To] = domain.GraphNode{
+			ID:      edge.To,
+			Kind:    kind,
+			Name:    name,
+			Missing: kind == domain.SetNode,
+		}
+	}
+}
+
+// graph returns the collected graph with nodes sorted by id and edges by their ends
+func (builder *graphBuilder) graph() domain.ConfigGraph {
+	graph := domain.ConfigGraph{
+		Nodes: make([]domain.GraphNode, 0, len(builder.nodes)),
+		Edges: []domain.GraphEdge{},
+	}
+
+	for _, node := range builder.nodes {
+		graph.Nodes = append(graph.Nodes, node)
+	}
+
+	sort.Slice(graph.Nodes, func(i, j int) bool {
+		return graph.Nodes[i].ID < graph.Nodes[j].ID
+	})
+
+	graph.Edges = append(graph.Edges, builder.edges...)
+	sort.SliceStable(graph.Edges, func(i, j int) bool {
+		a, b := graph.Edges[i], graph.Edges[j]
+		if a.From != b.From {
+			return a.From < b.From
+		}
+
+		if a.To != b.To {
+			return a.To < b.To
+		}
+
+		return a.Key < b.Key
+	})
+
+	return graph
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestGraph(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test graph includes every kind of reference", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("password", "db/password", domain.Secret), "db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+		service.AddItem(*domain.NewConfigItem("url", "https://${set:infra:host}/?key=${secret:api/key}&$${set:escaped}", domain.Plain), "app")
+		service.CreateSet("app-prod")
+		service.SetBase("app-prod", "app")
+
+		got, err := service.GetGraph()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := domain.ConfigGraph{
+			Nodes: []domain.GraphNode{
+				{ID: "secret:api/key", Kind: domain.SecretNode, Name: "api/key"},
+				{ID: "secret:db/password", Kind: domain.SecretNode, Name: "db/password"},
+				{ID: "set:app", Kind: domain.SetNode, Name: "app"},
+				{ID: "set:app-prod", Kind: domain.SetNode, Name: "app-prod"},
+				{ID: "set:db", Kind: domain.SetNode, Name: "db"},
+				{ID: "set:infra", Kind: domain.SetNode, Name: "infra", Missing: true},
+			},
+			Edges: []domain.GraphEdge{
+				{From: "set:app", To: "secret:api/key", Kind: domain.SecretEdge, Key: "url"},
+				{From: "set:app", To: "set:db", Kind: domain.NestedEdge, Key: "database"},
+				{From: "set:app", To: "set:infra", Kind: domain.ReferenceEdge, Key: "url"},
+				{From: "set:app-prod", To: "set:app", Kind: domain.BaseEdge},
+				{From: "set:db", To: "secret:db/password", Kind: domain.SecretEdge, Key: "password"},
+			},
+		}
+
+		if !cmp.Equal(got, expected) {
+			t.Errorf("Expected graph: %+v, got: %+v", expected, got)
+		}
+	})
+
+	t.Run("Test graph edges match the referrers index", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("infra")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "infra")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("name", "app", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("label", "${set:app:name}", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("host", "${set:infra:host}", domain.Plain), "app")
+
+		got, err := service.GetGraph()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := []domain.GraphEdge{
+			{From: "set:app", To: "set:infra", Kind: domain.ReferenceEdge, Key: "host"},
+		}
+		if !cmp.Equal(got.Edges, expected) {
+			t.Errorf("Expected edges: %+v, got: %+v", expected, got.Edges)
+		}
+
+		referrers, _ := service.GetReferrers("app")
+		if len(referrers) != 0 {
+			t.Errorf("Expected no referrers of app, got: %+v", referrers)
+		}
+	})
+
+	t.Run("Test set graph only includes related nodes", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+		service.CreateSet("unrelated")
+
+		got, err := service.GetSetGraph("db")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if len(got.Nodes) != 2 || len(got.Edges) != 1 {
+			t.Errorf("Expected 2 nodes and 1 edge, got: %+v", got)
+		}
+
+		_, err = service.GetSetGraph("notExists")
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+	})
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+func (service *ConfigService) UpdateMetadata(name string, metadata domain.SetMetadata) (domain.ConfigSet, error) {
+	err := metadata.Validate()
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	set, err := service.GetSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	err = service.checkFrozen(set)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	set.SetMetadata = metadata
+	set.UpdateDate = datetime.UnixUTCNow()
+	set, err = service.repo.ReplaceSet(set)
+	if err != nil {
+		return set, err
+	}
+
+	service.addRevision(set, domain.MetadataAction, "", false)
+	return set, nil
+}
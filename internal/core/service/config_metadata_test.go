@@ -0,0 +1,69 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestSetMetadata(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test metadata is saved and used to list sets", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("checkout")
+		service.CreateSet("search")
+		set, err := service.UpdateMetadata("checkout", domain.SetMetadata{
+			Description: "Checkout flow settings",
+			Owner:       "payments",
+			Contact:     "#payments-oncall",
+			Labels:      map[string]string{"team": "payments", "env": "prod"},
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if set.Owner != "payments" || set.Labels["env"] != "prod" {
+			t.Errorf("Expected metadata to be saved, got: %+v", set.SetMetadata)
+		}
+
+		names, _ := service.GetSetNames(10, 0, map[string]string{"team": "payments"})
+		if !cmp.Equal(names, []string{"checkout"}) {
+			t.Errorf("Expected sets with the label, got: %v", names)
+		}
+
+		names, _ = service.GetSetNames(10, 0, nil)
+		if !cmp.Equal(names, []string{"checkout", "search"}) {
+			t.Errorf("Expected every set without labels, got: %v", names)
+		}
+
+		revisions, _ := service.GetRevisions("checkout", 1, 0)
+		if len(revisions) != 1 || revisions[0].Action != domain.MetadataAction {
+			t.Errorf("Expected a metadata revision, got: %+v", revisions)
+		}
+	})
+
+	t.Run("Test invalid metadata is rejected", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("checkout")
+		_, err := service.UpdateMetadata("checkout", domain.SetMetadata{Labels: map[string]string{"team": "a,b"}})
+		if err != domain.ErrInvalidLabel {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrInvalidLabel, err)
+		}
+
+		_, err = service.UpdateMetadata("missing", domain.SetMetadata{})
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+	})
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+// checkCycle fails if rendering target can reach the set with the given name,
+// following both base sets and nested items. Missing sets are ignored
+func (service *ConfigService) checkCycle(name string, target string) error {
+	visited := map[string]bool{}
+
+	var walk func(path []string, current string) error
+	walk = func(path []string, current string) error {
+		if current == name {
+			return domain.NewCycleError(path, current)
+		}
+
+		if visited[current] {
+			return nil
+		}
+		visited[current] = true
+
+		set, err := service.GetSet(current)
+		if err == ports.ErrConfigNotExists {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		path = append(path, current)
+		if set.Base != "" {
+			err = walk(path, set.Base)
+			if err != nil {
+				return err
+			}
+		}
+
+		for _, key := range sortedKeys(set.Items) {
+			item := set.Items[key]
+			nested, ok := item.Value.(string)
+			if item.Type != domain.Nested || !ok {
+				continue
+			}
+
+			err = walk(path, nested)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return walk([]string{name}, target)
+}
+
+// checkNestedTarget fails with a *domain.ReferenceError if the item is a nested set that doesn't exist
+func (service *ConfigService) checkNestedTarget(item domain.ConfigItem, setName string) error {
+	if item.Type != domain.Nested {
+		return nil
+	}
+
+	nested, ok := item.Value.(string)
+	if !ok {
+		return domain.ErrInvalidNestedKeyValue
+	}
+
+	_, err := service.GetSet(nested)
+	if err == ports.ErrConfigNotExists {
+		return &domain.ReferenceError{Chain: []string{setName + ":" + item.Key, nested}}
+	}
+
+	return err
+}
+
+// checkNestedCycle fails if the item is a nested set that would render the set with the given name
+func (service *ConfigService) checkNestedCycle(item domain.ConfigItem, setName string) error {
+	if item.Type != domain.Nested {
+		return nil
+	}
+
+	nested, ok := item.Value.(string)
+	if !ok {
+		return domain.ErrInvalidNestedKeyValue
+	}
+
+	return service.checkCycle(setName, nested)
+}
+
+func sortedKeys(items domain.ConfigItemMap) []string {
+	keys := make([]string, 0, len(items))
+	for key := range items {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
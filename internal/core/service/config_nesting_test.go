@@ -0,0 +1,128 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestNestedCycles(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test nested cycles are rejected on write", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("a")
+		service.CreateSet("b")
+		service.CreateSet("c")
+		service.AddItem(*domain.NewConfigItem("b", "b", domain.Nested), "a")
+		service.AddItem(*domain.NewConfigItem("c", "c", domain.Nested), "b")
+
+		_, err := service.AddItem(*domain.NewConfigItem("a", "a", domain.Nested), "c")
+		cycleErr, ok := err.(*domain.CycleError)
+		if !ok {
+			t.Fatalf("Expected a cycle error, got: %v", err)
+		}
+
+		expected := []string{"c", "a", "b", "c"}
+		if !cmp.Equal(cycleErr.Chain, expected) {
+			t.Errorf("Expected cycle: %v, got: %v", expected, cycleErr.Chain)
+		}
+
+		service.AddItem(*domain.NewConfigItem("other", "value", domain.Plain), "c")
+		_, err = service.UpdateItem(*domain.NewConfigItem("other", "c", domain.Nested), "c")
+		if _, ok := err.(*domain.CycleError); !ok {
+			t.Errorf("Expected a cycle error, got: %v", err)
+		}
+
+		set, _ := service.GetSet("c")
+		if len(set.Items) != 1 || set.Items["other"].Type != domain.Plain {
+			t.Errorf("Expected set to not change, got: %+v", set.Items)
+		}
+	})
+
+	t.Run("Test cycles through overlays are rejected on write", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.CreateSet("app-prod")
+		service.SetBase("app-prod", "app")
+
+		_, err := service.AddItem(*domain.NewConfigItem("prod", "app-prod", domain.Nested), "app")
+		cycleErr, ok := err.(*domain.CycleError)
+		if !ok {
+			t.Fatalf("Expected a cycle error, got: %v", err)
+		}
+
+		expected := []string{"app", "app-prod", "app"}
+		if !cmp.Equal(cycleErr.Chain, expected) {
+			t.Errorf("Expected cycle: %v, got: %v", expected, cycleErr.Chain)
+		}
+	})
+
+	t.Run("Test stored cycles are detected while rendering", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		mockRepo.CreateSet(*domain.NewConfigSet("a", *domain.NewConfigItem("b", "b", domain.Nested)))
+		mockRepo.CreateSet(*domain.NewConfigSet("b", *domain.NewConfigItem("a", "a", domain.Nested)))
+
+		_, err := service.GetSetJson("a", domain.AnyAge)
+		cycleErr, ok := err.(*domain.CycleError)
+		if !ok {
+			t.Fatalf("Expected a cycle error, got: %v", err)
+		}
+
+		expected := []string{"a", "b", "a"}
+		if !cmp.Equal(cycleErr.Chain, expected) {
+			t.Errorf("Expected cycle: %v, got: %v", expected, cycleErr.Chain)
+		}
+
+		_, err = service.GetSetValue("a", []string{"b", "a", "b"})
+		if _, ok := err.(*domain.CycleError); !ok {
+			t.Errorf("Expected a cycle error, got: %v", err)
+		}
+	})
+
+	t.Run("Test nesting deeper than the maximum depth fails", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.MaxNestingDepth = 2
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("a")
+		service.CreateSet("b")
+		service.CreateSet("c")
+		service.CreateSet("d")
+		service.AddItem(*domain.NewConfigItem("d", "d", domain.Nested), "c")
+		service.AddItem(*domain.NewConfigItem("c", "c", domain.Nested), "b")
+
+		_, err := service.GetSetJson("b", domain.AnyAge)
+		if err != nil {
+			t.Errorf("Expected set to be rendered without errors, got: %v", err)
+		}
+
+		service.AddItem(*domain.NewConfigItem("b", "b", domain.Nested), "a")
+		_, err = service.GetSetJson("a", domain.AnyAge)
+		depthErr, ok := err.(*domain.DepthError)
+		if !ok {
+			t.Fatalf("Expected a depth error, got: %v", err)
+		}
+
+		expected := []string{"a", "b", "c", "d"}
+		if !cmp.Equal(depthErr.Chain, expected) {
+			t.Errorf("Expected chain: %v, got: %v", expected, depthErr.Chain)
+		}
+	})
+}
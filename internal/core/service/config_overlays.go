@@ -0,0 +1,57 @@
+package service
+
+import (
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+func (service *ConfigService) SetBase(name string, base string) (domain.ConfigSet, error) {
+	set, err := service.GetSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	err = service.checkFrozen(set)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	if base != "" {
+		_, err = service.GetSet(base)
+		if err != nil {
+			return domain.ConfigSet{}, err
+		}
+
+		err = service.checkCycle(name, base)
+		if err != nil {
+			return domain.ConfigSet{}, err
+		}
+	}
+
+	before := references(set)
+	set.Base = base
+	set.UpdateDate = datetime.UnixUTCNow()
+	set, err = service.repo.ReplaceSet(set)
+	if err != nil {
+		return set, err
+	}
+
+	service.updateCache(set)
+	service.syncReferrers(name, before, references(set))
+	service.addRevision(set, domain.SetBaseAction, base, false)
+	return set, nil
+}
+
+func (service *ConfigService) GetSetOrigins(name string) (map[string]interface{}, error) {
+	set, err := service.GetSet(name)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := service.renderSet(set, &renderContext{secretPlaceholders: true})
+	if err != nil {
+		return nil, err
+	}
+
+	return rendered.origins, nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestOverlays(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test overlay items take precedence over base items", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("payments")
+		service.AddItem(*domain.NewConfigItem("timeout", 10, domain.Plain), "payments")
+		service.AddItem(*domain.NewConfigItem("currency", "USD", domain.Plain), "payments")
+		service.CreateSet("payments-prod")
+		service.AddItem(*domain.NewConfigItem("timeout", 30, domain.Plain), "payments-prod")
+
+		set, err := service.SetBase("payments-prod", "payments")
+		if err != nil {
+			t.Errorf("Expected base to be set without errors, got: %v", err)
+		}
+
+		if set.Base != "payments" {
+			t.Errorf("Expected base: %q, got: %q", "payments", set.Base)
+		}
+
+		got, err := service.GetSetJson("payments-prod", domain.AnyAge)
+		if err != nil {
+			t.Errorf("Expected set to be rendered without errors, got: %v", err)
+		}
+
+		expected := `{"currency":"USD","timeout":30}`
+		if string(got) != expected {
+			t.Errorf("Expected json: %s, got: %s", expected, string(got))
+		}
+	})
+
+	t.Run("Test nested subtrees are deep merged across a chain", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db-common")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db-common")
+		service.AddItem(*domain.NewConfigItem("pool", 10, domain.Plain), "db-common")
+		service.CreateSet("db-prod")
+		service.AddItem(*domain.NewConfigItem("host", "db.prod", domain.Plain), "db-prod")
+
+		service.CreateSet("base")
+		service.AddItem(*domain.NewConfigItem("db", "db-common", domain.Nested), "base")
+		service.AddItem(*domain.NewConfigItem("tags", map[string]interface{}{"a": 1}, domain.Plain), "base")
+		service.CreateSet("region")
+		service.AddItem(*domain.NewConfigItem("region", "us-east-1", domain.Plain), "region")
+		service.AddItem(*domain.NewConfigItem("tags", map[string]interface{}{"b": 2}, domain.Plain), "region")
+		service.CreateSet("env")
+		service.AddItem(*domain.NewConfigItem("db", "db-prod", domain.Nested), "env")
+
+		service.SetBase("region", "base")
+		service.SetBase("env", "region")
+
+		set, _ := service.GetSet("env")
+		got, err := service.SetToJson(set)
+		if err != nil {
+			t.Errorf("Expected set to be rendered without errors, got: %v", err)
+		}
+
+		// Plain maps are values, only nested subtrees are merged
+		expected := `{"db":{"host":"db.prod","pool":10},"region":"us-east-1","tags":{"b":2}}`
+		if string(got) != expected {
+			t.Errorf("Expected json: %s, got: %s", expected, string(got))
+		}
+
+		origins, err := service.GetSetOrigins("env")
+		if err != nil {
+			t.Errorf("Expected origins to be read without errors, got: %v", err)
+		}
+
+		expectedOrigins := map[string]interface{}{
+			"db": map[string]interface{}{
+				"host": "db-prod",
+				"pool": "db-common",
+			},
+			"region": "region",
+			"tags":   "region",
+		}
+		if !cmp.Equal(origins, expectedOrigins) {
+			t.Errorf("Expected origins: %+v, got: %+v", expectedOrigins, origins)
+		}
+	})
+
+	t.Run("Test overlay cycles are rejected", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("a")
+		service.CreateSet("b")
+		service.CreateSet("c")
+		service.SetBase("b", "a")
+		service.SetBase("c", "b")
+
+		_, err := service.SetBase("a", "c")
+		cycleErr, ok := err.(*domain.CycleError)
+		if !ok {
+			t.Fatalf("Expected a cycle error, got: %v", err)
+		}
+
+		expected := []string{"a", "c", "b", "a"}
+		if !cmp.Equal(cycleErr.Chain, expected) {
+			t.Errorf("Expected cycle: %v, got: %v", expected, cycleErr.Chain)
+		}
+
+		_, err = service.SetBase("a", "a")
+		if _, ok := err.(*domain.CycleError); !ok {
+			t.Errorf("Expected a cycle error, got: %v", err)
+		}
+
+		_, err = service.SetBase("a", "notExists")
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+	})
+
+	t.Run("Test overlay cycles are detected while rendering", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("a")
+		service.CreateSet("b")
+		mockRepo.Sets["a"].Base = "b"
+		mockRepo.Sets["b"].Base = "a"
+
+		set, _ := service.GetSet("a")
+		_, err := service.SetToJson(set)
+		if _, ok := err.(*domain.CycleError); !ok {
+			t.Errorf("Expected a cycle error, got: %v", err)
+		}
+	})
+
+	t.Run("Test an overlay can be removed", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("base")
+		service.AddItem(*domain.NewConfigItem("key", "value", domain.Plain), "base")
+		service.CreateSet("overlay")
+		service.SetBase("overlay", "base")
+		service.SetBase("overlay", "")
+
+		got, _ := service.GetSetJson("overlay", domain.AnyAge)
+		if string(got) != "{}" {
+			t.Errorf("Expected json: %s, got: %s", "{}", string(got))
+		}
+
+		revs, _ := service.GetRevisions("overlay", 1, 0)
+		if revs[0].Action != domain.SetBaseAction {
+			t.Errorf("Expected last revision action: %q, got: %q", domain.SetBaseAction, revs[0].Action)
+		}
+	})
+}
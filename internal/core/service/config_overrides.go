@@ -0,0 +1,161 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+func (service *ConfigService) OverrideItem(item domain.ConfigItem, setName string, expiresAt time.Time) (domain.ConfigSet, error) {
+	now := datetime.UnixUTCNow()
+	if !expiresAt.After(now) {
+		return domain.ConfigSet{}, domain.ErrExpiresAtInPast
+	}
+
+	current, err := service.GetSet(setName)
+	if err != nil {
+		return current, err
+	}
+
+	previous, ok := current.Items[item.Key]
+	if !ok {
+		return current, domain.ErrKeyNotExists
+	}
+
+	// Overriding an override keeps the value it will revert to
+	active, found, err := service.findOverride(setName, item.Key)
+	if err != nil {
+		return current, err
+	}
+
+	if found {
+		previous = active.Item
+	}
+
+	err = service.checkFrozen(current)
+	if err != nil {
+		return current, err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return current, err
+	}
+
+	// The revert is scheduled first, so an override is never written without it
+	revert, err := service.repo.AddScheduledChange(domain.ScheduledChange{
+		ID:          id.String(),
+		Set:         setName,
+		Operation:   domain.UpdateChange,
+		Item:        previous,
+		EffectiveAt: expiresAt.UTC(),
+		CreateDate:  now,
+		Revert:      true,
+	})
+	if err != nil {
+		return current, err
+	}
+
+	set, err := service.updateItem(item, setName, domain.OverrideAction)
+	if err != nil {
+		_, removeErr := service.repo.RemoveScheduledChange(setName, revert.ID)
+		if removeErr != nil {
+			log.Error().Stack().Err(removeErr).Msgf("Can't remove revert %q of a failed override", revert.ID)
+		}
+		return set, err
+	}
+
+	if found {
+		// If the revert was already claimed the scheduler restores the previous item anyway
+		service.repo.RemoveScheduledChange(setName, active.ID)
+	}
+
+	return set, nil
+}
+
+func (service *ConfigService) GetOverrides() ([]domain.Override, error) {
+	overrides := []domain.Override{}
+	for skip := 0; ; skip += listPageSize {
+		sets, err := service.repo.ListSets(listPageSize, skip)
+		if err != nil {
+			return overrides, err
+		}
+
+		for _, set := range sets {
+			changes, err := service.repo.GetScheduledChanges(set.Name)
+			if err != nil {
+				return overrides, err
+			}
+
+			for _, change := range changes {
+				if change.Revert {
+					overrides = append(overrides, domain.NewOverride(change))
+				}
+			}
+		}
+
+		if len(sets) < listPageSize {
+			break
+		}
+	}
+
+	sort.SliceStable(overrides, func(i, j int) bool {
+		return overrides[i].ExpiresAt.Before(overrides[j].ExpiresAt)
+	})
+	return overrides, nil
+}
+
+// findOverride returns the pending revert of the given item if any
+func (service *ConfigService) findOverride(setName string, key string) (domain.ScheduledChange, bool, error) {
+	changes, err := service.repo.GetScheduledChanges(setName)
+	if err != nil {
+		return domain.ScheduledChange{}, false, err
+	}
+
+	for _, change := range changes {
+		if change.Revert && change.Item.Key == key {
+			return change, true, nil
+		}
+	}
+
+	return domain.ScheduledChange{}, false, nil
+}
+
+// cancelOverride drops the pending revert of an item written after being overridden,
+// the write already happened so errors are only logged
+func (service *ConfigService) cancelOverride(setName string, key string) {
+	active, found, err := service.findOverride(setName, key)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Can't read overrides of set: %q", setName)
+		return
+	}
+
+	if found {
+		service.repo.RemoveScheduledChange(setName, active.ID)
+	}
+}
+
+// cancelOverrides drops every pending revert of a set whose items are about to be replaced,
+// errors are only logged so the replacement isn't blocked by a revert that can't be removed
+func (service *ConfigService) cancelOverrides(setName string) {
+	changes, err := service.repo.GetScheduledChanges(setName)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Can't read overrides of set: %q", setName)
+		return
+	}
+
+	for _, change := range changes {
+		if !change.Revert {
+			continue
+		}
+
+		_, err = service.repo.RemoveScheduledChange(setName, change.ID)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Can't cancel override %q of set: %q", change.ID, setName)
+		}
+	}
+}
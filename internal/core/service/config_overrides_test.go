@@ -0,0 +1,234 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+// expireOverrides makes every pending revert of the repository due
+func expireOverrides(repo *mocks.MemRepo) {
+	for id, change := range repo.Scheduled {
+		if change.Revert {
+			change.EffectiveAt = datetime.UnixUTCNow().Add(-time.Second)
+			repo.Scheduled[id] = change
+		}
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test expired overrides revert to the previous value", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+
+		expiresAt := datetime.UnixUTCNow().Add(time.Hour)
+		_, err := service.OverrideItem(*domain.NewConfigItem("timeout", "30s", domain.Plain), "app", expiresAt)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		// A second override keeps the value from before the first one
+		_, err = service.OverrideItem(*domain.NewConfigItem("timeout", "60s", domain.Plain), "app", expiresAt)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		overrides, _ := service.GetOverrides()
+		if len(overrides) != 1 || overrides[0].Key != "timeout" || overrides[0].Previous.Value != "10s" {
+			t.Fatalf("Expected a single override of timeout, got: %+v", overrides)
+		}
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		if string(got) != `{"timeout":"60s"}` {
+			t.Errorf("Expected the overridden value, got: %s", got)
+		}
+
+		expireOverrides(mockRepo)
+		_, err = service.ApplyDueChanges()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		got, _ = service.GetSetJson("app", domain.AnyAge)
+		if string(got) != `{"timeout":"10s"}` {
+			t.Errorf("Expected the previous value, got: %s", got)
+		}
+
+		revisions, _ := service.GetRevisions("app", 1, 0)
+		if len(revisions) != 1 || revisions[0].Action != domain.RevertAction || revisions[0].Detail != "timeout" {
+			t.Errorf("Expected a revert revision, got: %+v", revisions)
+		}
+
+		overrides, _ = service.GetOverrides()
+		if len(overrides) != 0 {
+			t.Errorf("Expected no active overrides, got: %+v", overrides)
+		}
+	})
+
+	t.Run("Test later writes cancel the revert", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("retries", 3, domain.Plain), "app")
+
+		expiresAt := datetime.UnixUTCNow().Add(time.Hour)
+		service.OverrideItem(*domain.NewConfigItem("timeout", "30s", domain.Plain), "app", expiresAt)
+		service.OverrideItem(*domain.NewConfigItem("retries", 5, domain.Plain), "app", expiresAt)
+
+		service.UpdateItem(*domain.NewConfigItem("timeout", "20s", domain.Plain), "app")
+		service.RemoveItem(domain.ConfigItem{Key: "retries"}, "app")
+
+		overrides, _ := service.GetOverrides()
+		if len(overrides) != 0 {
+			t.Errorf("Expected overrides to be cancelled, got: %+v", overrides)
+		}
+	})
+
+	t.Run("Test invalid overrides are rejected", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+
+		_, err := service.OverrideItem(*domain.NewConfigItem("timeout", "30s", domain.Plain), "app", datetime.UnixUTCNow().Add(-time.Second))
+		if err != domain.ErrExpiresAtInPast {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrExpiresAtInPast, err)
+		}
+
+		_, err = service.OverrideItem(*domain.NewConfigItem("missing", "30s", domain.Plain), "app", datetime.UnixUTCNow().Add(time.Hour))
+		if err != domain.ErrKeyNotExists {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrKeyNotExists, err)
+		}
+
+		overrides, _ := service.GetOverrides()
+		if len(overrides) != 0 {
+			t.Errorf("Expected no overrides, got: %+v", overrides)
+		}
+	})
+
+	t.Run("Test failed override writes leave no revert", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		expiresAt := datetime.UnixUTCNow().Add(time.Hour)
+
+		mockRepo.AddScheduledChangeInterceptor = func(change domain.ScheduledChange) (domain.ScheduledChange, error) {
+			return domain.ScheduledChange{}, errors.New("connection lost")
+		}
+		_, err := service.OverrideItem(*domain.NewConfigItem("timeout", "30s", domain.Plain), "app", expiresAt)
+		if err == nil {
+			t.Errorf("Expected the override to fail")
+		}
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		if string(got) != `{"timeout":"10s"}` {
+			t.Errorf("Expected no override without its revert, got: %s", got)
+		}
+
+		mockRepo.AddScheduledChangeInterceptor = nil
+		mockRepo.UpdateItemInterceptor = func(item domain.ConfigItem, setName string) (domain.ConfigSet, error) {
+			return domain.ConfigSet{}, errors.New("connection lost")
+		}
+		_, err = service.OverrideItem(*domain.NewConfigItem("timeout", "30s", domain.Plain), "app", expiresAt)
+		if err == nil {
+			t.Errorf("Expected the override to fail")
+		}
+
+		overrides, _ := service.GetOverrides()
+		if len(overrides) != 0 {
+			t.Errorf("Expected the revert to be removed, got: %+v", overrides)
+		}
+	})
+
+	t.Run("Test reverts are applied to frozen sets and failed reverts are recorded", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("retries", "3", domain.Plain), "app")
+		expiresAt := datetime.UnixUTCNow().Add(time.Hour)
+		service.OverrideItem(*domain.NewConfigItem("timeout", "30s", domain.Plain), "app", expiresAt)
+		service.OverrideItem(*domain.NewConfigItem("retries", "5", domain.Plain), "app", expiresAt)
+		service.FreezeSet("app", "incident", nil)
+
+		var failRetries func(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
+		failRetries = func(item domain.ConfigItem, setName string) (domain.ConfigSet, error) {
+			if item.Key == "retries" {
+				return domain.ConfigSet{}, errors.New("connection lost")
+			}
+
+			mockRepo.UpdateItemInterceptor = nil
+			defer func() { mockRepo.UpdateItemInterceptor = failRetries }()
+			return mockRepo.UpdateItem(item, setName)
+		}
+		mockRepo.UpdateItemInterceptor = failRetries
+
+		expireOverrides(mockRepo)
+		service.ApplyDueChanges()
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		if string(got) != `{"retries":"5","timeout":"10s"}` {
+			t.Errorf("Expected the frozen set to be reverted, got: %s", got)
+		}
+
+		overrides, _ := service.GetOverrides()
+		if len(overrides) != 0 {
+			t.Errorf("Expected no pending reverts, got: %+v", overrides)
+		}
+
+		failed, _ := service.GetFailedChanges("app")
+		if len(failed) != 1 || failed[0].Item.Key != "retries" || !failed[0].Revert {
+			t.Errorf("Expected the failed revert to be recorded, got: %+v", failed)
+		}
+	})
+
+	t.Run("Test reverts can't be cancelled as scheduled changes", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		service.OverrideItem(*domain.NewConfigItem("timeout", "30s", domain.Plain), "app", datetime.UnixUTCNow().Add(time.Hour))
+
+		overrides, _ := service.GetOverrides()
+		if len(overrides) != 1 {
+			t.Fatalf("Expected one override, got: %+v", overrides)
+		}
+
+		_, err := service.CancelScheduledChange("app", overrides[0].ID)
+		if err != domain.ErrRevertNotCancellable {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrRevertNotCancellable, err)
+		}
+
+		overrides, _ = service.GetOverrides()
+		if len(overrides) != 1 {
+			t.Errorf("Expected the revert to stay scheduled, got: %+v", overrides)
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"strconv"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+func (service *ConfigService) GetSetValue(name string, path []string) (interface{}, error) {
+	set, err := service.GetSet(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.valueAt(set, path, &renderContext{})
+}
+
+// valueAt renders the set along the path and returns the value found at the end of it
+func (service *ConfigService) valueAt(set domain.ConfigSet, path []string, ctx *renderContext) (interface{}, error) {
+	// The value is read by another key, the deprecated items along the path aren't rendered as themselves
+	pathCtx := *ctx
+	pathCtx.deprecations = nil
+	rendered, err := service.renderPath(set, path, 0, &pathCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	var current interface{} = rendered.values
+	for i, segment := range path {
+		var found bool
+		switch node := current.(type) {
+		case map[string]interface{}:
+			current, found = node[segment]
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			found = err == nil && index >= 0 && index < len(node)
+			if found {
+				current = node[index]
+			}
+		}
+
+		if !found {
+			return nil, &domain.PathNotFoundError{Path: path, Index: i}
+		}
+	}
+
+	return current, nil
+}
+
+// renderPath renders only the items along path[index:] with the same merge rules as renderSet,
+// siblings of the path are left out so their secrets and nested sets are never resolved
+func (service *ConfigService) renderPath(set domain.ConfigSet, path []string, index int, ctx *renderContext) (renderedSet, error) {
+	if index == len(path) {
+		return service.renderSet(set, ctx)
+	}
+
+	rendered := renderedSet{
+		values:  map[string]interface{}{},
+		origins: map[string]interface{}{},
+	}
+
+	layers, err := service.overlayLayers(set, ctx)
+	if err != nil {
+		return rendered, err
+	}
+
+	key := path[index]
+	for _, layer := range layers {
+		item, ok := layer.Items[key]
+		if !ok {
+			continue
+		}
+
+		value, origin, err := service.renderPathItem(item, layer, set, path, index+1, ctx)
+		if err != nil {
+			return rendered, err
+		}
+
+		mergeValue(rendered.values, rendered.origins, key, value, origin)
+	}
+
+	return rendered, nil
+}
+
+// renderPathItem works like renderItem but nested sets are rendered only along path[index:]
+func (service *ConfigService) renderPathItem(item domain.ConfigItem, set domain.ConfigSet, self domain.ConfigSet, path []string, index int, ctx *renderContext) (interface{}, interface{}, error) {
+	if ctx.attributes != nil {
+		item.Value = item.ValueFor(*ctx.attributes)
+	}
+
+	switch item.Type {
+	case domain.Nested:
+		name, ok := item.Value.(string)
+		if !ok {
+			return nil, nil, domain.ErrInvalidNestedKeyValue
+		}
+		leave, err := service.enterNested(name, self, ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer leave()
+
+		nestedSet, err := service.loadSet(name, ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rendered, err := service.renderPath(nestedSet, path, index, ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return rendered.values, rendered.origins, nil
+	case domain.Ref:
+		if index == len(path) {
+			return service.renderItem(item, set, self, ctx)
+		}
+
+		// Only the value at the end of the path is resolved, it's wrapped back so valueAt can walk to it
+		value, err := service.resolveRef(item, self, path[index:], ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for i := len(path) - 1; i >= index; i-- {
+			value = map[string]interface{}{path[i]: value}
+		}
+
+		return value, set.Name, nil
+	default:
+		if index < len(path) {
+			// The path continues below this value so secrets inside strings are never returned
+			placeholderCtx := *ctx
+			placeholderCtx.secretPlaceholders = true
+			return service.renderItem(item, set, self, &placeholderCtx)
+		}
+
+		return service.renderItem(item, set, self, ctx)
+	}
+}
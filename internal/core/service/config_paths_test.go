@@ -0,0 +1,122 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestGetSetValue(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test values are found through nested sets and plain values", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{
+			Values: map[string]string{"db/password": "pass"},
+		}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("pool")
+		service.AddItem(*domain.NewConfigItem("max", 10, domain.Plain), "pool")
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("pool", "pool", domain.Nested), "db")
+		service.AddItem(*domain.NewConfigItem("password", "db/password", domain.Secret), "db")
+		service.AddItem(*domain.NewConfigItem("hosts", []interface{}{"a", "b"}, domain.Plain), "db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("db", "db", domain.Nested), "app")
+		// Never resolved unless requested
+		service.AddItem(*domain.NewConfigItem("apiKey", "missing/secret", domain.Secret), "app")
+
+		cases := []struct {
+			path     []string
+			expected interface{}
+		}{
+			{[]string{"db", "pool", "max"}, 10},
+			{[]string{"db", "pool"}, map[string]interface{}{"max": 10}},
+			{[]string{"db", "password"}, "pass"},
+			{[]string{"db", "hosts", "1"}, "b"},
+		}
+
+		for _, c := range cases {
+			got, err := service.GetSetValue("app", c.path)
+			if err != nil {
+				t.Errorf("Expected path %v to be found without errors, got: %v", c.path, err)
+			}
+
+			if !cmp.Equal(got, c.expected) {
+				t.Errorf("Expected value at %v: %v, got: %v", c.path, c.expected, got)
+			}
+		}
+	})
+
+	t.Run("Test the first missing segment is reported", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db")
+		service.AddItem(*domain.NewConfigItem("password", "db/password", domain.Secret), "db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("db", "db", domain.Nested), "app")
+
+		cases := []struct {
+			path    []string
+			segment string
+		}{
+			{[]string{"cache", "host"}, "cache"},
+			{[]string{"db", "pool", "max"}, "pool"},
+			{[]string{"db", "host", "name"}, "name"},
+			{[]string{"db", "password", "value"}, "value"},
+		}
+
+		for _, c := range cases {
+			_, err := service.GetSetValue("app", c.path)
+			pathErr, ok := err.(*domain.PathNotFoundError)
+			if !ok {
+				t.Errorf("Expected a path error for %v, got: %v", c.path, err)
+				continue
+			}
+
+			if pathErr.Segment() != c.segment {
+				t.Errorf("Expected missing segment: %q, got: %q", c.segment, pathErr.Segment())
+			}
+		}
+	})
+
+	t.Run("Test overlays are merged along the path", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db-common")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db-common")
+		service.AddItem(*domain.NewConfigItem("port", 5432, domain.Plain), "db-common")
+		service.CreateSet("db-prod")
+		service.AddItem(*domain.NewConfigItem("host", "db.prod", domain.Plain), "db-prod")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("db", "db-common", domain.Nested), "app")
+		service.CreateSet("app-prod")
+		service.AddItem(*domain.NewConfigItem("db", "db-prod", domain.Nested), "app-prod")
+		service.SetBase("app-prod", "app")
+
+		got, err := service.GetSetValue("app-prod", []string{"db"})
+		if err != nil {
+			t.Errorf("Expected path to be found without errors, got: %v", err)
+		}
+
+		expected := map[string]interface{}{"host": "db.prod", "port": 5432}
+		if !cmp.Equal(got, expected) {
+			t.Errorf("Expected value: %v, got: %v", expected, got)
+		}
+
+		got, _ = service.GetSetValue("app-prod", []string{"db", "port"})
+		if got != 5432 {
+			t.Errorf("Expected value: %v, got: %v", 5432, got)
+		}
+	})
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"sort"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+func (service *ConfigService) GetReferrers(name string) ([]domain.SetReferrer, error) {
+	names, err := service.repo.GetReferrers(name)
+	if err != nil {
+		return nil, err
+	}
+
+	referrers := []domain.SetReferrer{}
+	for _, referrerName := range names {
+		set, err := service.GetSet(referrerName)
+		if err == ports.ErrConfigNotExists {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		// The index is only a hint, the set content is the source of truth
+		referrer := domain.SetReferrer{
+			Name: referrerName,
+			Base: set.Base == name,
+		}
+		for _, key := range sortedKeys(set.Items) {
+			if containsString(itemTargets(set.Items[key]), name) {
+				referrer.Keys = append(referrer.Keys, key)
+			}
+		}
+
+		if referrer.Base || len(referrer.Keys) > 0 {
+			referrers = append(referrers, referrer)
+		}
+	}
+
+	return referrers, nil
+}
+
+func (service *ConfigService) RebuildReferrers() error {
+	for skip := 0; ; skip += listPageSize {
+		sets, err := service.repo.ListSets(listPageSize, skip)
+		if err != nil {
+			return err
+		}
+
+		for _, set := range sets {
+			for _, target := range references(set) {
+				err = service.repo.AddReferrer(target, set.Name)
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(sets) < listPageSize {
+			return nil
+		}
+	}
+}
+
+// checkReferrers fails with a *domain.ReferencedError if any set references the set with the given name
+func (service *ConfigService) checkReferrers(name string) ([]domain.SetReferrer, error) {
+	referrers, err := service.GetReferrers(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(referrers) == 0 {
+		return referrers, nil
+	}
+
+	names := make([]string, 0, len(referrers))
+	for _, referrer := range referrers {
+		names = append(names, referrer.Name)
+	}
+
+	return referrers, &domain.ReferencedError{Name: name, Referrers: names}
+}
+
+// retargetSet returns a copy of the set pointing at newName everywhere it pointed at oldName
+func retargetSet(set domain.ConfigSet, oldName string, newName string) domain.ConfigSet {
+	set = set.Copy()
+	for key, item := range set.Items {
+		if containsString(itemTargets(item), oldName) {
+			set.Items[key] = retargetItem(item, oldName, newName)
+		}
+	}
+
+	if set.Base == oldName {
+		set.Base = newName
+	}
+
+	set.UpdateDate = datetime.UnixUTCNow()
+	return set
+}
+
+// syncReferrers updates the referrers index after the references of the set with the given name changed
+// For now, errors are only logged so they don't fail the write
+func (service *ConfigService) syncReferrers(name string, before []string, after []string) {
+	for _, target := range before {
+		if containsString(after, target) {
+			continue
+		}
+
+		err := service.repo.RemoveReferrer(target, name)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Can't remove referrer %q of %q", name, target)
+		}
+	}
+
+	for _, target := range after {
+		if containsString(before, target) {
+			continue
+		}
+
+		err := service.repo.AddReferrer(target, name)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Can't add referrer %q of %q", name, target)
+		}
+	}
+}
+
+// invalidateReferrers removes the cached JSON of every set rendering the set with the given name
+func (service *ConfigService) invalidateReferrers(name string) {
+	visited := map[string]bool{name: true}
+	pending := []string{name}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+
+		referrers, err := service.repo.GetReferrers(current)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Can't read referrers of %q", current)
+			continue
+		}
+
+		for _, referrer := range referrers {
+			if visited[referrer] {
+				continue
+			}
+
+			visited[referrer] = true
+			service.cache.RemoveJSON(referrer)
+			pending = append(pending, referrer)
+		}
+	}
+}
+
+// references returns the sorted names of the sets the given set depends on
+// through nested or ref items, ${set:...} interpolations or its base
+func references(set domain.ConfigSet) []string {
+	names := []string{}
+	if set.Base != "" {
+		names = append(names, set.Base)
+	}
+
+	for _, item := range set.Items {
+		for _, target := range itemTargets(item) {
+			// References into the same set are not a dependency on another set
+			if target != set.Name && !containsString(names, target) {
+				names = append(names, target)
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// retargetItem returns the item pointing at the set newName wherever it pointed at oldName
+func retargetItem(item domain.ConfigItem, oldName string, newName string) domain.ConfigItem {
+	switch item.Type {
+	case domain.Ref:
+		if name, path, err := refTarget(item); err == nil && name == oldName {
+			item.Value = newName + ":" + path
+		}
+	case domain.Nested:
+		if item.Value == oldName {
+			item.Value = newName
+		}
+	case domain.Plain:
+		item = renameInterpolatedSet(item, oldName, newName)
+	}
+
+	return item
+}
+
+// itemTargets returns the names of the sets an item points at, as a nested or ref item or through interpolations
+func itemTargets(item domain.ConfigItem) []string {
+	switch item.Type {
+	case domain.Nested:
+		if name, _ := item.Value.(string); name != "" {
+			return []string{name}
+		}
+	case domain.Ref:
+		if name, _, _ := refTarget(item); name != "" {
+			return []string{name}
+		}
+	case domain.Plain:
+		return interpolatedSets(item)
+	}
+
+	return nil
+}
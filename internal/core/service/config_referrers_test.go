@@ -0,0 +1,331 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestReferrers(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test referrers are indexed on write", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.CreateSet("app")
+		service.CreateSet("app-prod")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+		service.AddItem(*domain.NewConfigItem("replica", "db", domain.Nested), "app")
+		service.SetBase("app-prod", "app")
+
+		got, err := service.GetReferrers("db")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := []domain.SetReferrer{{Name: "app", Keys: []string{"database", "replica"}}}
+		if !cmp.Equal(got, expected) {
+			t.Errorf("Expected referrers: %+v, got: %+v", expected, got)
+		}
+
+		got, _ = service.GetReferrers("app")
+		expected = []domain.SetReferrer{{Name: "app-prod", Base: true}}
+		if !cmp.Equal(got, expected) {
+			t.Errorf("Expected referrers: %+v, got: %+v", expected, got)
+		}
+
+		service.RemoveItem(domain.ConfigItem{Key: "database"}, "app")
+		service.RemoveItem(domain.ConfigItem{Key: "replica"}, "app")
+		got, _ = service.GetReferrers("db")
+		if len(got) != 0 {
+			t.Errorf("Expected no referrers after removing items, got: %+v", got)
+		}
+	})
+
+	t.Run("Test referenced sets can't be deleted", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+
+		_, err := service.DeleteSet("db", false)
+		refErr, ok := err.(*domain.ReferencedError)
+		if !ok {
+			t.Fatalf("Expected a referenced error, got: %v", err)
+		}
+
+		if !cmp.Equal(refErr.Referrers, []string{"app"}) {
+			t.Errorf("Expected referrers: [app], got: %v", refErr.Referrers)
+		}
+
+		_, err = service.GetSet("db")
+		if err != nil {
+			t.Errorf("Expected set to still exist, got: %v", err)
+		}
+
+		_, err = service.DeleteSet("db", true)
+		if err != nil {
+			t.Errorf("Expected forced delete to succeed, got: %v", err)
+		}
+	})
+
+	t.Run("Test referenced sets can't be renamed without rewrite", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+
+		_, err := service.RenameSet("db", "postgres", false)
+		if _, ok := err.(*domain.ReferencedError); !ok {
+			t.Errorf("Expected a referenced error, got: %v", err)
+		}
+
+		_, err = service.GetSet("postgres")
+		if err == nil {
+			t.Errorf("Expected set to not be renamed")
+		}
+	})
+
+	t.Run("Test rename rewrites referrers", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+		service.CreateSet("db-prod")
+		service.SetBase("db-prod", "db")
+		service.GetSetJson("app", domain.AnyAge)
+
+		_, err := service.RenameSet("db", "postgres", true)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		app, _ := service.GetSet("app")
+		if app.Items["database"].Value != "postgres" {
+			t.Errorf("Expected nested item to point at postgres, got: %v", app.Items["database"].Value)
+		}
+
+		prod, _ := service.GetSet("db-prod")
+		if prod.Base != "postgres" {
+			t.Errorf("Expected base to be postgres, got: %q", prod.Base)
+		}
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		expected := `{"database":{"host":"localhost"}}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+
+		revs, _ := service.GetRevisions("app", 10, 0)
+		if revs[0].Action != domain.RewriteAction || revs[0].Detail != "db -> postgres" {
+			t.Errorf("Expected a rewrite revision, got: %+v", revs[0])
+		}
+
+		referrers, _ := service.GetReferrers("postgres")
+		if len(referrers) != 2 {
+			t.Errorf("Expected 2 referrers of the new name, got: %+v", referrers)
+		}
+	})
+
+	t.Run("Test rename rewrites refs into the set itself", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db")
+		service.AddItem(*domain.NewConfigItem("primary", "db:host", domain.Ref), "db")
+
+		renamed, err := service.RenameSet("db", "postgres", false)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if value := renamed.Items["primary"].Value; value != "postgres:host" {
+			t.Errorf("Expected the ref to point at postgres, got: %v", value)
+		}
+
+		got, _ := service.GetSetJson("postgres", domain.AnyAge)
+		expected := `{"host":"localhost","primary":"localhost"}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+	})
+
+	t.Run("Test a failed rename leaves every set untouched", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.CreateSet("api")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "api")
+		service.CreateSet("worker")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "worker")
+
+		mockRepo.ReplaceSetInterceptor = func(set domain.ConfigSet) (domain.ConfigSet, error) {
+			if set.Name == "worker" && set.Items["database"].Value == "postgres" {
+				return domain.ConfigSet{}, errors.New("write failed")
+			}
+
+			mockRepo.Sets[set.Name] = &set
+			return set, nil
+		}
+
+		_, err := service.RenameSet("db", "postgres", true)
+		if err == nil {
+			t.Fatalf("Expected the rename to fail")
+		}
+
+		if _, err := service.GetSet("db"); err != nil {
+			t.Errorf("Expected the set to keep its name, got: %v", err)
+		}
+
+		if _, err := service.GetSet("postgres"); err != ports.ErrConfigNotExists {
+			t.Errorf("Expected the new name to not exist, got: %v", err)
+		}
+
+		for _, name := range []string{"api", "worker"} {
+			referrer, _ := service.GetSet(name)
+			if value := referrer.Items["database"].Value; value != "db" {
+				t.Errorf("Expected %s to still point at db, got: %v", name, value)
+			}
+		}
+	})
+
+	t.Run("Test changes invalidate the cache of referrers", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+		service.GetSetJson("app", domain.AnyAge)
+
+		service.UpdateItem(*domain.NewConfigItem("host", "remote", domain.Plain), "db")
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		expected := `{"database":{"host":"remote"}}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+	})
+
+	t.Run("Test interpolated sets are referrers", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("shared")
+		service.AddItem(*domain.NewConfigItem("x", "one", domain.Plain), "shared")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("url", "http://${set:shared:x}/", domain.Plain), "app")
+		service.GetSetJson("app", domain.AnyAge)
+
+		service.UpdateItem(*domain.NewConfigItem("x", "two", domain.Plain), "shared")
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		expected := `{"url":"http://two/"}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+
+		referrers, _ := service.GetReferrers("shared")
+		expectedReferrers := []domain.SetReferrer{{Name: "app", Keys: []string{"url"}}}
+		if !cmp.Equal(referrers, expectedReferrers) {
+			t.Errorf("Expected referrers: %+v, got: %+v", expectedReferrers, referrers)
+		}
+
+		_, err := service.DeleteSet("shared", false)
+		if _, ok := err.(*domain.ReferencedError); !ok {
+			t.Errorf("Expected a referenced error, got: %v", err)
+		}
+	})
+
+	t.Run("Test rename rewrites interpolated references", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("shared")
+		service.AddItem(*domain.NewConfigItem("x", "one", domain.Plain), "shared")
+		service.CreateSet("app")
+		item := domain.NewConfigItem("url", "${set:shared:x}/$${set:shared:x}", domain.Plain)
+		item.Variants = []domain.Variant{{
+			When:  domain.VariantCondition{Regions: []string{"eu"}},
+			Value: "${set:shared}",
+		}}
+		service.AddItem(*item, "app")
+
+		_, err := service.RenameSet("shared", "common", true)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		app, _ := service.GetSet("app")
+		if value := app.Items["url"].Value; value != "${set:common:x}/$${set:shared:x}" {
+			t.Errorf("Expected the reference to point at common and the escaped one to be kept, got: %v", value)
+		}
+
+		if value := app.Items["url"].Variants[0].Value; value != "${set:common}" {
+			t.Errorf("Expected the variant to point at common, got: %v", value)
+		}
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		expected := `{"url":"one/${set:shared:x}"}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+	})
+
+	t.Run("Test rebuild indexes existing sets", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		mockRepo.CreateSet(domain.ConfigSet{Name: "db", Items: map[string]domain.ConfigItem{}})
+		mockRepo.CreateSet(domain.ConfigSet{
+			Name:  "app",
+			Items: map[string]domain.ConfigItem{"database": *domain.NewConfigItem("database", "db", domain.Nested)},
+		})
+
+		err := service.RebuildReferrers()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		got, _ := service.GetReferrers("db")
+		expected := []domain.SetReferrer{{Name: "app", Keys: []string{"database"}}}
+		if !cmp.Equal(got, expected) {
+			t.Errorf("Expected referrers: %+v, got: %+v", expected, got)
+		}
+	})
+}
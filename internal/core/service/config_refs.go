@@ -0,0 +1,55 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+// refTarget returns the set name and path a ref item points at, e.g. "database:credentials.user"
+func refTarget(item domain.ConfigItem) (string, string, error) {
+	value, ok := item.Value.(string)
+	if !ok {
+		return "", "", domain.ErrRefKeyValue
+	}
+
+	name, path := splitReference(value)
+	if name == "" || path == "" {
+		return "", "", domain.ErrRefKeyValue
+	}
+
+	return name, path, nil
+}
+
+// checkRef verifies the target of a ref item exists, set is the set holding the item after the write
+func (service *ConfigService) checkRef(item domain.ConfigItem, set domain.ConfigSet) error {
+	if item.Type != domain.Ref {
+		return nil
+	}
+
+	_, err := service.resolveRef(item, set, nil, &renderContext{secretPlaceholders: true, pending: &set})
+	return err
+}
+
+// resolveRef returns the value a ref item points at, followed by the given extra path
+func (service *ConfigService) resolveRef(item domain.ConfigItem, self domain.ConfigSet, extra []string, ctx *renderContext) (interface{}, error) {
+	name, path, err := refTarget(item)
+	if err != nil {
+		return nil, err
+	}
+
+	id := self.Name + ":" + item.Key
+	if containsString(ctx.references, id) {
+		return nil, domain.NewCycleError(ctx.references, id)
+	}
+
+	ctx.references = append(ctx.references, id)
+	defer func() { ctx.references = ctx.references[:len(ctx.references)-1] }()
+
+	if len(extra) > 0 {
+		path += referencePathSeparator + strings.Join(extra, referencePathSeparator)
+	}
+
+	value, _, err := service.resolveReference(setReference+":"+name+":"+path, self, ctx)
+	return value, err
+}
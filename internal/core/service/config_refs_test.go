@@ -0,0 +1,141 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestRefItems(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test refs resolve plain, secret and nested targets", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{
+			Values: map[string]string{"db/password": "s3cr3t"},
+		}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("credentials")
+		service.AddItem(*domain.NewConfigItem("user", "admin", domain.Plain), "credentials")
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db")
+		service.AddItem(*domain.NewConfigItem("password", "db/password", domain.Secret), "db")
+		service.AddItem(*domain.NewConfigItem("credentials", "credentials", domain.Nested), "db")
+		service.CreateSet("app")
+
+		for _, item := range []*domain.ConfigItem{
+			domain.NewConfigItem("host", "db:host", domain.Ref),
+			domain.NewConfigItem("password", "db:password", domain.Ref),
+			domain.NewConfigItem("user", "db:credentials.user", domain.Ref),
+			domain.NewConfigItem("credentials", "db:credentials", domain.Ref),
+			domain.NewConfigItem("alias", "app:host", domain.Ref),
+		} {
+			_, err := service.AddItem(*item, "app")
+			if err != nil {
+				t.Fatalf("Expected no error adding %q, got: %v", item.Key, err)
+			}
+		}
+
+		got, err := service.GetSetJson("app", domain.AnyAge)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		expected := `{"alias":"localhost","credentials":{"user":"admin"},"host":"localhost","password":"s3cr3t","user":"admin"}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+
+		value, err := service.GetSetValue("app", []string{"credentials", "user"})
+		if err != nil || value != "admin" {
+			t.Errorf("Expected value: admin, got: %v, %v", value, err)
+		}
+	})
+
+	t.Run("Test refs to missing targets are rejected on write", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db")
+		service.CreateSet("app")
+
+		_, err := service.AddItem(*domain.NewConfigItem("host", "infra:host", domain.Ref), "app")
+		if _, ok := err.(*domain.ReferenceError); !ok {
+			t.Errorf("Expected a reference error for a missing set, got: %v", err)
+		}
+
+		_, err = service.AddItem(*domain.NewConfigItem("host", "db:port", domain.Ref), "app")
+		if _, ok := err.(*domain.ReferenceError); !ok {
+			t.Errorf("Expected a reference error for a missing key, got: %v", err)
+		}
+
+		_, err = service.AddItem(*domain.NewConfigItem("host", "db", domain.Ref), "app")
+		if err != domain.ErrRefKeyValue {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrRefKeyValue, err)
+		}
+
+		set, _ := service.GetSet("app")
+		if len(set.Items) != 0 {
+			t.Errorf("Expected set to not change, got: %+v", set.Items)
+		}
+	})
+
+	t.Run("Test ref cycles are rejected on write", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("a")
+		service.CreateSet("b")
+		service.AddItem(*domain.NewConfigItem("x", "value", domain.Plain), "a")
+		service.AddItem(*domain.NewConfigItem("y", "a:x", domain.Ref), "b")
+
+		_, err := service.UpdateItem(*domain.NewConfigItem("x", "b:y", domain.Ref), "a")
+		cycleErr, ok := err.(*domain.CycleError)
+		if !ok {
+			t.Fatalf("Expected a cycle error, got: %v", err)
+		}
+
+		expected := []string{"a:x", "b:y", "a:x"}
+		if !cmp.Equal(cycleErr.Chain, expected) {
+			t.Errorf("Expected chain: %v, got: %v", expected, cycleErr.Chain)
+		}
+	})
+
+	t.Run("Test refs are referrers of their target set", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("host", "db:host", domain.Ref), "app")
+		service.AddItem(*domain.NewConfigItem("alias", "app:host", domain.Ref), "app")
+
+		_, err := service.DeleteSet("app", false)
+		if err != nil {
+			t.Errorf("Expected refs into the same set to not block deletes, got: %v", err)
+		}
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("host", "db:host", domain.Ref), "app")
+		_, err = service.RenameSet("db", "postgres", true)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		set, _ := service.GetSet("app")
+		if set.Items["host"].Value != "postgres:host" {
+			t.Errorf("Expected ref to be rewritten, got: %v", set.Items["host"].Value)
+		}
+	})
+}
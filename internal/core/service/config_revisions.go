@@ -0,0 +1,133 @@
+package service
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+func (service *ConfigService) GetRevisions(name string, count int, skip int) ([]domain.ConfigRevision, error) {
+	return service.repo.GetRevisions(name, count, skip)
+}
+
+func (service *ConfigService) GetRevision(name string, revision int) (domain.ConfigRevision, error) {
+	return service.repo.GetRevision(name, revision)
+}
+
+func (service *ConfigService) RollbackSet(name string, revision int) (domain.ConfigSet, error) {
+	set, err := service.GetSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	err = service.checkFrozen(set)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	rev, err := service.repo.GetRevision(name, revision)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	if rev.Deleted {
+		return domain.ConfigSet{}, domain.ErrDeletedRevision
+	}
+
+	before := references(set)
+	candidate := set.Copy()
+	candidate.Items = rev.Set.Copy().Items
+	err = service.checkItems(candidate)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	// Pending reverts would overwrite the rolled back items once their overrides expire
+	service.cancelOverrides(name)
+	candidate.UpdateDate = datetime.UnixUTCNow()
+	set, err = service.repo.ReplaceSet(candidate)
+	if err != nil {
+		return set, err
+	}
+
+	service.updateCache(set)
+	service.syncReferrers(name, before, references(set))
+	service.addRevision(set, domain.RollbackAction, strconv.Itoa(revision), false)
+	return set, nil
+}
+
+func (service *ConfigService) DiffSets(name string, against string) (domain.ConfigDiff, error) {
+	set, err := service.GetSet(name)
+	if err != nil {
+		return domain.ConfigDiff{}, err
+	}
+
+	var base domain.ConfigSet
+	if revision, convErr := strconv.Atoi(against); convErr == nil {
+		rev, err := service.repo.GetRevision(name, revision)
+		if err != nil {
+			return domain.ConfigDiff{}, err
+		}
+
+		if rev.Deleted {
+			return domain.ConfigDiff{}, domain.ErrDeletedRevision
+		}
+
+		base = rev.Set
+	} else {
+		base, err = service.GetSet(against)
+		if err != nil {
+			return domain.ConfigDiff{}, err
+		}
+	}
+
+	return domain.DiffSets(base, set), nil
+}
+
+// getSetAt returns the state of a set at the given time using its revisions
+func (service *ConfigService) getSetAt(name string, at time.Time) (domain.ConfigSet, error) {
+	rev, err := service.repo.GetRevisionAt(name, at)
+	if err == ports.ErrRevisionNotExists {
+		// Sets written before revisions were recorded have no history,
+		// their current state is only valid if it didn't change after the given time
+		set, err := service.GetSet(name)
+		if err != nil {
+			return domain.ConfigSet{}, err
+		}
+
+		if set.UpdateDate.After(at) {
+			return domain.ConfigSet{}, ports.ErrConfigNotExists
+		}
+
+		return set, nil
+	}
+
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	if rev.Deleted {
+		return domain.ConfigSet{}, ports.ErrConfigNotExists
+	}
+
+	return rev.Set, nil
+}
+
+// addRevision records the given set state in its history
+// the write was already done at this point so errors are only logged
+func (service *ConfigService) addRevision(set domain.ConfigSet, action domain.RevisionAction, detail string, deleted bool) {
+	_, err := service.repo.AddRevision(domain.ConfigRevision{
+		Action:  action,
+		Detail:  detail,
+		Deleted: deleted,
+		Set:     set.Copy(),
+	})
+
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Can't save revision of set: %q", set.Name)
+	}
+}
@@ -0,0 +1,454 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestRevisions(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test every write produces a revision", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		name := "mySet"
+		service.CreateSet(name)
+		item := domain.ConfigItem{
+			Key:   "myKey",
+			Value: 100,
+			Type:  domain.Plain,
+		}
+		service.AddItem(item, name)
+		item.Value = 200
+		service.UpdateItem(item, name)
+		service.RemoveItem(item, name)
+
+		revs, err := service.GetRevisions(name, 10, 0)
+		if err != nil {
+			t.Errorf("Expected revisions to be read without errors, got: %v", err)
+		}
+
+		expected := []domain.RevisionAction{
+			domain.RemoveItemAction,
+			domain.UpdateItemAction,
+			domain.AddItemAction,
+			domain.CreateAction,
+		}
+		if len(revs) != len(expected) {
+			t.Fatalf("Expected %d revisions, got: %d", len(expected), len(revs))
+		}
+
+		for i, action := range expected {
+			if revs[i].Action != action {
+				t.Errorf("Expected revision action: %q, got: %q", action, revs[i].Action)
+			}
+
+			if revs[i].Revision != len(expected)-i {
+				t.Errorf("Expected revision number: %d, got: %d", len(expected)-i, revs[i].Revision)
+			}
+		}
+
+		rev, err := service.GetRevision(name, 2)
+		if err != nil {
+			t.Errorf("Expected revision to be read without errors, got: %v", err)
+		}
+
+		gotItem, _ := rev.Set.Get("myKey")
+		if gotItem.Value != 100 {
+			t.Errorf("Expected item value in revision: %v, got: %v", 100, gotItem.Value)
+		}
+	})
+
+	t.Run("Test revisions can be paginated", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		name := "mySet"
+		service.CreateSet(name)
+		for _, key := range []string{"a", "b", "c"} {
+			service.AddItem(*domain.NewConfigItem(key, key, domain.Plain), name)
+		}
+
+		revs, _ := service.GetRevisions(name, 2, 1)
+		if len(revs) != 2 {
+			t.Fatalf("Expected 2 revisions, got: %d", len(revs))
+		}
+
+		if revs[0].Revision != 3 || revs[1].Revision != 2 {
+			t.Errorf("Expected revisions 3 and 2, got: %d and %d", revs[0].Revision, revs[1].Revision)
+		}
+	})
+
+	t.Run("Test delete and rename are recorded", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("old")
+		service.RenameSet("old", "new", false)
+		service.DeleteSet("new", false)
+
+		revs, _ := service.GetRevisions("old", 10, 0)
+		if len(revs) != 2 || revs[0].Action != domain.RenameAction || !revs[0].Deleted {
+			t.Errorf("Expected last revision of old name to be a deleting rename, got: %+v", revs)
+		}
+
+		revs, _ = service.GetRevisions("new", 10, 0)
+		if len(revs) != 2 || revs[0].Action != domain.DeleteAction || !revs[0].Deleted {
+			t.Errorf("Expected last revision of new name to be a delete, got: %+v", revs)
+		}
+
+		if revs[1].Action != domain.RenameAction || revs[1].Detail != "old" {
+			t.Errorf("Expected first revision of new name to be a rename from old, got: %+v", revs[1])
+		}
+	})
+
+	t.Run("Test a non existing revision can't be read", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		name := "mySet"
+		service.CreateSet(name)
+
+		_, err := service.GetRevision(name, 2)
+		if err != ports.ErrRevisionNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrRevisionNotExists, err)
+		}
+	})
+}
+
+func TestRollbackSet(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test a set can be rolled back", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		name := "mySet"
+		service.CreateSet(name)
+		item := domain.ConfigItem{
+			Key:   "myKey",
+			Value: 100,
+			Type:  domain.Plain,
+		}
+		service.AddItem(item, name)
+		item.Value = 200
+		service.UpdateItem(item, name)
+		service.AddItem(*domain.NewConfigItem("other", "value", domain.Plain), name)
+
+		got, err := service.RollbackSet(name, 2)
+		if err != nil {
+			t.Errorf("Expected set to be rolled back without errors, got: %v", err)
+		}
+
+		expected := domain.ConfigItemMap{
+			"myKey": {
+				Key:   "myKey",
+				Value: 100,
+				Type:  domain.Plain,
+			},
+		}
+		if !cmp.Equal(got.Items, expected) {
+			t.Errorf("Expected items: %+v, got: %+v", expected, got.Items)
+		}
+
+		stored, _ := service.GetSet(name)
+		if !cmp.Equal(stored.Items, expected) {
+			t.Errorf("Expected stored items: %+v, got: %+v", expected, stored.Items)
+		}
+
+		jsonBytes, _ := service.GetSetJson(name, domain.AnyAge)
+		if string(jsonBytes) != `{"myKey":100}` {
+			t.Errorf("Expected cached json: %s, got: %s", `{"myKey":100}`, string(jsonBytes))
+		}
+
+		revs, _ := service.GetRevisions(name, 1, 0)
+		if revs[0].Revision != 5 || revs[0].Action != domain.RollbackAction || revs[0].Detail != "2" {
+			t.Errorf("Expected rollback to be recorded as revision 5, got: %+v", revs[0])
+		}
+
+		rev, _ := service.GetRevision(name, 2)
+		if !cmp.Equal(rev.Set.Items, expected) {
+			t.Errorf("Expected old revision to be unchanged: %+v, got: %+v", expected, rev.Set.Items)
+		}
+	})
+
+	t.Run("Test a set can't be rolled back to a missing revision", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		name := "mySet"
+		service.CreateSet(name)
+
+		_, err := service.RollbackSet(name, 10)
+		if err != ports.ErrRevisionNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrRevisionNotExists, err)
+		}
+	})
+
+	t.Run("Test a set can't be rolled back to a deleted revision", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		name := "mySet"
+		service.CreateSet(name)
+		service.DeleteSet(name, false)
+		service.CreateSet(name)
+
+		_, err := service.RollbackSet(name, 2)
+		if err != domain.ErrDeletedRevision {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrDeletedRevision, err)
+		}
+	})
+	t.Run("Test a rollback can't create a nested cycle", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("a")
+		service.CreateSet("b")
+		service.AddItem(*domain.NewConfigItem("b", "b", domain.Nested), "a")
+		service.RemoveItem(domain.ConfigItem{Key: "b"}, "a")
+		service.AddItem(*domain.NewConfigItem("a", "a", domain.Nested), "b")
+
+		_, err := service.RollbackSet("a", 2)
+		if _, ok := err.(*domain.CycleError); !ok {
+			t.Errorf("Expected a cycle error, got: %v", err)
+		}
+
+		set, _ := service.GetSet("a")
+		if len(set.Items) != 0 {
+			t.Errorf("Expected the set to be kept, got: %+v", set.Items)
+		}
+	})
+
+	t.Run("Test a rollback can't point at a deleted nested set", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+		service.RemoveItem(domain.ConfigItem{Key: "database"}, "app")
+		service.DeleteSet("db", false)
+
+		_, err := service.RollbackSet("app", 2)
+		if _, ok := err.(*domain.ReferenceError); !ok {
+			t.Errorf("Expected a reference error, got: %v", err)
+		}
+	})
+
+	t.Run("Test a rollback must satisfy the current schema", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		service.UpdateItem(*domain.NewConfigItem("timeout", 10, domain.Plain), "app")
+		service.SetSchema("app", []byte(`{"properties": {"timeout": {"type": "integer"}}}`))
+
+		_, err := service.RollbackSet("app", 2)
+		if _, ok := err.(*domain.ValidationError); !ok {
+			t.Errorf("Expected a validation error, got: %v", err)
+		}
+	})
+
+	t.Run("Test a rollback cancels pending override reverts", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		service.UpdateItem(*domain.NewConfigItem("timeout", "20s", domain.Plain), "app")
+		service.OverrideItem(*domain.NewConfigItem("timeout", "30s", domain.Plain), "app", time.Now().Add(time.Hour))
+
+		got, err := service.RollbackSet("app", 2)
+		if err != nil || got.Items["timeout"].Value != "10s" {
+			t.Fatalf("Expected the set to be rolled back, got: %+v, %v", got.Items, err)
+		}
+
+		overrides, _ := service.GetOverrides()
+		changes, _ := service.GetScheduledChanges("app")
+		if len(overrides) != 0 || len(changes) != 0 {
+			t.Errorf("Expected no pending reverts, got: %+v", changes)
+		}
+	})
+}
+
+func TestGetSetJsonAt(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test a set is rendered as it was at a given time", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("nested")
+		service.AddItem(*domain.NewConfigItem("key", 1, domain.Plain), "nested")
+		service.CreateSet("main")
+		service.AddItem(*domain.NewConfigItem("nested", "nested", domain.Nested), "main")
+		service.UpdateItem(*domain.NewConfigItem("key", 2, domain.Plain), "nested")
+
+		// Spread the revisions in time: nested 1, 2, 3 and main 1, 2
+		start := time.Date(2021, 10, 1, 0, 0, 0, 0, time.UTC)
+		for i := range mockRepo.Revisions["nested"] {
+			mockRepo.Revisions["nested"][i].Date = start.Add(time.Duration(i*10) * time.Second)
+		}
+		for i := range mockRepo.Revisions["main"] {
+			mockRepo.Revisions["main"][i].Date = start.Add(time.Duration(i*10) * time.Second)
+		}
+
+		got, err := service.GetSetJsonAt("main", start.Add(15*time.Second))
+		if err != nil {
+			t.Errorf("Expected set to be rendered without errors, got: %v", err)
+		}
+
+		expected := `{"nested":{"key":1}}`
+		if string(got) != expected {
+			t.Errorf("Expected json: %s, got: %s", expected, string(got))
+		}
+
+		got, _ = service.GetSetJsonAt("main", start.Add(25*time.Second))
+		expected = `{"nested":{"key":2}}`
+		if string(got) != expected {
+			t.Errorf("Expected json: %s, got: %s", expected, string(got))
+		}
+
+		got, _ = service.GetSetJsonAt("main", start)
+		expected = `{}`
+		if string(got) != expected {
+			t.Errorf("Expected json: %s, got: %s", expected, string(got))
+		}
+
+		_, err = service.GetSetJsonAt("main", start.Add(-time.Second))
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+	})
+
+	t.Run("Test a deleted set can't be rendered after deletion", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		name := "mySet"
+		service.CreateSet(name)
+		service.DeleteSet(name, false)
+
+		_, err := service.GetSetJsonAt(name, time.Now().Add(time.Hour))
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+	})
+
+	t.Run("Test a set without history is rendered if it didn't change", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		name := "mySet"
+		service.CreateSet(name)
+		service.AddItem(*domain.NewConfigItem("key", "value", domain.Plain), name)
+		mockRepo.Revisions = map[string][]domain.ConfigRevision{}
+
+		got, err := service.GetSetJsonAt(name, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Errorf("Expected set to be rendered without errors, got: %v", err)
+		}
+
+		expected := `{"key":"value"}`
+		if string(got) != expected {
+			t.Errorf("Expected json: %s, got: %s", expected, string(got))
+		}
+
+		_, err = service.GetSetJsonAt(name, time.Now().Add(-time.Hour))
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+	})
+}
+
+func TestDiffSets(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test a set can be compared against another set", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("dev")
+		service.AddItem(*domain.NewConfigItem("password", "dev/password", domain.Secret), "dev")
+		service.AddItem(*domain.NewConfigItem("debug", true, domain.Plain), "dev")
+		service.CreateSet("prod")
+		service.AddItem(*domain.NewConfigItem("password", "prod/password", domain.Secret), "prod")
+
+		diff, err := service.DiffSets("dev", "prod")
+		if err != nil {
+			t.Errorf("Expected sets to be compared without errors, got: %v", err)
+		}
+
+		if len(diff.Added) != 1 || diff.Added[0].Key != "debug" {
+			t.Errorf("Expected debug key to be added, got: %+v", diff.Added)
+		}
+
+		if len(diff.Changed) != 1 || diff.Changed[0].To.Value != "dev/password" {
+			t.Errorf("Expected password reference to be changed, got: %+v", diff.Changed)
+		}
+	})
+
+	t.Run("Test a set can be compared against one of its revisions", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		name := "mySet"
+		service.CreateSet(name)
+		service.AddItem(*domain.NewConfigItem("key", "value", domain.Plain), name)
+
+		diff, err := service.DiffSets(name, "1")
+		if err != nil {
+			t.Errorf("Expected set to be compared without errors, got: %v", err)
+		}
+
+		if len(diff.Added) != 1 || diff.Added[0].Key != "key" {
+			t.Errorf("Expected key to be added since revision 1, got: %+v", diff)
+		}
+
+		_, err = service.DiffSets(name, "10")
+		if err != ports.ErrRevisionNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrRevisionNotExists, err)
+		}
+
+		_, err = service.DiffSets(name, "notExists")
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+	})
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+// Maximum number of due changes claimed at once by the scheduler
+const claimPageSize = 100
+
+// Time a claimed change is reserved to the replica applying it, other replicas claim it again once it expires
+const claimLease = time.Minute
+
+func (service *ConfigService) ScheduleChange(change domain.ScheduledChange) (domain.ScheduledChange, error) {
+	now := datetime.UnixUTCNow()
+	err := change.Validate(now)
+	if err != nil {
+		return domain.ScheduledChange{}, err
+	}
+
+	err = service.checkSetFrozen(change.Set)
+	if err != nil {
+		return domain.ScheduledChange{}, err
+	}
+
+	if change.Operation == domain.RemoveChange {
+		change.Item = domain.ConfigItem{Key: change.Item.Key}
+	} else {
+		// Catch invalid values now instead of when nobody is watching,
+		// the rest of the checks depend on the set state at the effective time
+		change.Item, err = change.Item.Normalize()
+		if err != nil {
+			return domain.ScheduledChange{}, err
+		}
+
+		// Sealed values must not wait in cleartext
+		change.Item, err = service.sealValue(change.Item)
+		if err != nil {
+			return domain.ScheduledChange{}, err
+		}
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return domain.ScheduledChange{}, err
+	}
+
+	change.ID = id.String()
+	change.CreateDate = now
+	// Reverts are only scheduled by OverrideItem
+	change.Revert = false
+	change.EffectiveAt = change.EffectiveAt.UTC()
+	return service.repo.AddScheduledChange(change)
+}
+
+func (service *ConfigService) GetScheduledChanges(name string) ([]domain.ScheduledChange, error) {
+	return service.repo.GetScheduledChanges(name)
+}
+
+func (service *ConfigService) GetFailedChanges(name string) ([]domain.FailedChange, error) {
+	return service.repo.GetFailedChanges(name)
+}
+
+func (service *ConfigService) CancelScheduledChange(name string, id string) (domain.ScheduledChange, error) {
+	err := service.checkSetFrozen(name)
+	if err != nil {
+		return domain.ScheduledChange{}, err
+	}
+
+	changes, err := service.repo.GetScheduledChanges(name)
+	if err != nil {
+		return domain.ScheduledChange{}, err
+	}
+
+	for _, change := range changes {
+		// Cancelling a revert would make its override permanent without a revision recording it
+		if change.ID == id && change.Revert {
+			return domain.ScheduledChange{}, domain.ErrRevertNotCancellable
+		}
+	}
+
+	return service.repo.RemoveScheduledChange(name, id)
+}
+
+func (service *ConfigService) ApplyDueChanges() ([]domain.ScheduledChange, error) {
+	applied := []domain.ScheduledChange{}
+	for {
+		now := datetime.UnixUTCNow()
+		changes, err := service.repo.ClaimDueChanges(now, now.Add(claimLease), claimPageSize)
+		if err != nil {
+			return applied, err
+		}
+
+		for _, change := range changes {
+			err := service.applyChange(change)
+			if _, frozen := err.(*domain.FrozenError); frozen {
+				// Retried once the lease expires, the freeze may be over by then
+				continue
+			}
+
+			if err != nil {
+				log.Error().Err(err).Msgf("Can't apply scheduled %s of key %q in set %q", change.Operation, change.Item.Key, change.Set)
+				err = service.repo.FailScheduledChange(domain.NewFailedChange(change, err, now))
+				if err != nil {
+					log.Error().Stack().Err(err).Msgf("Can't record failed scheduled change %q", change.ID)
+				}
+				continue
+			}
+
+			// If the acknowledgement is lost the change is applied again once its lease expires
+			err = service.repo.AckScheduledChange(change.Set, change.ID)
+			if err != nil {
+				log.Error().Stack().Err(err).Msgf("Can't acknowledge scheduled change %q", change.ID)
+			}
+
+			applied = append(applied, change)
+		}
+
+		if len(changes) < claimPageSize {
+			return applied, nil
+		}
+	}
+}
+
+// RunScheduler applies due changes every interval until the context is done
+// Several replicas can run it against the same repository, each change is leased to one of them at a time
+func (service *ConfigService) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			applied, err := service.ApplyDueChanges()
+			if err != nil {
+				log.Error().Stack().Err(err).Msg("Can't claim scheduled changes")
+			}
+
+			if len(applied) > 0 {
+				log.Info().Msgf("Applied %d scheduled changes", len(applied))
+			}
+		}
+	}
+}
+
+func (service *ConfigService) applyChange(change domain.ScheduledChange) error {
+	var err error
+	switch change.Operation {
+	case domain.AddChange:
+		_, err = service.AddItem(change.Item, change.Set)
+	case domain.UpdateChange:
+		if change.Revert {
+			_, err = service.updateItem(change.Item, change.Set, domain.RevertAction)
+		} else {
+			_, err = service.UpdateItem(change.Item, change.Set)
+		}
+	case domain.RemoveChange:
+		_, err = service.RemoveItem(change.Item, change.Set)
+	default:
+		err = domain.ErrInvalidChangeOperation
+	}
+
+	return err
+}
+
+// moveScheduledChanges schedules again the pending changes of a renamed set under its new name
+// or cancels them if newName is empty, the set write already happened so errors are only logged
+func (service *ConfigService) moveScheduledChanges(name string, newName string) {
+	changes, err := service.repo.GetScheduledChanges(name)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Can't read scheduled changes of set: %q", name)
+		return
+	}
+
+	for _, change := range changes {
+		change, err = service.repo.RemoveScheduledChange(name, change.ID)
+		if err != nil || newName == "" {
+			continue
+		}
+
+		change.Set = newName
+		_, err = service.repo.AddScheduledChange(change)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Can't move scheduled change %q to set: %q", change.ID, newName)
+		}
+	}
+}
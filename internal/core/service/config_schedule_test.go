@@ -0,0 +1,215 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestScheduledChanges(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test changes are listed until they are cancelled", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		change, err := service.ScheduleChange(domain.ScheduledChange{
+			Set:         "app",
+			Operation:   domain.AddChange,
+			Item:        *domain.NewConfigItem("banner", "maintenance", domain.Plain),
+			EffectiveAt: datetime.UnixUTCNow().Add(time.Hour),
+		})
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if change.ID == "" {
+			t.Errorf("Expected change to get an id")
+		}
+
+		changes, _ := service.GetScheduledChanges("app")
+		if len(changes) != 1 || changes[0].ID != change.ID {
+			t.Errorf("Expected the scheduled change to be listed, got: %+v", changes)
+		}
+
+		applied, _ := service.ApplyDueChanges()
+		if len(applied) != 0 {
+			t.Errorf("Expected changes to wait for their effective time, got: %+v", applied)
+		}
+
+		_, err = service.CancelScheduledChange("app", change.ID)
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+
+		_, err = service.CancelScheduledChange("app", change.ID)
+		if err != ports.ErrChangeNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrChangeNotExists, err)
+		}
+	})
+
+	t.Run("Test due changes are applied in order", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+
+		now := datetime.UnixUTCNow()
+		for i, change := range []domain.ScheduledChange{
+			{ID: "add", Operation: domain.AddChange, Item: *domain.NewConfigItem("banner", "on", domain.Plain)},
+			{ID: "update", Operation: domain.UpdateChange, Item: *domain.NewConfigItem("banner", "off", domain.Plain)},
+			{ID: "invalid", Operation: domain.UpdateChange, Item: *domain.NewConfigItem("missing", "value", domain.Plain)},
+			{ID: "remove", Operation: domain.RemoveChange, Item: domain.ConfigItem{Key: "timeout"}},
+		} {
+			// Due changes can't be scheduled, so they are saved straight into the repository
+			change.Set = "app"
+			change.EffectiveAt = now.Add(time.Duration(i-10) * time.Minute)
+			mockRepo.AddScheduledChange(change)
+		}
+
+		applied, err := service.ApplyDueChanges()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if len(applied) != 3 {
+			t.Errorf("Expected 3 applied changes, got: %+v", applied)
+		}
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		expected := `{"banner":"off"}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+
+		changes, _ := service.GetScheduledChanges("app")
+		if len(changes) != 0 {
+			t.Errorf("Expected no pending changes, got: %+v", changes)
+		}
+
+		failed, _ := service.GetFailedChanges("app")
+		if len(failed) != 1 || failed[0].ID != "invalid" || failed[0].Error != domain.ErrKeyNotExists.Error() {
+			t.Errorf("Expected the failed change to be kept, got: %+v", failed)
+		}
+	})
+
+	t.Run("Test changes are acknowledged only once applied", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.CreateSet("frozen")
+		service.FreezeSet("frozen", "", nil)
+		now := datetime.UnixUTCNow()
+		for _, change := range []domain.ScheduledChange{
+			{ID: "applied", Set: "app", Operation: domain.AddChange, Item: *domain.NewConfigItem("banner", "on", domain.Plain)},
+			{ID: "waiting", Set: "frozen", Operation: domain.AddChange, Item: *domain.NewConfigItem("banner", "on", domain.Plain)},
+		} {
+			change.EffectiveAt = now.Add(-time.Minute)
+			mockRepo.AddScheduledChange(change)
+		}
+
+		mockRepo.AckScheduledChangeInterceptor = func(name string, id string) error {
+			return errors.New("connection lost")
+		}
+
+		applied, _ := service.ApplyDueChanges()
+		if len(applied) != 1 || applied[0].ID != "applied" {
+			t.Errorf("Expected the change to be applied, got: %+v", applied)
+		}
+
+		// Both are leased, the unacknowledged one is claimed again once its lease expires
+		for _, id := range []string{"applied", "waiting"} {
+			if _, leased := mockRepo.Leases[id]; !leased {
+				t.Errorf("Expected %q to stay leased", id)
+			}
+		}
+
+		failed, _ := service.GetFailedChanges("frozen")
+		if len(failed) != 0 {
+			t.Errorf("Expected changes to frozen sets to be retried, got: %+v", failed)
+		}
+
+		mockRepo.AckScheduledChangeInterceptor = nil
+		service.UnfreezeSet("frozen")
+		claimed, _ := mockRepo.ClaimDueChanges(now.Add(claimLease+time.Second), now.Add(2*claimLease), claimPageSize)
+		if len(claimed) != 2 {
+			t.Errorf("Expected expired leases to be claimed again, got: %+v", claimed)
+		}
+	})
+
+	t.Run("Test invalid changes are rejected", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		future := datetime.UnixUTCNow().Add(time.Hour)
+		cases := []struct {
+			change   domain.ScheduledChange
+			expected error
+		}{
+			{domain.ScheduledChange{Set: "app", Operation: "rename", Item: domain.ConfigItem{Key: "a"}, EffectiveAt: future}, domain.ErrInvalidChangeOperation},
+			{domain.ScheduledChange{Set: "app", Operation: domain.AddChange, EffectiveAt: future}, domain.ErrMissingChangeKey},
+			{domain.ScheduledChange{Set: "app", Operation: domain.AddChange, Item: domain.ConfigItem{Key: "a"}, EffectiveAt: datetime.UnixUTCNow().Add(-time.Second)}, domain.ErrEffectiveAtInPast},
+			{domain.ScheduledChange{Set: "missing", Operation: domain.AddChange, Item: domain.ConfigItem{Key: "a"}, EffectiveAt: future}, ports.ErrConfigNotExists},
+		}
+
+		for _, c := range cases {
+			_, err := service.ScheduleChange(c.change)
+			if err != c.expected {
+				t.Errorf("Expected error: %v, got: %v", c.expected, err)
+			}
+		}
+
+		_, err := service.ScheduleChange(domain.ScheduledChange{
+			Set:         "app",
+			Operation:   domain.AddChange,
+			Item:        domain.ConfigItem{Key: "port", Value: "http", Type: domain.Plain, ValueType: domain.IntValue},
+			EffectiveAt: future,
+		})
+		if _, ok := err.(*domain.ValueTypeError); !ok {
+			t.Errorf("Expected a value type error, got: %v", err)
+		}
+	})
+
+	t.Run("Test pending changes follow renames and deletes of their set", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.ScheduleChange(domain.ScheduledChange{
+			Set:         "app",
+			Operation:   domain.AddChange,
+			Item:        *domain.NewConfigItem("banner", "on", domain.Plain),
+			EffectiveAt: datetime.UnixUTCNow().Add(time.Hour),
+		})
+
+		service.RenameSet("app", "api", false)
+		changes, _ := service.GetScheduledChanges("api")
+		if len(changes) != 1 || changes[0].Set != "api" {
+			t.Errorf("Expected change to move to the renamed set, got: %+v", changes)
+		}
+
+		service.DeleteSet("api", false)
+		changes, _ = service.GetScheduledChanges("api")
+		if len(changes) != 0 {
+			t.Errorf("Expected changes of deleted sets to be cancelled, got: %+v", changes)
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Number of sets loaded at once while walking every stored set
+const listPageSize = 100
+
+func (service *ConfigService) SetSchema(name string, schema []byte) (domain.ConfigSet, error) {
+	set, err := service.GetSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	err = service.checkFrozen(set)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	if len(schema) > 0 {
+		_, err = gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schema))
+		if err != nil {
+			return domain.ConfigSet{}, domain.ErrInvalidSchema
+		}
+	}
+
+	set.Schema = schema
+	set.UpdateDate = datetime.UnixUTCNow()
+	set, err = service.repo.ReplaceSet(set)
+	if err != nil {
+		return set, err
+	}
+
+	service.updateCache(set)
+	service.addRevision(set, domain.SetSchemaAction, "", false)
+	return set, nil
+}
+
+func (service *ConfigService) ValidateSets() ([]domain.SetValidation, error) {
+	results := []domain.SetValidation{}
+	for skip := 0; ; skip += listPageSize {
+		sets, err := service.repo.ListSets(listPageSize, skip)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, set := range sets {
+			result := domain.SetValidation{Name: set.Name, Valid: true}
+			schemaSet, err := service.schemaSet(set)
+			if err == nil && schemaSet.Name == "" {
+				continue
+			}
+
+			if err == nil {
+				result.SchemaSet = schemaSet.Name
+				err = service.validateSet(set)
+			}
+
+			switch err := err.(type) {
+			case nil:
+			case *domain.ValidationError:
+				result.Valid = false
+				result.Violations = err.Violations
+			default:
+				// The set can't be rendered, report it instead of failing the whole validation
+				result.Valid = false
+				result.Violations = []string{err.Error()}
+			}
+
+			results = append(results, result)
+		}
+
+		if len(sets) < listPageSize {
+			return results, nil
+		}
+	}
+}
+
+// validateSet renders the set and checks it against the closest schema in its overlay chain
+// Secrets are not resolved, they are validated as their placeholder. The values of the item variants
+// are checked one at a time in place of the item value.
+// returns a *domain.ValidationError listing every violation if the rendered set is invalid
+func (service *ConfigService) validateSet(set domain.ConfigSet) error {
+	schemaSet, err := service.schemaSet(set)
+	if err != nil || schemaSet.Name == "" {
+		return err
+	}
+
+	violations, err := service.schemaViolations(schemaSet, set)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range sortedKeys(set.Items) {
+		for i, variant := range set.Items[key].Variants {
+			candidate := set.Copy()
+			item := candidate.Items[key]
+			item.Value = variant.Value
+			item.Variants = nil
+			candidate.Items[key] = item
+
+			found, err := service.schemaViolations(schemaSet, candidate)
+			if err != nil {
+				return err
+			}
+
+			for _, violation := range found {
+				violations = append(violations, fmt.Sprintf("variant %d of %s: %s", i+1, key, violation))
+			}
+		}
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+
+	return &domain.ValidationError{Violations: violations}
+}
+
+// schemaViolations renders the set with secret placeholders and lists where it breaks the schema of schemaSet
+func (service *ConfigService) schemaViolations(schemaSet domain.ConfigSet, set domain.ConfigSet) ([]string, error) {
+	values, err := service.setToMap(set, &renderContext{secretPlaceholders: true})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := gojsonschema.Validate(
+		gojsonschema.NewBytesLoader(schemaSet.Schema),
+		gojsonschema.NewGoLoader(values),
+	)
+	if err != nil {
+		return nil, domain.ErrInvalidSchema
+	}
+
+	violations := make([]string, 0, len(result.Errors()))
+	for _, desc := range result.Errors() {
+		violations = append(violations, fmt.Sprintf("%s: %s", desc.Field(), desc.Description()))
+	}
+
+	return violations, nil
+}
+
+// schemaSet returns the first set with a schema starting at the given set and walking down its bases
+// returns an empty set if no layer has a schema
+func (service *ConfigService) schemaSet(set domain.ConfigSet) (domain.ConfigSet, error) {
+	layers, err := service.overlayLayers(set, &renderContext{})
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		if len(layers[i].Schema) > 0 {
+			return layers[i], nil
+		}
+	}
+
+	return domain.ConfigSet{}, nil
+}
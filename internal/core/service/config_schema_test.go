@@ -0,0 +1,295 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+const portSchema = `{
+	"type": "object",
+	"properties": {
+		"port": { "type": "integer", "minimum": 1 },
+		"host": { "type": "string" },
+		"password": { "type": "string" }
+	},
+	"required": ["port"]
+}`
+
+func TestSetSchema(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test a schema can be attached and removed", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("server")
+		set, err := service.SetSchema("server", []byte(portSchema))
+		if err != nil {
+			t.Errorf("Expected schema to be set without errors, got: %v", err)
+		}
+
+		if string(set.Schema) != portSchema {
+			t.Errorf("Expected schema: %s, got: %s", portSchema, string(set.Schema))
+		}
+
+		revisions, _ := service.GetRevisions("server", 1, 0)
+		if len(revisions) != 1 || revisions[0].Action != domain.SetSchemaAction {
+			t.Errorf("Expected a %q revision, got: %+v", domain.SetSchemaAction, revisions)
+		}
+
+		set, err = service.SetSchema("server", nil)
+		if err != nil {
+			t.Errorf("Expected schema to be removed without errors, got: %v", err)
+		}
+
+		if len(set.Schema) != 0 {
+			t.Errorf("Expected empty schema, got: %s", string(set.Schema))
+		}
+	})
+
+	t.Run("Test an invalid schema is rejected", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("server")
+		_, err := service.SetSchema("server", []byte(`{ "type": 10 }`))
+		if err != domain.ErrInvalidSchema {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrInvalidSchema, err)
+		}
+	})
+}
+
+func TestSchemaValidation(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test invalid writes are rejected listing every violation", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("server")
+		service.SetSchema("server", []byte(`{
+			"type": "object",
+			"properties": {
+				"port": { "type": "integer" },
+				"host": { "type": "string" }
+			}
+		}`))
+		service.AddItem(*domain.NewConfigItem("port", 8080, domain.Plain), "server")
+
+		_, err := service.AddItem(*domain.NewConfigItem("host", 10, domain.Plain), "server")
+		validationErr, ok := err.(*domain.ValidationError)
+		if !ok {
+			t.Fatalf("Expected a validation error, got: %v", err)
+		}
+
+		expected := []string{"host: Invalid type. Expected: string, given: integer"}
+		if !cmp.Equal(validationErr.Violations, expected) {
+			t.Errorf("Expected violations: %+v, got: %+v", expected, validationErr.Violations)
+		}
+
+		_, err = service.UpdateItem(*domain.NewConfigItem("port", "8080", domain.Plain), "server")
+		if _, ok := err.(*domain.ValidationError); !ok {
+			t.Errorf("Expected a validation error, got: %v", err)
+		}
+
+		service.SetSchema("server", []byte(portSchema))
+		_, err = service.RemoveItem(domain.ConfigItem{Key: "port"}, "server")
+		if _, ok := err.(*domain.ValidationError); !ok {
+			t.Errorf("Expected a validation error removing a required item, got: %v", err)
+		}
+
+		set, _ := service.GetSet("server")
+		if _, err := set.Get("host"); err != domain.ErrKeyNotExists {
+			t.Errorf("Expected invalid item to not be saved, got: %v", err)
+		}
+
+		if set.Items["port"].Value != 8080 {
+			t.Errorf("Expected port to keep value: 8080, got: %v", set.Items["port"].Value)
+		}
+	})
+
+	t.Run("Test secrets are validated by their placeholder", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("server")
+		service.AddItem(*domain.NewConfigItem("port", 8080, domain.Plain), "server")
+		service.SetSchema("server", []byte(portSchema))
+
+		_, err := service.AddItem(*domain.NewConfigItem("password", "db/password", domain.Secret), "server")
+		if err != nil {
+			t.Errorf("Expected secret to be added without errors, got: %v", err)
+		}
+	})
+
+	t.Run("Test sealed items are validated by a placeholder of their type", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.SealKeys = map[string]string{"k1": testSealKey}
+		config.ActiveSealKey = "k1"
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("server")
+		service.AddItem(*domain.NewConfigItem("port", 8080, domain.Plain), "server")
+		service.SetSchema("server", []byte(`{
+			"type": "object",
+			"properties": {
+				"port": { "type": "integer" },
+				"pin": { "type": "integer" }
+			}
+		}`))
+
+		_, err := service.AddItem(*domain.NewConfigItem("pin", "1234", domain.Sealed), "server")
+		if _, ok := err.(*domain.ValidationError); !ok {
+			t.Errorf("Expected an untyped sealed item to be validated as a string, got: %v", err)
+		}
+
+		pin := domain.NewConfigItem("pin", 1234, domain.Sealed)
+		pin.ValueType = domain.IntValue
+		_, err = service.AddItem(*pin, "server")
+		if err != nil {
+			t.Fatalf("Expected a sealed int to be added without errors, got: %v", err)
+		}
+
+		got, _ := service.GetSetJson("server", domain.AnyAge)
+		expected := `{"pin":1234,"port":8080}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+	})
+
+	t.Run("Test every variant value is validated", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("server")
+		service.SetSchema("server", []byte(portSchema))
+
+		port := domain.NewConfigItem("port", 8080, domain.Plain)
+		port.Variants = []domain.Variant{
+			{When: domain.VariantCondition{Regions: []string{"eu"}}, Value: 8081},
+			{When: domain.VariantCondition{Regions: []string{"us"}}, Value: "8082"},
+		}
+		_, err := service.AddItem(*port, "server")
+		validationErr, ok := err.(*domain.ValidationError)
+		if !ok {
+			t.Fatalf("Expected a validation error, got: %v", err)
+		}
+
+		expected := []string{"variant 2 of port: port: Invalid type. Expected: integer, given: string"}
+		if !cmp.Equal(validationErr.Violations, expected) {
+			t.Errorf("Expected violations: %+v, got: %+v", expected, validationErr.Violations)
+		}
+
+		port.Variants = port.Variants[:1]
+		_, err = service.AddItem(*port, "server")
+		if err != nil {
+			t.Errorf("Expected valid variants to be added without errors, got: %v", err)
+		}
+	})
+
+	t.Run("Test overlays are validated with the schema of their base", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("server")
+		service.AddItem(*domain.NewConfigItem("port", 8080, domain.Plain), "server")
+		service.SetSchema("server", []byte(portSchema))
+		service.CreateSet("server-prod")
+		service.SetBase("server-prod", "server")
+
+		_, err := service.AddItem(*domain.NewConfigItem("port", 0, domain.Plain), "server-prod")
+		validationErr, ok := err.(*domain.ValidationError)
+		if !ok {
+			t.Fatalf("Expected a validation error, got: %v", err)
+		}
+
+		expected := []string{"port: Must be greater than or equal to 1"}
+		if !cmp.Equal(validationErr.Violations, expected) {
+			t.Errorf("Expected violations: %+v, got: %+v", expected, validationErr.Violations)
+		}
+	})
+}
+
+func TestValidateSets(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test every set with a schema is validated", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("no-schema")
+		service.CreateSet("server")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "server")
+		service.CreateSet("valid")
+		service.AddItem(*domain.NewConfigItem("port", 80, domain.Plain), "valid")
+
+		service.SetSchema("server", []byte(portSchema))
+		service.SetSchema("valid", []byte(portSchema))
+
+		got, err := service.ValidateSets()
+		if err != nil {
+			t.Errorf("Expected sets to be validated without errors, got: %v", err)
+		}
+
+		expected := []domain.SetValidation{
+			{
+				Name:       "server",
+				SchemaSet:  "server",
+				Valid:      false,
+				Violations: []string{"(root): port is required"},
+			},
+			{
+				Name:      "valid",
+				SchemaSet: "valid",
+				Valid:     true,
+			},
+		}
+
+		if !cmp.Equal(got, expected) {
+			t.Errorf("Expected results: %+v, got: %+v", expected, got)
+		}
+	})
+
+	t.Run("Test sets that can't be rendered are reported as invalid", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("leaf")
+		service.CreateSet("middle")
+		service.AddItem(*domain.NewConfigItem("leaf", "leaf", domain.Nested), "middle")
+		service.CreateSet("server")
+		service.AddItem(*domain.NewConfigItem("port", 80, domain.Plain), "server")
+		service.AddItem(*domain.NewConfigItem("middle", "middle", domain.Nested), "server")
+		service.SetSchema("server", []byte(portSchema))
+
+		config.MaxNestingDepth = 1
+		got, err := service.ValidateSets()
+		if err != nil {
+			t.Fatalf("Expected render errors to be reported per set, got: %v", err)
+		}
+
+		if len(got) != 1 || got[0].Name != "server" || got[0].Valid || len(got[0].Violations) != 1 {
+			t.Errorf("Expected the set to be reported as invalid, got: %+v", got)
+		}
+	})
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+func (service *ConfigService) ResealSets() ([]string, error) {
+	if service.sealer == nil {
+		return nil, domain.ErrSealingDisabled
+	}
+
+	err := service.checkFreezeWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	resealed := []string{}
+	for skip := 0; ; skip += listPageSize {
+		sets, err := service.repo.ListSets(listPageSize, skip)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, set := range sets {
+			changed, err := service.resealSet(set)
+			if err != nil {
+				return resealed, err
+			}
+
+			if changed {
+				resealed = append(resealed, set.Name)
+			}
+		}
+
+		if len(sets) < listPageSize {
+			return resealed, nil
+		}
+	}
+}
+
+// resealSet seals again with the active key every sealed item of the set and its canary sealed with an older key
+func (service *ConfigService) resealSet(set domain.ConfigSet) (bool, error) {
+	canaryChanged, err := service.resealCanary(set.Name)
+	if err != nil {
+		return false, err
+	}
+
+	items, changed, err := service.resealItems(set.Items)
+	if err != nil {
+		return false, err
+	}
+
+	if !changed {
+		return canaryChanged, nil
+	}
+
+	set.Items = items
+	set.UpdateDate = datetime.UnixUTCNow()
+	set, err = service.repo.ReplaceSet(set)
+	if err != nil {
+		return false, err
+	}
+
+	service.updateCache(set)
+	service.addRevision(set, domain.ResealAction, service.sealer.ActiveKey(), false)
+	return true, nil
+}
+
+// resealCanary seals again the staged items of the canary of the given set if there is one
+func (service *ConfigService) resealCanary(name string) (bool, error) {
+	canary, err := service.repo.GetCanary(name)
+	if err == ports.ErrCanaryNotExists {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	items, changed, err := service.resealItems(canary.Items)
+	if err != nil || !changed {
+		return false, err
+	}
+
+	canary.Items = items
+	_, err = service.repo.SaveCanary(canary)
+	return err == nil, err
+}
+
+// resealItems returns a copy of the items with the sealed values sealed with an older key sealed again
+func (service *ConfigService) resealItems(items domain.ConfigItemMap) (domain.ConfigItemMap, bool, error) {
+	items = domain.ConfigSet{Items: items}.Copy().Items
+	changed := false
+	for key, item := range items {
+		value, ok := item.Value.(string)
+		if item.Type != domain.Sealed || !ok {
+			continue
+		}
+
+		if id, _ := domain.SealedKeyID(value); id == service.sealer.ActiveKey() {
+			continue
+		}
+
+		opened, err := service.sealer.Open(value)
+		if err != nil {
+			return nil, false, err
+		}
+
+		item.Value, err = service.sealer.Seal(opened)
+		if err != nil {
+			return nil, false, err
+		}
+
+		items[key] = item
+		changed = true
+	}
+
+	return items, changed, nil
+}
+
+// sealValue encrypts the value of a sealed item being written.
+// Values that are already sealed with a configured key, e.g. copied from a raw set, are kept as they are
+func (service *ConfigService) sealValue(item domain.ConfigItem) (domain.ConfigItem, error) {
+	if item.Type != domain.Sealed {
+		return item, nil
+	}
+
+	if service.sealer == nil {
+		return item, domain.ErrSealingDisabled
+	}
+
+	value, ok := item.Value.(string)
+	if ok {
+		if _, err := service.sealer.Open(value); err == nil {
+			return item, nil
+		}
+	}
+
+	if item.ValueType != "" {
+		// Typed values are sealed as JSON so they are rendered with their type
+		typed, err := item.TypedValue()
+		if err != nil {
+			return item, err
+		}
+
+		data, err := json.Marshal(typed)
+		if err != nil {
+			return item, err
+		}
+
+		value, ok = string(data), true
+	}
+
+	if !ok {
+		return item, domain.ErrSealedKeyValue
+	}
+
+	sealed, err := service.sealer.Seal(value)
+	if err != nil {
+		return item, err
+	}
+
+	item.Value = sealed
+	return item, nil
+}
+
+// openValue decrypts the value of a sealed item for rendering, typed values are decoded to their type
+func (service *ConfigService) openValue(item domain.ConfigItem) (interface{}, error) {
+	value, ok := item.Value.(string)
+	if !ok {
+		return nil, domain.ErrSealedKeyValue
+	}
+
+	if service.sealer == nil {
+		return nil, domain.ErrSealingDisabled
+	}
+
+	opened, err := service.sealer.Open(value)
+	if err != nil || item.ValueType == "" {
+		return opened, err
+	}
+
+	var decoded interface{}
+	err = domain.DecodeJSON([]byte(opened), &decoded)
+	if err != nil {
+		return nil, domain.ErrInvalidSealedValue
+	}
+
+	item.Value = decoded
+	return item.TypedValue()
+}
+
+// sealedPlaceholder stands for the value of a sealed item in renders that don't resolve secrets
+// Typed items get a value of their type so they can be checked against a schema
+func sealedPlaceholder(item domain.ConfigItem) interface{} {
+	switch item.ValueType {
+	case "", domain.StringValue, domain.JSONValue:
+		// The stored value is encrypted so it never reveals the cleartext
+		return item.Value
+	default:
+		return item.ValueType.Placeholder()
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+const (
+	testSealKey  = "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="
+	otherSealKey = "ZmVkY2JhOTg3NjU0MzIxMGZlZGNiYTk4NzY1NDMyMTA="
+)
+
+func TestSealedItems(t *testing.T) {
+	t.Run("Test sealed values are stored encrypted and rendered in clear", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.SealKeys = map[string]string{"k1": testSealKey}
+		config.ActiveSealKey = "k1"
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		_, err := service.AddItem(*domain.NewConfigItem("password", "s3cr3t", domain.Sealed), "app")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		stored, _ := mockRepo.GetSet("app")
+		value, _ := stored.Items["password"].Value.(string)
+		if !strings.HasPrefix(value, "sealed:k1:") || strings.Contains(value, "s3cr3t") {
+			t.Errorf("Expected value to be stored sealed, got: %s", value)
+		}
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		expected := `{"password":"s3cr3t"}`
+		if string(got) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, got)
+		}
+
+		set, _ := service.UpdateItem(*domain.NewConfigItem("password", value, domain.Sealed), "app")
+		if set.Items["password"].Value != value {
+			t.Errorf("Expected an already sealed value to be kept, got: %v", set.Items["password"].Value)
+		}
+	})
+
+	t.Run("Test invalid seal keys fail the service creation", func(t *testing.T) {
+		cases := []struct {
+			keys     map[string]string
+			active   string
+			expected error
+		}{
+			{map[string]string{"k1": "not-base64"}, "k1", domain.ErrInvalidSealKey},
+			{map[string]string{"k1": testSealKey}, "k2", domain.ErrUnknownActiveSealKey},
+		}
+
+		for _, c := range cases {
+			config := domain.DefaultConfig()
+			config.SealKeys = c.keys
+			config.ActiveSealKey = c.active
+			_, err := NewConfigService(&config, mocks.NewMockRepo(), mocks.NewMockRepo(), &mocks.MockSecrets{})
+			if err != c.expected {
+				t.Errorf("Expected error: %v, got: %v", c.expected, err)
+			}
+		}
+	})
+
+	t.Run("Test sealed items need seal keys", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		_, err := service.AddItem(*domain.NewConfigItem("password", "s3cr3t", domain.Sealed), "app")
+		if err != domain.ErrSealingDisabled {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrSealingDisabled, err)
+		}
+
+		_, err = service.ResealSets()
+		if err != domain.ErrSealingDisabled {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrSealingDisabled, err)
+		}
+	})
+
+	t.Run("Test reseal after a key rotation", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.SealKeys = map[string]string{"k1": testSealKey}
+		config.ActiveSealKey = "k1"
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.CreateSet("other")
+		service.AddItem(*domain.NewConfigItem("password", "s3cr3t", domain.Sealed), "app")
+
+		rotated := domain.DefaultConfig()
+		rotated.SealKeys = map[string]string{"k1": testSealKey, "k2": otherSealKey}
+		rotated.ActiveSealKey = "k2"
+		service, _ = NewConfigService(&rotated, mockRepo, cacheRepo, &mockSecret)
+
+		got, err := service.ResealSets()
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		if !cmp.Equal(got, []string{"app"}) {
+			t.Errorf("Expected resealed sets: [app], got: %v", got)
+		}
+
+		set, _ := service.GetSet("app")
+		if id, _ := domain.SealedKeyID(set.Items["password"].Value.(string)); id != "k2" {
+			t.Errorf("Expected value sealed with k2, got: %v", set.Items["password"].Value)
+		}
+
+		json, _ := service.GetSetJson("app", domain.AnyAge)
+		expected := `{"password":"s3cr3t"}`
+		if string(json) != expected {
+			t.Errorf("Expected JSON: %s, got: %s", expected, json)
+		}
+	})
+}
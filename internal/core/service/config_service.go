@@ -2,7 +2,9 @@ package service
 
 import (
 	"encoding/json"
+	"time"
 
+	"github.com/rs/zerolog/log"
 	"github.com/sy-software/minerva-go-utils/datetime"
 	"github.com/sy-software/minerva-olive/internal/core/domain"
 	"github.com/sy-software/minerva-olive/internal/core/ports"
@@ -14,18 +16,37 @@ type ConfigService struct {
 	cache         ports.CacheRepo
 	secretManager ports.Secret
 	config        *domain.Config
+	sealer        *domain.Sealer
+	// Allows changes during freeze windows, see WithFreezeOverride
+	freezeOverride bool
 }
 
-func NewConfigService(config *domain.Config, repo ports.Repo, cache ports.CacheRepo, secretManager ports.Secret) *ConfigService {
-	return &ConfigService{
+// NewConfigService returns an error if the seal keys of the config can't be loaded
+func NewConfigService(config *domain.Config, repo ports.Repo, cache ports.CacheRepo, secretManager ports.Secret) (*ConfigService, error) {
+	service := ConfigService{
 		repo:          repo,
 		cache:         cache,
 		secretManager: secretManager,
 		config:        config,
 	}
+
+	if len(config.SealKeys) > 0 {
+		sealer, err := domain.NewSealer(config.SealKeys, config.ActiveSealKey)
+		if err != nil {
+			return nil, err
+		}
+		service.sealer = sealer
+	}
+
+	return &service, nil
 }
 
 func (service *ConfigService) CreateSet(name string) (domain.ConfigSet, error) {
+	err := service.checkFreezeWindow()
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
 	now := datetime.UnixUTCNow()
 	newSet := domain.ConfigSet{
 		Name:       name,
@@ -34,7 +55,13 @@ func (service *ConfigService) CreateSet(name string) (domain.ConfigSet, error) {
 		UpdateDate: now,
 	}
 	service.updateCache(newSet)
-	return service.repo.CreateSet(newSet)
+	set, err := service.repo.CreateSet(newSet)
+	if err != nil {
+		return set, err
+	}
+
+	service.addRevision(set, domain.CreateAction, "", false)
+	return set, nil
 }
 
 func (service *ConfigService) GetSet(name string) (domain.ConfigSet, error) {
@@ -47,135 +74,445 @@ func (service *ConfigService) GetSet(name string) (domain.ConfigSet, error) {
 }
 
 func (service *ConfigService) GetSetJson(name string, maxAge int) ([]byte, error) {
-	jsonBytes, err := service.cache.GetJSON(name, maxAge)
+	rendered, err := service.renderCached(name, maxAge)
+	return rendered.Values, err
+}
+
+func (service *ConfigService) GetSetJsonAt(name string, at time.Time) ([]byte, error) {
+	rendered, err := service.RenderSetAt(name, at)
+	return rendered.Values, err
+}
+
+func (service *ConfigService) RenderSetAt(name string, at time.Time) (domain.RenderedConfig, error) {
+	set, err := service.getSetAt(name, at)
+	if err != nil {
+		return domain.RenderedConfig{Values: []byte{}}, err
+	}
+
+	return service.renderJSON(set, &renderContext{at: &at})
+}
+
+// renderCached returns the cached render of the set, the set is rendered if the cache is missing or too old
+func (service *ConfigService) renderCached(name string, maxAge int) (domain.RenderedConfig, error) {
+	cached, err := service.cache.GetJSON(name, maxAge)
 	if err == nil {
-		return jsonBytes, nil
+		var rendered domain.RenderedConfig
+		// Entries saved with another layout, like the plain values cached before deprecations, are rendered again
+		if json.Unmarshal(cached, &rendered) == nil && rendered.Format == domain.RenderedFormat {
+			return rendered, nil
+		}
 	}
 
 	set, err := service.GetSet(name)
 	if err != nil {
-		return []byte{}, err
+		return domain.RenderedConfig{Values: []byte{}}, err
 	}
 
-	return service.SetToJson(set)
+	return service.renderJSON(set, &renderContext{})
 }
 
-func (service *ConfigService) GetSetNames(count int, skip int) ([]string, error) {
+func (service *ConfigService) GetSetNames(count int, skip int, labels map[string]string) ([]string, error) {
+	if len(labels) > 0 {
+		return service.repo.GetSetNamesByLabels(labels, count, skip)
+	}
+
 	return service.repo.GetSetNames(count, skip)
 }
 
-func (service *ConfigService) RenameSet(name string, newName string) (domain.ConfigSet, error) {
+func (service *ConfigService) RenameSet(name string, newName string, rewrite bool) (domain.ConfigSet, error) {
 	set, err := service.repo.GetSet(name)
 	if err != nil {
 		return domain.ConfigSet{}, err
 	}
 
+	err = service.checkFrozen(*set)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
 	_, err = service.repo.GetSet(newName)
 	if err != ports.ErrConfigNotExists {
 		return domain.ConfigSet{}, ports.ErrDuplicatedConfig
 	}
 
-	_, err = service.DeleteSet(name)
+	referrers, err := service.checkReferrers(name)
+	if _, referenced := err.(*domain.ReferencedError); err != nil && !(referenced && rewrite) {
+		return domain.ConfigSet{}, err
+	}
+
+	// Every write is prepared first, so a failure can put back what was already written
+	originals := make([]domain.ConfigSet, 0, len(referrers))
+	rewritten := make([]domain.ConfigSet, 0, len(referrers))
+	for _, referrer := range referrers {
+		current, err := service.GetSet(referrer.Name)
+		if err != nil {
+			return domain.ConfigSet{}, err
+		}
+
+		err = service.checkFrozen(current)
+		if err != nil {
+			return domain.ConfigSet{}, err
+		}
+
+		originals = append(originals, current)
+		rewritten = append(rewritten, retargetSet(current, name, newName))
+	}
+
+	// Refs into the set itself follow the rename too
+	renamed := retargetSet(*set, name, newName)
+	renamed.Name = newName
+	renamed, err = service.repo.CreateSet(renamed)
 	if err != nil {
 		return domain.ConfigSet{}, err
 	}
 
+	for i, referrer := range rewritten {
+		_, err = service.repo.ReplaceSet(referrer)
+		if err != nil {
+			service.undoRename(newName, originals[:i])
+			return domain.ConfigSet{}, err
+		}
+	}
+
+	oldSet, err := service.repo.DeleteSet(name)
+	if err != nil {
+		service.undoRename(newName, originals)
+		return domain.ConfigSet{}, err
+	}
+
+	outgoing := references(oldSet)
 	service.cache.RemoveJSON(name)
+	service.syncReferrers(name, outgoing, nil)
+	service.addRevision(oldSet, domain.RenameAction, newName, true)
+
+	service.updateCache(renamed)
+	service.syncReferrers(newName, nil, references(renamed))
+	service.addRevision(renamed, domain.RenameAction, name, false)
+	service.moveCanary(name, newName)
+	service.moveScheduledChanges(name, newName)
+
+	for i, referrer := range rewritten {
+		service.updateCache(referrer)
+		service.syncReferrers(referrer.Name, references(originals[i]), references(referrer))
+		service.addRevision(referrer, domain.RewriteAction, name+" -> "+newName, false)
+	}
 
-	set.Name = newName
-	set.UpdateDate = datetime.UnixUTCNow()
+	return renamed, nil
+}
 
-	service.updateCache(*set)
+// undoRename removes the renamed set and puts back the referrers rewritten before a rename failed
+// The rename error is returned to the caller so these errors are only logged
+func (service *ConfigService) undoRename(newName string, originals []domain.ConfigSet) {
+	for _, original := range originals {
+		_, err := service.repo.ReplaceSet(original)
+		if err != nil {
+			log.Error().Stack().Err(err).Msgf("Can't restore set %q after a failed rename", original.Name)
+		}
+	}
 
-	return service.repo.CreateSet(*set)
+	_, err := service.repo.DeleteSet(newName)
+	if err != nil {
+		log.Error().Stack().Err(err).Msgf("Can't remove set %q after a failed rename", newName)
+	}
 }
 
-func (service *ConfigService) DeleteSet(name string) (domain.ConfigSet, error) {
-	return service.repo.DeleteSet(name)
+func (service *ConfigService) DeleteSet(name string, force bool) (domain.ConfigSet, error) {
+	current, err := service.repo.GetSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	err = service.checkFrozen(*current)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	if !force {
+		_, err = service.checkReferrers(name)
+		if err != nil {
+			return domain.ConfigSet{}, err
+		}
+	}
+
+	trashed, err := service.repo.TrashSet(name, service.purgeDate())
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	set := trashed.Set
+	service.cache.RemoveJSON(name)
+	service.invalidateReferrers(name)
+	service.syncReferrers(name, references(set), nil)
+	service.addRevision(set, domain.DeleteAction, "", true)
+	service.repo.DeleteCanary(name)
+	service.moveScheduledChanges(name, "")
+	return set, nil
 }
 
 func (service *ConfigService) AddItem(item domain.ConfigItem, setName string) (domain.ConfigSet, error) {
+	current, err := service.GetSet(setName)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.checkFrozen(current)
+	if err != nil {
+		return current, err
+	}
+
+	item, err = item.Normalize()
+	if err != nil {
+		return current, err
+	}
+
+	item, err = generateValue(item, current)
+	if err != nil {
+		return current, err
+	}
+
+	item, err = service.sealValue(item)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.checkNestedCycle(item, setName)
+	if err != nil {
+		return current, err
+	}
+
+	candidate := current.Copy()
+	err = candidate.Add(item)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.checkRef(item, candidate)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.checkComputed(candidate)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.validateSet(candidate)
+	if err != nil {
+		return current, err
+	}
+
+	before := references(current)
 	set, err := service.repo.AddItem(item, setName)
 	if err == domain.ErrDuplicatedKey {
 		set, _ = service.GetSet(setName)
 		return set, err
 	}
 
+	if err != nil {
+		return set, err
+	}
+
 	service.updateCache(set)
-	return set, err
+	service.syncReferrers(setName, before, references(set))
+	service.addRevision(set, domain.AddItemAction, item.Key, false)
+	return set, nil
 }
 
 func (service *ConfigService) UpdateItem(item domain.ConfigItem, setName string) (domain.ConfigSet, error) {
+	set, err := service.updateItem(item, setName, domain.UpdateItemAction)
+	if err == nil {
+		// A later write wins over a temporary override, it must not be reverted
+		service.cancelOverride(setName, item.Key)
+	}
+
+	return set, err
+}
+
+func (service *ConfigService) updateItem(item domain.ConfigItem, setName string, action domain.RevisionAction) (domain.ConfigSet, error) {
+	current, err := service.GetSet(setName)
+	if err != nil {
+		return current, err
+	}
+
+	// Reverts restore the item an override replaced, a freeze must not make the override permanent
+	if action != domain.RevertAction {
+		err = service.checkFrozen(current)
+		if err != nil {
+			return current, err
+		}
+	}
+
+	item, err = item.Normalize()
+	if err != nil {
+		return current, err
+	}
+
+	item, err = generateValue(item, current)
+	if err != nil {
+		return current, err
+	}
+
+	item, err = service.sealValue(item)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.checkNestedCycle(item, setName)
+	if err != nil {
+		return current, err
+	}
+
+	candidate := current.Copy()
+	_, err = candidate.Update(item)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.checkRef(item, candidate)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.checkComputed(candidate)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.validateSet(candidate)
+	if err != nil {
+		return current, err
+	}
+
+	before := references(current)
 	set, err := service.repo.UpdateItem(item, setName)
 	if err == domain.ErrKeyNotExists {
 		set, _ = service.GetSet(setName)
 		return set, err
 	}
 
+	if err != nil {
+		return set, err
+	}
+
 	service.updateCache(set)
-	return set, err
+	service.syncReferrers(setName, before, references(set))
+	service.addRevision(set, action, item.Key, false)
+	return set, nil
 }
 
 func (service *ConfigService) RemoveItem(item domain.ConfigItem, setName string) (domain.ConfigSet, error) {
+	current, err := service.GetSet(setName)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.checkFrozen(current)
+	if err != nil {
+		return current, err
+	}
+
+	candidate := current.Copy()
+	delete(candidate.Items, item.Key)
+	err = service.checkComputed(candidate)
+	if err != nil {
+		return current, err
+	}
+
+	err = service.validateSet(candidate)
+	if err != nil {
+		return current, err
+	}
+
+	before := references(current)
 	set, err := service.repo.RemoveItem(item, setName)
 	if err == domain.ErrKeyNotExists {
 		set, _ = service.GetSet(setName)
 		return set, err
 	}
 
+	if err != nil {
+		return set, err
+	}
+
 	service.updateCache(set)
-	return set, err
+	service.syncReferrers(setName, before, references(set))
+	service.addRevision(set, domain.RemoveItemAction, item.Key, false)
+	service.cancelOverride(setName, item.Key)
+	return set, nil
 }
 
 func (service *ConfigService) SetToJson(set domain.ConfigSet) ([]byte, error) {
-	mappedItems, err := service.setToMap(set)
+	rendered, err := service.renderJSON(set, &renderContext{})
+	return rendered.Values, err
+}
+
+// renderJSON renders the set as JSON recording the deprecated items rendered
+func (service *ConfigService) renderJSON(set domain.ConfigSet, ctx *renderContext) (domain.RenderedConfig, error) {
+	ctx.deprecations = map[string]domain.Deprecation{}
+	mappedItems, err := service.setToMap(set, ctx)
 	if err != nil {
-		return []byte{}, err
+		return domain.RenderedConfig{Values: []byte{}}, err
 	}
 
-	return json.Marshal(mappedItems)
+	jsonBytes, err := json.Marshal(mappedItems)
+	if err != nil {
+		return domain.RenderedConfig{Values: []byte{}}, err
+	}
+
+	return domain.RenderedConfig{
+		Format:       domain.RenderedFormat,
+		Values:       jsonBytes,
+		Deprecations: sortedDeprecations(ctx.deprecations),
+	}, nil
 }
 
 // Private utils
 
-func (service *ConfigService) setToMap(set domain.ConfigSet) (map[string]interface{}, error) {
-	mappedItems := map[string]interface{}{}
-	for _, item := range set.Items {
-
-		switch item.Type {
-		case domain.Nested:
-			name, ok := item.Value.(string)
-			if !ok {
-				return mappedItems, domain.ErrInvalidNestedKeyValue
-			}
-			set, err := service.GetSet(name)
-			if err != nil {
-				return mappedItems, err
-			}
-
-			mappedItems[item.Key], err = service.setToMap(set)
-		case domain.Secret:
-			name, ok := item.Value.(string)
-			if !ok {
-				return mappedItems, domain.ErrSecretKeyValue
-			}
-			val, err := service.secretManager.Get(name)
-
-			if err != nil {
-				return mappedItems, err
-			}
-
-			mappedItems[item.Key] = val
-		default:
-			mappedItems[item.Key] = item.Value
+// checkItems runs the checks of an item write on every item of a set about to replace the stored items:
+// nested sets must exist and not form cycles, references must resolve, expressions must be valid and
+// the rendered set must satisfy its schema
+func (service *ConfigService) checkItems(candidate domain.ConfigSet) error {
+	for _, key := range sortedKeys(candidate.Items) {
+		err := service.checkNestedTarget(candidate.Items[key], candidate.Name)
+		if err != nil {
+			return err
+		}
+
+		err = service.checkNestedCycle(candidate.Items[key], candidate.Name)
+		if err != nil {
+			return err
 		}
+
+		err = service.checkRef(candidate.Items[key], candidate)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := service.checkComputed(candidate)
+	if err != nil {
+		return err
 	}
 
-	return mappedItems, nil
+	return service.validateSet(candidate)
 }
 
 func (service *ConfigService) updateCache(set domain.ConfigSet) {
+	var jsonBytes []byte
+	rendered, err := service.renderJSON(set, &renderContext{})
+	if err == nil {
+		// The deprecations are cached with the values so cached reads list them too
+		jsonBytes, err = json.Marshal(rendered)
+	}
+
+	if err != nil {
+		// Don't keep serving an old render, the error will be returned on the next read
+		service.cache.RemoveJSON(set.Name)
+		service.invalidateReferrers(set.Name)
+		return
+	}
+
 	// For now, ignore errors during cache saving
-	jsonBytes, _ := service.SetToJson(set)
 	service.cache.SaveJSON(jsonBytes, set.Name, int(service.config.CacheTTL))
+	service.invalidateReferrers(set.Name)
 }
@@ -20,7 +20,7 @@ func TestCreateSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		now := datetime.UnixUTCNow()
@@ -48,7 +48,7 @@ func TestCreateSet(t *testing.T) {
 
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -66,7 +66,7 @@ func TestReadSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -85,7 +85,7 @@ func TestReadSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -101,7 +101,7 @@ func TestReadSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		for i := 0; i < 20; i++ {
 			service.CreateSet(fmt.Sprintf("mySet%d", i))
@@ -109,7 +109,7 @@ func TestReadSet(t *testing.T) {
 
 		skip := 0
 		count := 2
-		names, err := service.GetSetNames(count, skip)
+		names, err := service.GetSetNames(count, skip, nil)
 
 		if err != nil {
 			t.Errorf("Expected set names to be read without errors, got: %v", err)
@@ -126,7 +126,7 @@ func TestReadSet(t *testing.T) {
 
 		skip = 20
 		count = 2
-		names, err = service.GetSetNames(count, skip)
+		names, err = service.GetSetNames(count, skip, nil)
 
 		if err != nil {
 			t.Errorf("Expected set names to be read without errors, got: %v", err)
@@ -141,7 +141,7 @@ func TestReadSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -199,12 +199,12 @@ func TestDeleteSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
 
-		_, err := service.DeleteSet(name)
+		_, err := service.DeleteSet(name, false)
 		if err != nil {
 			t.Errorf("Expected set to be delete without errors, got: %v", err)
 		}
@@ -220,12 +220,12 @@ func TestDeleteSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
 
-		_, err := service.DeleteSet("otherName")
+		_, err := service.DeleteSet("otherName", false)
 
 		if err == nil {
 			t.Errorf("Expected error: %v got nil", ports.ErrConfigNotExists)
@@ -239,7 +239,7 @@ func TestRenameSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -247,7 +247,7 @@ func TestRenameSet(t *testing.T) {
 		// Wait a few seconds to have a token with different expiration
 		time.Sleep(1 * time.Second)
 		newName := "newName"
-		got, err := service.RenameSet(name, newName)
+		got, err := service.RenameSet(name, newName, false)
 		if err != nil {
 			t.Errorf("Expected set to be renamed without errors, got: %v", err)
 		}
@@ -284,7 +284,7 @@ func TestRenameSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -292,7 +292,7 @@ func TestRenameSet(t *testing.T) {
 		// Wait a few seconds to have a token with different expiration
 		time.Sleep(1 * time.Second)
 		newName := "newName"
-		_, err := service.RenameSet("notExists", newName)
+		_, err := service.RenameSet("notExists", newName, false)
 
 		if err == nil {
 			t.Errorf("Expected error: %v got nil", ports.ErrConfigNotExists)
@@ -303,7 +303,7 @@ func TestRenameSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -312,7 +312,7 @@ func TestRenameSet(t *testing.T) {
 		time.Sleep(1 * time.Second)
 		newName := "newName"
 		service.CreateSet(newName)
-		_, err := service.RenameSet("notExists", newName)
+		_, err := service.RenameSet("notExists", newName, false)
 
 		if err == nil {
 			t.Errorf("Expected error: %v got nil", ports.ErrDuplicatedConfig)
@@ -328,7 +328,7 @@ func TestAddItemToSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -368,7 +368,7 @@ func TestAddItemToSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -406,6 +406,42 @@ func TestAddItemToSet(t *testing.T) {
 			t.Errorf("Expected item value: %+v, got: %+v", 100, gotItem.Value)
 		}
 	})
+
+	t.Run("Test typed values are normalized and validated", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		name := "mySet"
+		service.CreateSet(name)
+
+		newItem := domain.ConfigItem{
+			Key:       "timeout",
+			Value:     "90s",
+			Type:      domain.Plain,
+			ValueType: domain.DurationValue,
+		}
+		got, err := service.AddItem(newItem, name)
+		if err != nil {
+			t.Errorf("Expected item to be added without errors, got: %v", err)
+		}
+
+		if got.Items["timeout"].Value != "1m30s" {
+			t.Errorf("Expected item value: %q, got: %v", "1m30s", got.Items["timeout"].Value)
+		}
+
+		newItem.Value = 90
+		_, err = service.UpdateItem(newItem, name)
+		if _, ok := err.(*domain.ValueTypeError); !ok {
+			t.Errorf("Expected a value type error, got: %v", err)
+		}
+
+		set, _ := service.GetSet(name)
+		if set.Items["timeout"].Value != "1m30s" {
+			t.Errorf("Expected item value: %q, got: %v", "1m30s", set.Items["timeout"].Value)
+		}
+	})
 }
 
 func TestUpdateItemFromSet(t *testing.T) {
@@ -414,7 +450,7 @@ func TestUpdateItemFromSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -458,7 +494,7 @@ func TestUpdateItemFromSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -505,7 +541,7 @@ func TestRemoveItemFromSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -546,7 +582,7 @@ func TestRemoveItemFromSet(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -595,7 +631,7 @@ func TestConvertSetToJSON(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -651,7 +687,7 @@ func TestConvertSetToJSON(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		nested := "nested"
@@ -726,7 +762,7 @@ func TestConvertSetToJSON(t *testing.T) {
 		mockSecret := mocks.MockSecrets{
 			Values: values,
 		}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		name := "mySet"
 		service.CreateSet(name)
@@ -772,6 +808,32 @@ func TestConvertSetToJSON(t *testing.T) {
 			t.Errorf("Expected json: %s, got: %v", string(jsonBytes), string(got))
 		}
 	})
+
+	t.Run("Test typed values are rendered with their declared type", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		set := domain.NewConfigSet(
+			"mySet",
+			// Values as read from storage
+			domain.ConfigItem{Key: "id", Value: json.Number("9007199254740993"), Type: domain.Plain, ValueType: domain.IntValue},
+			domain.ConfigItem{Key: "ratio", Value: json.Number("1"), Type: domain.Plain, ValueType: domain.FloatValue},
+			domain.ConfigItem{Key: "hosts", Value: []interface{}{"a", "b"}, Type: domain.Plain, ValueType: domain.ListValue},
+			domain.ConfigItem{Key: "untyped", Value: json.Number("12345678901234567890"), Type: domain.Plain},
+		)
+
+		got, err := service.SetToJson(*set)
+		if err != nil {
+			t.Errorf("Expected set to be converted without errors, got: %v", err)
+		}
+
+		expected := `{"hosts":["a","b"],"id":9007199254740993,"ratio":1,"untyped":12345678901234567890}`
+		if string(got) != expected {
+			t.Errorf("Expected json: %s, got: %s", expected, string(got))
+		}
+	})
 }
 
 /// Test Caching of values
@@ -789,8 +851,8 @@ func TestJSONIsSavedToCache(t *testing.T) {
 		cacheRepo.SaveJSONInterceptor = func(json []byte, key string, ttl int) error {
 			cacheSaveCalled = true
 
-			if string(json) != "{}" {
-				t.Errorf("Expected json: %s, got: %s", "{}", string(json))
+			if string(json) != `{"format":1,"values":{}}` {
+				t.Errorf("Expected json: %s, got: %s", `{"format":1,"values":{}}`, string(json))
 			}
 
 			if ttl != int(config.CacheTTL) {
@@ -804,7 +866,7 @@ func TestJSONIsSavedToCache(t *testing.T) {
 			return nil
 		}
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		_, err := service.CreateSet(name)
 
@@ -826,15 +888,15 @@ func TestJSONIsSavedToCache(t *testing.T) {
 
 		name := "mySet"
 		newName := "newName"
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 		service.CreateSet(name)
 
 		cacheSaveCalled := false
 		cacheRepo.SaveJSONInterceptor = func(json []byte, key string, ttl int) error {
 			cacheSaveCalled = true
 
-			if string(json) != "{}" {
-				t.Errorf("Expected json: %s, got: %s", "{}", string(json))
+			if string(json) != `{"format":1,"values":{}}` {
+				t.Errorf("Expected json: %s, got: %s", `{"format":1,"values":{}}`, string(json))
 			}
 
 			if ttl != int(config.CacheTTL) {
@@ -856,7 +918,7 @@ func TestJSONIsSavedToCache(t *testing.T) {
 			return nil
 		}
 
-		_, err := service.RenameSet(name, newName)
+		_, err := service.RenameSet(name, newName, false)
 		if err != nil {
 			t.Errorf("Expected set to be renamed without errors, got: %v", err)
 		}
@@ -878,15 +940,15 @@ func TestJSONIsSavedToCache(t *testing.T) {
 		mockSecret := mocks.MockSecrets{}
 
 		name := "mySet"
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 		service.CreateSet(name)
 
 		cacheSaveCalled := false
 		cacheRepo.SaveJSONInterceptor = func(json []byte, key string, ttl int) error {
 			cacheSaveCalled = true
 
-			if string(json) != `{"string":"hello"}` {
-				t.Errorf("Expected json: %s, got: %s", "{}", string(json))
+			if string(json) != `{"format":1,"values":{"string":"hello"}}` {
+				t.Errorf("Expected json: %s, got: %s", `{"format":1,"values":{"string":"hello"}}`, string(json))
 			}
 
 			if ttl != int(config.CacheTTL) {
@@ -922,7 +984,7 @@ func TestJSONIsSavedToCache(t *testing.T) {
 		mockSecret := mocks.MockSecrets{}
 
 		name := "mySet"
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 		service.CreateSet(name)
 		service.AddItem(domain.ConfigItem{
 			Key:   "string",
@@ -934,8 +996,8 @@ func TestJSONIsSavedToCache(t *testing.T) {
 		cacheRepo.SaveJSONInterceptor = func(json []byte, key string, ttl int) error {
 			cacheSaveCalled = true
 
-			if string(json) != `{"string":"goodbye"}` {
-				t.Errorf("Expected json: %s, got: %s", "{}", string(json))
+			if string(json) != `{"format":1,"values":{"string":"goodbye"}}` {
+				t.Errorf("Expected json: %s, got: %s", `{"format":1,"values":{"string":"goodbye"}}`, string(json))
 			}
 
 			if ttl != int(config.CacheTTL) {
@@ -971,7 +1033,7 @@ func TestJSONIsSavedToCache(t *testing.T) {
 		mockSecret := mocks.MockSecrets{}
 
 		name := "mySet"
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 		service.CreateSet(name)
 		service.AddItem(domain.ConfigItem{
 			Key:   "string",
@@ -983,8 +1045,8 @@ func TestJSONIsSavedToCache(t *testing.T) {
 		cacheRepo.SaveJSONInterceptor = func(json []byte, key string, ttl int) error {
 			cacheSaveCalled = true
 
-			if string(json) != "{}" {
-				t.Errorf("Expected json: %s, got: %s", "{}", string(json))
+			if string(json) != `{"format":1,"values":{}}` {
+				t.Errorf("Expected json: %s, got: %s", `{"format":1,"values":{}}`, string(json))
 			}
 
 			if ttl != int(config.CacheTTL) {
@@ -1033,11 +1095,11 @@ func TestJSONIsReadFromCache(t *testing.T) {
 				t.Errorf("Expected MaxAge: %d, got: %d", expectedMaxAge, maxAge)
 			}
 
-			return []byte("{}"), nil
+			return []byte(`{"format":1,"values":{"cached":"value"}}`), nil
 		}
 
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 		service.CreateSet(name)
 
 		jsonBytes, err := service.GetSetJson(name, expectedMaxAge)
@@ -1049,6 +1111,31 @@ func TestJSONIsReadFromCache(t *testing.T) {
 			t.Errorf("Expected cache GetJSON to be called")
 		}
 
+		expected := `{"cached":"value"}`
+		if string(jsonBytes) != expected {
+			t.Errorf("Expected json: %s, got: %s", expected, string(jsonBytes))
+		}
+	})
+
+	t.Run("Test JSON cached with another layout is rendered again", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+
+		name := "mySet"
+		cacheRepo.GetJSONInterceptor = func(key string, maxAge int) ([]byte, error) {
+			return []byte(`{"values":{"cached":"value"}}`), nil
+		}
+
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service.CreateSet(name)
+
+		jsonBytes, err := service.GetSetJson(name, domain.AnyAge)
+		if err != nil {
+			t.Errorf("Expected set to be retrieved without errors, got: %v", err)
+		}
+
 		if string(jsonBytes) != "{}" {
 			t.Errorf("Expected json: %s, got: %s", "{}", string(jsonBytes))
 		}
@@ -1077,7 +1164,7 @@ func TestJSONIsReadFromCache(t *testing.T) {
 		}
 
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 		service.CreateSet(name)
 
 		jsonBytes, err := service.GetSetJson(name, expectedMaxAge)
@@ -1117,7 +1204,7 @@ func TestJSONIsReadFromCache(t *testing.T) {
 		}
 
 		mockSecret := mocks.MockSecrets{}
-		service := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 		service.CreateSet(name)
 
 		time.Sleep(2 * time.Second)
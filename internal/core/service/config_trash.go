@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+// Maximum number of expired sets purged at once by the sweeper
+const purgePageSize = 100
+
+func (service *ConfigService) GetTrashedSets(count int, skip int) ([]domain.TrashedSet, error) {
+	return service.repo.GetTrashedSets(count, skip)
+}
+
+func (service *ConfigService) RestoreSet(name string) (domain.ConfigSet, error) {
+	err := service.checkFreezeWindow()
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	set, err := service.repo.RestoreSet(name)
+	if err != nil {
+		return set, err
+	}
+
+	service.updateCache(set)
+	// Referrers kept rendering errors while the set was deleted
+	service.invalidateReferrers(name)
+	service.syncReferrers(name, nil, references(set))
+	service.addRevision(set, domain.RestoreAction, "", false)
+	return set, nil
+}
+
+func (service *ConfigService) PurgeSet(name string) (domain.TrashedSet, error) {
+	err := service.checkFreezeWindow()
+	if err != nil {
+		return domain.TrashedSet{}, err
+	}
+
+	return service.repo.PurgeSet(name)
+}
+
+func (service *ConfigService) PurgeExpiredSets() ([]domain.TrashedSet, error) {
+	purged := []domain.TrashedSet{}
+	for {
+		sets, err := service.repo.PurgeExpiredSets(datetime.UnixUTCNow(), purgePageSize)
+		purged = append(purged, sets...)
+		if err != nil || len(sets) < purgePageSize {
+			return purged, err
+		}
+	}
+}
+
+// RunTrashSweeper purges expired sets from the trash every interval until the context is done
+func (service *ConfigService) RunTrashSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := service.PurgeExpiredSets()
+			if err != nil {
+				log.Error().Stack().Err(err).Msg("Can't purge expired sets")
+			}
+
+			if len(purged) > 0 {
+				log.Info().Msgf("Purged %d expired sets", len(purged))
+			}
+		}
+	}
+}
+
+// purgeDate returns when a set deleted now must be purged, nil if the trash retention is disabled
+func (service *ConfigService) purgeDate() *time.Time {
+	if service.config.TrashRetention <= 0 {
+		return nil
+	}
+
+	purgeDate := datetime.UnixUTCNow().Add(time.Duration(service.config.TrashRetention) * time.Hour)
+	return &purgeDate
+}
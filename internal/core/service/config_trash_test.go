@@ -0,0 +1,169 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestTrash(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test deleted sets can be restored", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("db", "db", domain.Nested), "app")
+
+		_, err := service.DeleteSet("db", true)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+
+		trashed, _ := service.GetTrashedSets(10, 0)
+		if len(trashed) != 1 || trashed[0].Set.Name != "db" || trashed[0].PurgeDate == nil {
+			t.Fatalf("Expected the set to be trashed with a purge date, got: %+v", trashed)
+		}
+
+		_, err = service.GetSetJson("app", domain.AnyAge)
+		if err == nil {
+			t.Errorf("Expected referrers to fail while the set is deleted")
+		}
+
+		set, err := service.RestoreSet("db")
+		if err != nil || set.Items["host"].Value != "localhost" {
+			t.Fatalf("Expected set to be restored, got: %+v, %v", set, err)
+		}
+
+		cached, err := cacheRepo.GetJSON("db", domain.AnyAge)
+		if err != nil || string(cached) != `{"format":1,"values":{"host":"localhost"}}` {
+			t.Errorf("Expected restored set to be cached, got: %s, %v", cached, err)
+		}
+
+		got, err := service.GetSetJson("app", domain.AnyAge)
+		if err != nil || string(got) != `{"db":{"host":"localhost"}}` {
+			t.Errorf("Expected referrers to render the restored set, got: %s, %v", got, err)
+		}
+
+		referrers, _ := service.GetReferrers("db")
+		if len(referrers) != 1 || referrers[0].Name != "app" {
+			t.Errorf("Expected referrers to be kept, got: %v", referrers)
+		}
+
+		revisions, _ := service.GetRevisions("db", 10, 0)
+		if len(revisions) != 4 || revisions[0].Action != domain.RestoreAction || revisions[1].Action != domain.DeleteAction {
+			t.Errorf("Expected the revisions to survive the delete, got: %+v", revisions)
+		}
+
+		_, err = service.RestoreSet("db")
+		if err != ports.ErrTrashNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrTrashNotExists, err)
+		}
+	})
+
+	t.Run("Test sets are restored only if the name is free", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.DeleteSet("app", false)
+		service.CreateSet("app")
+
+		_, err := service.RestoreSet("app")
+		if err != ports.ErrDuplicatedConfig {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrDuplicatedConfig, err)
+		}
+
+		_, err = service.PurgeSet("app")
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+
+		trashed, _ := service.GetTrashedSets(10, 0)
+		if len(trashed) != 0 {
+			t.Errorf("Expected an empty trash, got: %+v", trashed)
+		}
+	})
+
+	t.Run("Test sets are deleted only if the trash has no set with the name", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		service.DeleteSet("app", false)
+		service.CreateSet("app")
+
+		_, err := service.DeleteSet("app", false)
+		if err != ports.ErrTrashExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrTrashExists, err)
+		}
+
+		trashed, _ := service.GetTrashedSets(10, 0)
+		if len(trashed) != 1 || trashed[0].Set.Items["timeout"].Value != "10s" {
+			t.Errorf("Expected the first deleted set in the trash, got: %+v", trashed)
+		}
+
+		service.PurgeSet("app")
+		_, err = service.DeleteSet("app", false)
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+	})
+
+	t.Run("Test expired sets are purged", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("old")
+		service.CreateSet("new")
+		service.DeleteSet("old", false)
+		service.DeleteSet("new", false)
+
+		expired := mockRepo.Trash["old"]
+		purgeDate := datetime.UnixUTCNow().Add(-time.Second)
+		expired.PurgeDate = &purgeDate
+		mockRepo.Trash["old"] = expired
+
+		purged, err := service.PurgeExpiredSets()
+		if err != nil || len(purged) != 1 || purged[0].Set.Name != "old" {
+			t.Errorf("Expected the expired set to be purged, got: %+v, %v", purged, err)
+		}
+
+		trashed, _ := service.GetTrashedSets(10, 0)
+		if len(trashed) != 1 || trashed[0].Set.Name != "new" {
+			t.Errorf("Expected the other set to stay in the trash, got: %+v", trashed)
+		}
+	})
+
+	t.Run("Test sets are kept until purged without a retention", func(t *testing.T) {
+		config := domain.DefaultConfig()
+		config.TrashRetention = 0
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.DeleteSet("app", false)
+
+		trashed, _ := service.GetTrashedSets(10, 0)
+		if len(trashed) != 1 || trashed[0].PurgeDate != nil {
+			t.Errorf("Expected the set to be trashed without a purge date, got: %+v", trashed)
+		}
+	})
+}
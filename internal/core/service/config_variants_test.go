@@ -0,0 +1,93 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestItemVariants(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test renders pick the variants matching the caller", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{
+			Values: map[string]string{"db/password": "s3cr3t", "db/eu-password": "eu-s3cr3t"},
+		}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("region", "default", domain.Plain), "app")
+
+		endpoint := domain.NewConfigItem("endpoint", "https://api.example.com/${self:region}", domain.Plain)
+		endpoint.Variants = []domain.Variant{
+			{When: domain.VariantCondition{Clients: []string{"web"}, MinVersion: "2.0"}, Value: "https://v2.example.com"},
+			{When: domain.VariantCondition{Regions: []string{"eu"}}, Value: "https://eu.example.com/${self:region}"},
+		}
+		password := domain.NewConfigItem("password", "db/password", domain.Secret)
+		password.Variants = []domain.Variant{
+			{When: domain.VariantCondition{Regions: []string{"eu"}}, Value: "db/eu-password"},
+		}
+
+		for _, item := range []*domain.ConfigItem{endpoint, password} {
+			_, err := service.AddItem(*item, "app")
+			if err != nil {
+				t.Fatalf("Expected no error adding %q, got: %v", item.Key, err)
+			}
+		}
+
+		cases := []struct {
+			attrs    domain.RequestAttributes
+			expected string
+		}{
+			{domain.RequestAttributes{}, `{"endpoint":"https://api.example.com/default","password":"s3cr3t","region":"default"}`},
+			{domain.RequestAttributes{Client: "web", Version: "2.1"}, `{"endpoint":"https://v2.example.com","password":"s3cr3t","region":"default"}`},
+			{domain.RequestAttributes{Client: "web", Version: "1.9", Region: "eu"}, `{"endpoint":"https://eu.example.com/default","password":"eu-s3cr3t","region":"default"}`},
+			{domain.RequestAttributes{Client: "ios", Region: "us"}, `{"endpoint":"https://api.example.com/default","password":"s3cr3t","region":"default"}`},
+		}
+
+		for _, c := range cases {
+			got, _, err := service.GetSetJsonFor("app", domain.AnyAge, c.attrs)
+			if err != nil {
+				t.Fatalf("Expected no error for %+v, got: %v", c.attrs, err)
+			}
+
+			if string(got) != c.expected {
+				t.Errorf("Expected JSON: %s for %+v, got: %s", c.expected, c.attrs, got)
+			}
+		}
+
+		got, _ := service.GetSetJson("app", domain.AnyAge)
+		if string(got) != cases[0].expected {
+			t.Errorf("Expected cached JSON to use default values, got: %s", got)
+		}
+	})
+
+	t.Run("Test invalid variants are rejected on write", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		item := domain.NewConfigItem("host", "app:other", domain.Ref)
+		item.Variants = []domain.Variant{{When: domain.VariantCondition{Regions: []string{"eu"}}, Value: "app:eu"}}
+		_, err := service.AddItem(*item, "app")
+		if err != domain.ErrVariantsNotSupported {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrVariantsNotSupported, err)
+		}
+
+		item = domain.NewConfigItem("host", "localhost", domain.Plain)
+		item.Variants = []domain.Variant{{Value: "eu.localhost"}}
+		_, err = service.AddItem(*item, "app")
+		if err != domain.ErrInvalidVariant {
+			t.Errorf("Expected error: %v, got: %v", domain.ErrInvalidVariant, err)
+		}
+
+		set, _ := service.GetSet("app")
+		if len(set.Items) != 0 {
+			t.Errorf("Expected set to not change, got: %+v", set.Items)
+		}
+	})
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+// Reference kinds supported inside plain string values, e.g. ${self:db.host}
+const (
+	// A key of the set being rendered: ${self:<path>}
+	selfReference = "self"
+	// A key of another set: ${set:<name>:<path>}, the path can be omitted to embed the whole set
+	setReference = "set"
+	// A value stored in the secret manager: ${secret:<name>}
+	secretReference = "secret"
+)
+
+// Separator of path segments inside references
+const referencePathSeparator = "."
+
+// interpolate replaces the references inside a plain string value.
+// A value made of a single reference keeps the type of the referenced value,
+// otherwise referenced values are embedded as text. "$${" is kept as a literal "${"
+// and references of unknown kinds are left untouched.
+func (service *ConfigService) interpolate(text string, key string, self domain.ConfigSet, ctx *renderContext) (interface{}, error) {
+	if !strings.Contains(text, "${") {
+		return text, nil
+	}
+
+	id := self.Name + ":" + key
+	if containsString(ctx.references, id) {
+		return nil, domain.NewCycleError(ctx.references, id)
+	}
+
+	ctx.references = append(ctx.references, id)
+	defer func() { ctx.references = ctx.references[:len(ctx.references)-1] }()
+
+	var out strings.Builder
+	rest := text
+	for {
+		start := strings.Index(rest, "${")
+		if start == -1 {
+			out.WriteString(rest)
+			break
+		}
+
+		if start > 0 && rest[start-1] == '$' {
+			out.WriteString(rest[:start-1] + "${")
+			rest = rest[start+2:]
+			continue
+		}
+
+		end := strings.Index(rest[start:], "}")
+		if end == -1 {
+			out.WriteString(rest)
+			break
+		}
+
+		expression := rest[start+2 : start+end]
+		value, ok, err := service.resolveReference(expression, self, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			out.WriteString(rest[:start+end+1])
+			rest = rest[start+end+1:]
+			continue
+		}
+
+		if start == 0 && end == len(rest)-1 && out.Len() == 0 {
+			return value, nil
+		}
+
+		out.WriteString(rest[:start])
+		out.WriteString(referenceText(value))
+		rest = rest[start+end+1:]
+	}
+
+	return out.String(), nil
+}
+
+// referenceExpressions returns the reference expressions inside a plain string value, e.g. "set:db:host",
+// following the same rules as interpolate. Escaped references are skipped
+func referenceExpressions(text string) []string {
+	expressions := []string{}
+	mapReferenceExpressions(text, func(expression string) string {
+		expressions = append(expressions, expression)
+		return expression
+	})
+
+	return expressions
+}
+
+// mapReferenceExpressions returns the text with every reference expression replaced by the result of mapping,
+// following the same rules as interpolate. Escaped references are kept as they are
+func mapReferenceExpressions(text string, mapping func(expression string) string) string {
+	var out strings.Builder
+	rest := text
+	for {
+		start := strings.Index(rest, "${")
+		if start == -1 {
+			out.WriteString(rest)
+			return out.String()
+		}
+
+		if start > 0 && rest[start-1] == '$' {
+			out.WriteString(rest[:start+2])
+			rest = rest[start+2:]
+			continue
+		}
+
+		end := strings.Index(rest[start:], "}")
+		if end == -1 {
+			out.WriteString(rest)
+			return out.String()
+		}
+
+		out.WriteString(rest[:start+2])
+		out.WriteString(mapping(rest[start+2 : start+end]))
+		out.WriteString("}")
+		rest = rest[start+end+1:]
+	}
+}
+
+// interpolatesText returns true for items whose string values are interpolated when rendered
+func interpolatesText(item domain.ConfigItem) bool {
+	return item.Type == domain.Plain && (item.ValueType == "" || item.ValueType == domain.StringValue)
+}
+
+// interpolatedSets returns the names of the sets referenced with ${set:...} in the value or variants of an item
+func interpolatedSets(item domain.ConfigItem) []string {
+	names := []string{}
+	if !interpolatesText(item) {
+		return names
+	}
+
+	for _, value := range itemValues(item) {
+		text, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		for _, expression := range referenceExpressions(text) {
+			kind, target := splitReference(expression)
+			if name, _ := splitReference(target); kind == setReference && name != "" && !containsString(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+
+	return names
+}
+
+// itemSecrets returns the names of the secrets read by an item, through its secret values or ${secret:...} interpolations
+func itemSecrets(item domain.ConfigItem) []string {
+	names := []string{}
+	if item.Type != domain.Secret && !interpolatesText(item) {
+		return names
+	}
+
+	for _, value := range itemValues(item) {
+		text, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		if item.Type == domain.Secret {
+			if !containsString(names, text) {
+				names = append(names, text)
+			}
+			continue
+		}
+
+		for _, expression := range referenceExpressions(text) {
+			if kind, target := splitReference(expression); kind == secretReference && !containsString(names, target) {
+				names = append(names, target)
+			}
+		}
+	}
+
+	return names
+}
+
+// renameInterpolatedSet returns the item with every ${set:oldName...} reference in its value or variants pointing at newName
+func renameInterpolatedSet(item domain.ConfigItem, oldName string, newName string) domain.ConfigItem {
+	if !interpolatesText(item) {
+		return item
+	}
+
+	rename := func(value interface{}) interface{} {
+		text, ok := value.(string)
+		if !ok {
+			return value
+		}
+
+		return mapReferenceExpressions(text, func(expression string) string {
+			kind, target := splitReference(expression)
+			name, path := splitReference(target)
+			if kind != setReference || name != oldName {
+				return expression
+			}
+
+			if strings.Contains(target, ":") {
+				return setReference + ":" + newName + ":" + path
+			}
+
+			return setReference + ":" + newName
+		})
+	}
+
+	item.Value = rename(item.Value)
+	if len(item.Variants) > 0 {
+		variants := make([]domain.Variant, len(item.Variants))
+		for i, variant := range item.Variants {
+			variant.Value = rename(variant.Value)
+			variants[i] = variant
+		}
+		item.Variants = variants
+	}
+
+	return item
+}
+
+// resolveReference returns the value of a single reference expression like "self:db.host"
+// ok is false when the expression is not a known reference kind
+func (service *ConfigService) resolveReference(expression string, self domain.ConfigSet, ctx *renderContext) (value interface{}, ok bool, err error) {
+	kind, target := splitReference(expression)
+
+	var set domain.ConfigSet
+	var path string
+	switch kind {
+	case selfReference:
+		set, path = self, target
+	case setReference:
+		name, setPath := splitReference(target)
+		set, err = service.loadSet(name, ctx)
+		if err == ports.ErrConfigNotExists {
+			return nil, true, &domain.ReferenceError{Chain: append(copyStrings(ctx.references), target)}
+		}
+
+		if err != nil {
+			return nil, true, err
+		}
+		path = setPath
+	case secretReference:
+		if ctx.secretPlaceholders {
+			return secretPlaceholderPrefix + target, true, nil
+		}
+
+		value, err = service.secretManager.Get(target)
+		return value, true, err
+	default:
+		return nil, false, nil
+	}
+
+	// The referenced value is rendered on its own, cycles through references are detected by ctx.references
+	nesting := ctx.nesting
+	ctx.nesting = nil
+	defer func() { ctx.nesting = nesting }()
+
+	segments := []string{}
+	if path != "" {
+		segments = strings.Split(path, referencePathSeparator)
+	}
+
+	value, err = service.valueAt(set, segments, ctx)
+	if _, missing := err.(*domain.PathNotFoundError); missing || err == ports.ErrConfigNotExists {
+		return nil, true, &domain.ReferenceError{Chain: append(copyStrings(ctx.references), set.Name+":"+path)}
+	}
+
+	return value, true, err
+}
+
+// splitReference splits "kind:rest" at the first colon
+func splitReference(expression string) (string, string) {
+	parts := strings.SplitN(expression, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+
+	return parts[0], parts[1]
+}
+
+// referenceText formats a referenced value to be embedded in a string
+func referenceText(value interface{}) string {
+	if str, ok := value.(string); ok {
+		return str
+	}
+
+	// Numbers, booleans and subtrees are embedded as JSON
+	bytes, _ := json.Marshal(value)
+	return string(bytes)
+}
+
+func copyStrings(list []string) []string {
+	return append([]string{}, list...)
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+	"github.com/sy-software/minerva-olive/mocks"
+)
+
+func TestInterpolation(t *testing.T) {
+	config := domain.DefaultConfig()
+	t.Run("Test references are resolved inside plain values", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("redis")
+		service.AddItem(*domain.NewConfigItem("host", "redis.local", domain.Plain), "redis")
+		service.CreateSet("shared-infra")
+		service.AddItem(*domain.NewConfigItem("redis", "redis", domain.Nested), "shared-infra")
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("port", 8080, domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("url", "https://${self:host}:${self:port}", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("health", "${self:url}/health", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("redisHost", "${set:shared-infra:redis.host}", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("listen", "${self:port}", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("literal", "$${self:host} ${HOME}", domain.Plain), "app")
+
+		got, err := service.GetSetJson("app", domain.AnyAge)
+		if err != nil {
+			t.Errorf("Expected set to be rendered without errors, got: %v", err)
+		}
+
+		expected := `{"health":"https://localhost:8080/health","host":"localhost","listen":8080,` +
+			`"literal":"${self:host} ${HOME}","port":8080,"redisHost":"redis.local","url":"https://localhost:8080"}`
+		if string(got) != expected {
+			t.Errorf("Expected json: %s, got: %s", expected, string(got))
+		}
+	})
+
+	t.Run("Test self references follow overlays", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("url", "http://${self:host}", domain.Plain), "app")
+		service.CreateSet("app-prod")
+		service.AddItem(*domain.NewConfigItem("host", "app.prod", domain.Plain), "app-prod")
+		service.SetBase("app-prod", "app")
+
+		got, _ := service.GetSetValue("app-prod", []string{"url"})
+		if got != "http://app.prod" {
+			t.Errorf("Expected value: %q, got: %v", "http://app.prod", got)
+		}
+	})
+
+	t.Run("Test reference cycles are detected", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("other")
+		service.AddItem(*domain.NewConfigItem("b", "${set:app:a}", domain.Plain), "other")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("a", "x${set:other:b}", domain.Plain), "app")
+
+		_, err := service.GetSetJson("app", domain.AnyAge)
+		cycleErr, ok := err.(*domain.CycleError)
+		if !ok {
+			t.Fatalf("Expected a cycle error, got: %v", err)
+		}
+
+		expected := []string{"app:a", "other:b", "app:a"}
+		if !cmp.Equal(cycleErr.Chain, expected) {
+			t.Errorf("Expected chain: %v, got: %v", expected, cycleErr.Chain)
+		}
+	})
+
+	t.Run("Test dangling references report the chain", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("endpoint", "http://${self:host}", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("host", "${set:infra:host}", domain.Plain), "app")
+
+		_, err := service.GetSetJson("app", domain.AnyAge)
+		refErr, ok := err.(*domain.ReferenceError)
+		if !ok {
+			t.Fatalf("Expected a reference error, got: %v", err)
+		}
+
+		expected := []string{"app:endpoint", "app:host", "infra:host"}
+		if !cmp.Equal(refErr.Chain, expected) {
+			t.Errorf("Expected chain: %v, got: %v", expected, refErr.Chain)
+		}
+	})
+
+	t.Run("Test inline secrets are resolved keeping the surrounding text", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{
+			Values: map[string]string{"db/password": "s3cr3t"},
+		}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		dsn := "postgres://app:${secret:db/password}@db:5432/app"
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("dsn", dsn, domain.Plain), "app")
+
+		got, err := service.GetSetJson("app", domain.AnyAge)
+		if err != nil {
+			t.Errorf("Expected set to be rendered without errors, got: %v", err)
+		}
+
+		expected := `{"dsn":"postgres://app:s3cr3t@db:5432/app"}`
+		if string(got) != expected {
+			t.Errorf("Expected json: %s, got: %s", expected, string(got))
+		}
+
+		set, _ := service.GetSet("app")
+		if set.Items["dsn"].Value != dsn {
+			t.Errorf("Expected stored value: %q, got: %v", dsn, set.Items["dsn"].Value)
+		}
+
+		origins, _ := service.GetSetOrigins("app")
+		if !cmp.Equal(origins, map[string]interface{}{"dsn": "app"}) {
+			t.Errorf("Expected origins to not resolve secrets, got: %v", origins)
+		}
+	})
+
+	t.Run("Test a missing inline secret fails the render", func(t *testing.T) {
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("dsn", "postgres://app:${secret:db/password}@db", domain.Plain), "app")
+
+		_, err := service.GetSetJson("app", domain.AnyAge)
+		if err != ports.ErrSecretNoExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrSecretNoExists, err)
+		}
+	})
+}
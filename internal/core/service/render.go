@@ -0,0 +1,262 @@
+package service
+
+import (
+	"time"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+// Prefix used to replace secret values when a render doesn't resolve secrets
+const secretPlaceholderPrefix = "secret:"
+
+// renderContext holds the options of a single render operation
+type renderContext struct {
+	// When not nil, referenced sets are resolved as they existed at this time
+	at *time.Time
+	// When true, secrets are not resolved and are replaced by a placeholder string
+	secretPlaceholders bool
+	// The items whose references are being resolved, formatted as "set:key", used to detect cycles
+	references []string
+	// The sets being rendered through nested items, starting with the rendered set
+	nesting []string
+	// When not nil, a set about to be written that is used instead of its stored version
+	pending *domain.ConfigSet
+	// When not nil, the caller attributes used to pick item variants
+	attributes *domain.RequestAttributes
+	// When not nil, the deprecated items rendered are saved here keyed by their path
+	deprecations map[string]domain.Deprecation
+	// The path of the nested item being rendered, e.g. "database.", used to key deprecations
+	keyPrefix string
+}
+
+// renderedSet is the result of rendering a set
+type renderedSet struct {
+	// The final values of the set
+	values map[string]interface{}
+	// A tree with the same shape as values where each leaf
+	// is the name of the set that defined the value
+	origins map[string]interface{}
+}
+
+// loadSet finds a set by name honoring the render context options
+func (service *ConfigService) loadSet(name string, ctx *renderContext) (domain.ConfigSet, error) {
+	if ctx.pending != nil && ctx.pending.Name == name {
+		return *ctx.pending, nil
+	}
+
+	if ctx.at == nil {
+		return service.GetSet(name)
+	}
+
+	return service.getSetAt(name, *ctx.at)
+}
+
+func (service *ConfigService) setToMap(set domain.ConfigSet, ctx *renderContext) (map[string]interface{}, error) {
+	rendered, err := service.renderSet(set, ctx)
+	return rendered.values, err
+}
+
+// renderSet renders the set on top of its base sets
+func (service *ConfigService) renderSet(set domain.ConfigSet, ctx *renderContext) (renderedSet, error) {
+	rendered := renderedSet{
+		values:  map[string]interface{}{},
+		origins: map[string]interface{}{},
+	}
+
+	layers, err := service.overlayLayers(set, ctx)
+	if err != nil {
+		return rendered, err
+	}
+
+	for _, layer := range layers {
+		// Render in key order so errors are reported the same way on every read
+		for _, key := range sortedKeys(layer.Items) {
+			item := layer.Items[key]
+			_, merged := rendered.origins[item.Key].(map[string]interface{})
+			ctx.recordDeprecation(item, merged && item.Type == domain.Nested)
+
+			value, origin, err := service.renderItem(item, layer, set, ctx)
+			if err != nil {
+				return rendered, err
+			}
+
+			mergeValue(rendered.values, rendered.origins, item.Key, value, origin)
+		}
+	}
+
+	return rendered, nil
+}
+
+// overlayLayers returns the chain of sets the given set overlays,
+// starting with the bottom base and ending with the set itself
+func (service *ConfigService) overlayLayers(set domain.ConfigSet, ctx *renderContext) ([]domain.ConfigSet, error) {
+	layers := []domain.ConfigSet{set}
+	chain := []string{set.Name}
+	for current := set; current.Base != ""; {
+		if containsString(chain, current.Base) {
+			return nil, domain.NewCycleError(chain, current.Base)
+		}
+
+		base, err := service.loadSet(current.Base, ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append([]domain.ConfigSet{base}, layers...)
+		chain = append(chain, base.Name)
+		current = base
+	}
+
+	return layers, nil
+}
+
+// renderItem returns the final value of a single item defined in the given set and its origin
+// References inside plain values are resolved against self, the top of the overlay chain being rendered
+func (service *ConfigService) renderItem(item domain.ConfigItem, set domain.ConfigSet, self domain.ConfigSet, ctx *renderContext) (interface{}, interface{}, error) {
+	if ctx.attributes != nil {
+		item.Value = item.ValueFor(*ctx.attributes)
+	}
+
+	switch item.Type {
+	case domain.Nested:
+		name, ok := item.Value.(string)
+		if !ok {
+			return nil, nil, domain.ErrInvalidNestedKeyValue
+		}
+		leave, err := service.enterNested(name, self, ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer leave()
+
+		prefix := ctx.keyPrefix
+		ctx.keyPrefix = prefix + item.Key + "."
+		defer func() { ctx.keyPrefix = prefix }()
+
+		nestedSet, err := service.loadSet(name, ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		rendered, err := service.renderSet(nestedSet, ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return rendered.values, rendered.origins, nil
+	case domain.Ref:
+		value, err := service.resolveRef(item, self, nil, ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return value, set.Name, nil
+	case domain.Sealed:
+		if ctx.secretPlaceholders {
+			return sealedPlaceholder(item), set.Name, nil
+		}
+
+		value, err := service.openValue(item)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return value, set.Name, nil
+	case domain.Computed:
+		value, err := service.evaluateComputed(item, self, ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return value, set.Name, nil
+	case domain.Generated:
+		// Generated values are used as stored, they are never interpolated
+		return item.Value, set.Name, nil
+	case domain.Secret:
+		name, ok := item.Value.(string)
+		if !ok {
+			return nil, nil, domain.ErrSecretKeyValue
+		}
+
+		if ctx.secretPlaceholders {
+			return secretPlaceholderPrefix + name, set.Name, nil
+		}
+
+		val, err := service.secretManager.Get(name)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return val, set.Name, nil
+	default:
+		value, err := item.TypedValue()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		text, isText := value.(string)
+		if isText && (item.ValueType == "" || item.ValueType == domain.StringValue) {
+			value, err = service.interpolate(text, item.Key, self, ctx)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			if item.ValueType == domain.StringValue {
+				value = referenceText(value)
+			}
+		}
+
+		return value, set.Name, nil
+	}
+}
+
+// enterNested records that the set with the given name is about to be rendered inside self
+// It fails if the set is already being rendered or the maximum nesting depth is exceeded
+// The returned function must be called once the nested set is rendered
+func (service *ConfigService) enterNested(name string, self domain.ConfigSet, ctx *renderContext) (func(), error) {
+	previous := ctx.nesting
+	chain := previous
+	if len(chain) == 0 {
+		chain = []string{self.Name}
+	}
+
+	if containsString(chain, name) {
+		return nil, domain.NewCycleError(chain, name)
+	}
+
+	chain = append(copyStrings(chain), name)
+	if max := service.config.MaxNestingDepth; max > 0 && len(chain)-1 > max {
+		return nil, &domain.DepthError{Chain: chain, Max: max}
+	}
+
+	ctx.nesting = chain
+	return func() { ctx.nesting = previous }, nil
+}
+
+// mergeValue saves the value under key, nested subtrees present in both sides are deep merged
+// Only nested subtrees are merged, their origins are maps instead of set names
+func mergeValue(values map[string]interface{}, origins map[string]interface{}, key string, value interface{}, origin interface{}) {
+	currentOrigins, currentIsTree := origins[key].(map[string]interface{})
+	newOrigins, newIsTree := origin.(map[string]interface{})
+	if currentIsTree && newIsTree {
+		current := values[key].(map[string]interface{})
+		for subKey, subValue := range value.(map[string]interface{}) {
+			mergeValue(current, currentOrigins, subKey, subValue, newOrigins[subKey])
+		}
+
+		return
+	}
+
+	values[key] = value
+	origins[key] = origin
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+
+	return false
+}
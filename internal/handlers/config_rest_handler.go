@@ -3,9 +3,11 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -20,10 +22,46 @@ const (
 	singleflightOn string = "single_flight_on"
 )
 
+// Headers describing the caller, used to pick item variants and canary buckets
+const (
+	clientNameHeader    = "X-Client-Name"
+	clientRegionHeader  = "X-Client-Region"
+	clientVersionHeader = "X-Client-Version"
+	clientLabelsHeader  = "X-Client-Labels"
+	clientIDHeader      = "X-Client-ID"
+	// Response header with the version of the set served, stable or canary
+	configVersionHeader = "X-Config-Version"
+	// Response header listing the deprecated keys of the set served
+	warningHeader = "Warning"
+)
+
+// Default page size for paginated endpoints
+const defaultPageSize = 20
+
 type renameBody struct {
 	Name string `json:"name"`
 }
 
+type cloneBody struct {
+	Name          string `json:"name"`
+	Prefix        string `json:"prefix"`
+	KeepGenerated bool   `json:"keepGenerated"`
+}
+
+type baseBody struct {
+	Base string `json:"base"`
+}
+
+type canaryBody struct {
+	Items   domain.ConfigItemMap `json:"items"`
+	Percent *int                 `json:"percent"`
+}
+
+type freezeBody struct {
+	Reason string     `json:"reason"`
+	Until  *time.Time `json:"until"`
+}
+
 // ConfigRESTHandler provides a REST API handler for ports.ConfigService
 type ConfigRESTHandler struct {
 	config      *domain.Config
@@ -65,6 +103,17 @@ func (handler *ConfigRESTHandler) CreateRoutes(router *gin.Engine) {
 			c.Data(http.StatusOK, "application/json; charset=utf-8", data)
 		})
 
+		group.GET("/config/:name/*path", func(c *gin.Context) {
+			data, version, err := handler.GetConfigValue(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeConfigVersion(c, version)
+			c.JSON(http.StatusOK, gin.H{"data": data})
+		})
+
 		group.POST("/configset/:name/item", func(c *gin.Context) {
 			data, err := handler.AddConfigItem(c)
 
@@ -72,7 +121,7 @@ func (handler *ConfigRESTHandler) CreateRoutes(router *gin.Engine) {
 				handleError(err, c)
 				return
 			}
-			c.JSON(http.StatusOK, gin.H{"data": data})
+			writeData(c, data)
 		})
 
 		group.PATCH("/configset/:name/item", func(c *gin.Context) {
@@ -82,7 +131,7 @@ func (handler *ConfigRESTHandler) CreateRoutes(router *gin.Engine) {
 				handleError(err, c)
 				return
 			}
-			c.JSON(http.StatusOK, gin.H{"data": data})
+			writeData(c, data)
 		})
 
 		group.DELETE("/configset/:name/item/:key", func(c *gin.Context) {
@@ -92,7 +141,17 @@ func (handler *ConfigRESTHandler) CreateRoutes(router *gin.Engine) {
 				handleError(err, c)
 				return
 			}
-			c.JSON(http.StatusOK, gin.H{"data": data})
+			writeData(c, data)
+		})
+
+		group.POST("/configset/:name/item/:key/regenerate", func(c *gin.Context) {
+			data, err := handler.RegenerateConfigItem(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
 		})
 
 		group.GET("/configset/:name", func(c *gin.Context) {
@@ -101,7 +160,17 @@ func (handler *ConfigRESTHandler) CreateRoutes(router *gin.Engine) {
 				handleError(err, c)
 				return
 			}
-			c.JSON(http.StatusOK, gin.H{"data": data})
+			writeData(c, data)
+		})
+
+		group.GET("/configsets", func(c *gin.Context) {
+			data, err := handler.GetConfigSetNames(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
 		})
 
 		group.POST("/configset/:name", func(c *gin.Context) {
@@ -113,153 +182,1102 @@ func (handler *ConfigRESTHandler) CreateRoutes(router *gin.Engine) {
 				data, err = handler.CreateConfigSet(c)
 			}
 
-			if err != nil {
-				handleError(err, c)
-				return
-			}
-			c.JSON(http.StatusOK, gin.H{"data": data})
-		})
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.DELETE("/configset/:name", func(c *gin.Context) {
+			data, err := handler.DeleteConfigSet(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.PUT("/configset/:name/metadata", func(c *gin.Context) {
+			data, err := handler.UpdateConfigSetMetadata(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.POST("/configset/:name/clone", func(c *gin.Context) {
+			data, err := handler.CloneConfigSet(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.GET("/configset/:name/revisions", func(c *gin.Context) {
+			data, err := handler.GetConfigSetRevisions(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.GET("/configset/:name/revisions/:revision", func(c *gin.Context) {
+			data, err := handler.GetConfigSetRevision(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.POST("/configset/:name/revisions/:revision/rollback", func(c *gin.Context) {
+			data, err := handler.RollbackConfigSet(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.PUT("/configset/:name/base", func(c *gin.Context) {
+			data, err := handler.SetConfigSetBase(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.DELETE("/configset/:name/base", func(c *gin.Context) {
+			data, err := handler.RemoveConfigSetBase(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.GET("/configset/:name/origins", func(c *gin.Context) {
+			data, err := handler.GetConfigSetOrigins(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.GET("/configset/:name/referrers", func(c *gin.Context) {
+			data, err := handler.GetConfigSetReferrers(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.GET("/configset/:name/diff", func(c *gin.Context) {
+			data, err := handler.DiffConfigSet(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.PUT("/configset/:name/schema", func(c *gin.Context) {
+			data, err := handler.SetConfigSetSchema(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.DELETE("/configset/:name/schema", func(c *gin.Context) {
+			data, err := handler.RemoveConfigSetSchema(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.GET("/configset/:name/graph", func(c *gin.Context) {
+			format, err := graphFormat(c)
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+
+			data, err := handler.GetConfigSetGraph(c)
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeGraph(c, data, format)
+		})
+
+		group.GET("/graph", func(c *gin.Context) {
+			format, err := graphFormat(c)
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+
+			data, err := handler.GetGraph(c)
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeGraph(c, data, format)
+		})
+
+		group.GET("/configset/:name/canary", func(c *gin.Context) {
+			data, err := handler.GetConfigSetCanary(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.PUT("/configset/:name/canary", func(c *gin.Context) {
+			data, err := handler.StartConfigSetCanary(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.PATCH("/configset/:name/canary", func(c *gin.Context) {
+			data, err := handler.SetConfigSetCanaryPercent(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.POST("/configset/:name/canary/promote", func(c *gin.Context) {
+			data, err := handler.PromoteConfigSetCanary(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.DELETE("/configset/:name/canary", func(c *gin.Context) {
+			data, err := handler.AbortConfigSetCanary(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.GET("/configset/:name/scheduled", func(c *gin.Context) {
+			data, err := handler.GetScheduledConfigChanges(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.GET("/configset/:name/scheduled/failed", func(c *gin.Context) {
+			data, err := handler.GetFailedConfigChanges(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.POST("/configset/:name/scheduled", func(c *gin.Context) {
+			data, err := handler.ScheduleConfigChange(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.DELETE("/configset/:name/scheduled/:id", func(c *gin.Context) {
+			data, err := handler.CancelScheduledConfigChange(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.PUT("/configset/:name/freeze", func(c *gin.Context) {
+			data, err := handler.FreezeConfigSet(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.DELETE("/configset/:name/freeze", func(c *gin.Context) {
+			data, err := handler.UnfreezeConfigSet(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.GET("/trash", func(c *gin.Context) {
+			data, err := handler.GetTrashedConfigSets(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.POST("/trash/:name/restore", func(c *gin.Context) {
+			data, err := handler.RestoreConfigSet(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.DELETE("/trash/:name", func(c *gin.Context) {
+			data, err := handler.PurgeConfigSet(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.GET("/overrides", func(c *gin.Context) {
+			data, err := handler.GetConfigOverrides(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.POST("/reseal", func(c *gin.Context) {
+			data, err := handler.ResealConfigSets(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+
+		group.GET("/validate", func(c *gin.Context) {
+			data, err := handler.ValidateConfigSets(c)
+
+			if err != nil {
+				handleError(err, c)
+				return
+			}
+			writeData(c, data)
+		})
+	}
+}
+
+// renderedConfig is a rendered set ready to be sent, the version of the set it comes from
+// and the deprecated items it contains
+type renderedConfig struct {
+	data         []byte
+	version      domain.ConfigVersion
+	deprecations []domain.Deprecation
+}
+
+func (handler *ConfigRESTHandler) GetConfigJSON(c *gin.Context) ([]byte, error) {
+	rendered, err := handler.renderConfigJSON(c)
+	if err != nil {
+		return nil, err
+	}
+
+	writeConfigVersion(c, rendered.version)
+	writeDeprecationWarning(c, rendered.deprecations)
+	return rendered.data, nil
+}
+
+func (handler *ConfigRESTHandler) renderConfigJSON(c *gin.Context) (renderedConfig, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return renderedConfig{}, domain.ErrMissingParam("name")
+	}
+
+	ageQuery := c.Query("maxAge")
+	age := domain.AnyAge
+	if ageQuery != "" {
+		var err error
+		age, err = strconv.Atoi(ageQuery)
+
+		if err != nil {
+			return renderedConfig{}, domain.InvalidParam("maxAge")
+		}
+	}
+
+	attrs, err := requestAttributes(c)
+	if err != nil {
+		return renderedConfig{}, err
+	}
+
+	var rendered domain.RenderedConfig
+	if atQuery := c.Query("at"); atQuery != "" {
+		at, parseErr := time.Parse(time.RFC3339, atQuery)
+		if parseErr != nil {
+			return renderedConfig{}, domain.InvalidParam("at")
+		}
+
+		if !attrs.Empty() {
+			return renderedConfig{}, domain.ErrBadRequest("at can't be combined with client attributes")
+		}
+
+		rendered, err = handler.service.RenderSetAt(name, at)
+	} else {
+		rendered, err = handler.service.RenderSetFor(name, age, attrs)
+	}
+
+	if err != nil {
+		return renderedConfig{}, mapServiceError(err, name, "GetConfigJSON")
+	}
+
+	output := append([]byte(`{"data":`), rendered.Values...)
+	output = append(output, []byte("}")...)
+	return renderedConfig{data: output, version: rendered.Version, deprecations: rendered.Deprecations}, nil
+}
+
+func (handler *ConfigRESTHandler) GetConfigValue(c *gin.Context) (interface{}, domain.ConfigVersion, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return nil, "", domain.ErrMissingParam("name")
+	}
+
+	path := []string{}
+	for _, segment := range strings.Split(c.Param("path"), "/") {
+		if segment != "" {
+			path = append(path, segment)
+		}
+	}
+
+	attrs, err := requestAttributes(c)
+	if err != nil {
+		return nil, "", err
+	}
+
+	output, version, err := handler.service.GetSetValueFor(name, path, attrs)
+	if err != nil {
+		return nil, "", mapServiceError(err, name, "GetConfigValue")
+	}
+
+	return output, version, nil
+}
+
+func (handler *ConfigRESTHandler) CreateConfigSet(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	output, err := handler.serviceFor(c).CreateSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, mapServiceError(err, name, "CreateConfigSet")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) RenameConfigSet(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	jsonData, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+	var body renameBody
+	err = json.Unmarshal(jsonData, &body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+
+	if body.Name == "" || body.Name == name {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid new name")
+	}
+
+	rewrite, err := boolParam(c, "rewrite")
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	output, err := handler.serviceFor(c).RenameSet(name, body.Name, rewrite)
+	if err != nil {
+		return domain.ConfigSet{}, mapServiceError(err, name, "RenameConfigSet")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) GetConfigSet(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	output, err := handler.service.GetSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, mapServiceError(err, name, "GetConfigSet")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) GetConfigSetNames(c *gin.Context) ([]string, error) {
+	limit, skip, err := pageParams(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var labels map[string]string
+	if selector := c.Query("labels"); selector != "" {
+		labels, err = domain.ParseLabelSelector(selector)
+		if err != nil {
+			return nil, domain.InvalidParam("labels")
+		}
+	}
+
+	output, err := handler.service.GetSetNames(limit, skip, labels)
+	if err != nil {
+		return nil, mapServiceError(err, "", "GetConfigSetNames")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) UpdateConfigSetMetadata(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	jsonData, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+	var body domain.SetMetadata
+	err = domain.DecodeJSON(jsonData, &body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+
+	output, err := handler.serviceFor(c).UpdateMetadata(name, body)
+	if err != nil {
+		return domain.ConfigSet{}, mapServiceError(err, name, "UpdateConfigSetMetadata")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) CloneConfigSet(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	jsonData, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+	var body cloneBody
+	err = json.Unmarshal(jsonData, &body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+
+	if body.Name == "" || body.Name == name {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid new name")
+	}
+
+	output, err := handler.serviceFor(c).CloneSet(name, body.Name, body.Prefix, body.KeepGenerated)
+	if err != nil {
+		return domain.ConfigSet{}, mapServiceError(err, name, "CloneConfigSet")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) DeleteConfigSet(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	force, err := boolParam(c, "force")
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	output, err := handler.serviceFor(c).DeleteSet(name, force)
+	if err != nil {
+		return domain.ConfigSet{}, mapServiceError(err, name, "DeleteConfigSet")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) AddConfigItem(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	jsonData, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+	var body domain.ConfigItem
+	err = domain.DecodeJSON(jsonData, &body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+
+	output, err := handler.serviceFor(c).AddItem(body, name)
+	if err != nil {
+		return domain.ConfigSet{}, mapServiceError(err, name, "AddConfigItem")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) UpdateConfigItem(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	jsonData, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+	var body domain.ConfigItem
+	err = domain.DecodeJSON(jsonData, &body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+
+	expiresAt, temporary, err := expiryParam(c)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	var output domain.ConfigSet
+	if temporary {
+		output, err = handler.serviceFor(c).OverrideItem(body, name, expiresAt)
+	} else {
+		output, err = handler.serviceFor(c).UpdateItem(body, name)
+	}
+
+	if err != nil {
+		if err == domain.ErrKeyNotExists {
+			return domain.ConfigSet{}, domain.ErrNotFound(body.Key)
+		}
+
+		return domain.ConfigSet{}, mapServiceError(err, name, "UpdateConfigItem")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) DeleteConfigItem(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	key, ok := c.Params.Get("key")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("key")
+	}
+
+	output, err := handler.serviceFor(c).RemoveItem(*domain.NewConfigItem(key, "", domain.Plain), name)
+	if err != nil {
+		if err == domain.ErrKeyNotExists {
+			return domain.ConfigSet{}, domain.ErrNotFound(key)
+		}
+
+		return domain.ConfigSet{}, mapServiceError(err, name, "DeleteConfigItem")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) RegenerateConfigItem(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	key, ok := c.Params.Get("key")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("key")
+	}
+
+	output, err := handler.serviceFor(c).RegenerateItem(name, key)
+	if err != nil {
+		if err == domain.ErrKeyNotExists {
+			return domain.ConfigSet{}, domain.ErrNotFound(key)
+		}
+
+		return domain.ConfigSet{}, mapServiceError(err, name, "RegenerateConfigItem")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) GetConfigSetRevisions(c *gin.Context) ([]domain.ConfigRevision, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return nil, domain.ErrMissingParam("name")
+	}
+
+	limit, skip, err := pageParams(c)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := handler.service.GetRevisions(name, limit, skip)
+	if err != nil {
+		return nil, mapServiceError(err, name, "GetConfigSetRevisions")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) GetConfigSetRevision(c *gin.Context) (domain.ConfigRevision, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigRevision{}, domain.ErrMissingParam("name")
+	}
+
+	revision, err := revisionParam(c)
+	if err != nil {
+		return domain.ConfigRevision{}, err
+	}
+
+	output, err := handler.service.GetRevision(name, revision)
+	if err != nil {
+		if err == ports.ErrRevisionNotExists {
+			return domain.ConfigRevision{}, domain.ErrNotFound(fmt.Sprintf("%s@%d", name, revision))
+		}
+
+		return domain.ConfigRevision{}, mapServiceError(err, name, "GetConfigSetRevision")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) RollbackConfigSet(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	revision, err := revisionParam(c)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	output, err := handler.serviceFor(c).RollbackSet(name, revision)
+	if err != nil {
+		if err == ports.ErrRevisionNotExists {
+			return domain.ConfigSet{}, domain.ErrNotFound(fmt.Sprintf("%s@%d", name, revision))
+		}
+
+		return domain.ConfigSet{}, mapServiceError(err, name, "RollbackConfigSet")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) DiffConfigSet(c *gin.Context) (domain.ConfigDiff, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigDiff{}, domain.ErrMissingParam("name")
+	}
+
+	against := c.Query("against")
+	if against == "" {
+		return domain.ConfigDiff{}, domain.ErrMissingParam("against")
+	}
+
+	output, err := handler.service.DiffSets(name, against)
+	if err != nil {
+		if err == ports.ErrRevisionNotExists {
+			return domain.ConfigDiff{}, domain.ErrNotFound(fmt.Sprintf("%s@%s", name, against))
+		}
+
+		return domain.ConfigDiff{}, mapServiceError(err, fmt.Sprintf("%s or %s", name, against), "DiffConfigSet")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) SetConfigSetBase(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	jsonData, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+	var body baseBody
+	err = json.Unmarshal(jsonData, &body)
+	if err != nil {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+
+	if body.Base == "" {
+		return domain.ConfigSet{}, domain.ErrMissingParam("base")
+	}
+
+	return handler.setConfigSetBase(c, name, body.Base)
+}
+
+func (handler *ConfigRESTHandler) RemoveConfigSetBase(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	return handler.setConfigSetBase(c, name, "")
+}
+
+func (handler *ConfigRESTHandler) setConfigSetBase(c *gin.Context, name string, base string) (domain.ConfigSet, error) {
+	output, err := handler.serviceFor(c).SetBase(name, base)
+	if err != nil {
+		resource := name
+		if base != "" {
+			resource = fmt.Sprintf("%s or %s", name, base)
+		}
+
+		return domain.ConfigSet{}, mapServiceError(err, resource, "SetConfigSetBase")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) GetConfigSetOrigins(c *gin.Context) (map[string]interface{}, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return nil, domain.ErrMissingParam("name")
+	}
+
+	output, err := handler.service.GetSetOrigins(name)
+	if err != nil {
+		return nil, mapServiceError(err, name, "GetConfigSetOrigins")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) GetConfigSetReferrers(c *gin.Context) ([]domain.SetReferrer, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return nil, domain.ErrMissingParam("name")
+	}
+
+	output, err := handler.service.GetReferrers(name)
+	if err != nil {
+		return nil, mapServiceError(err, name, "GetConfigSetReferrers")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) SetConfigSetSchema(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	jsonData, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil || !json.Valid(jsonData) {
+		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+	}
+
+	return handler.setConfigSetSchema(c, name, jsonData)
+}
+
+func (handler *ConfigRESTHandler) RemoveConfigSetSchema(c *gin.Context) (domain.ConfigSet, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+	}
+
+	return handler.setConfigSetSchema(c, name, nil)
+}
 
-		group.DELETE("/configset/:name", func(c *gin.Context) {
-			data, err := handler.DeleteConfigSet(c)
+func (handler *ConfigRESTHandler) setConfigSetSchema(c *gin.Context, name string, schema []byte) (domain.ConfigSet, error) {
+	output, err := handler.serviceFor(c).SetSchema(name, schema)
+	if err != nil {
+		return domain.ConfigSet{}, mapServiceError(err, name, "SetConfigSetSchema")
+	}
 
-			if err != nil {
-				handleError(err, c)
-				return
-			}
-			c.JSON(http.StatusOK, gin.H{"data": data})
-		})
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) ValidateConfigSets(c *gin.Context) ([]domain.SetValidation, error) {
+	output, err := handler.service.ValidateSets()
+	if err != nil {
+		return nil, mapServiceError(err, "", "ValidateConfigSets")
 	}
+
+	return output, nil
 }
 
-func (handler *ConfigRESTHandler) GetConfigJSON(c *gin.Context) ([]byte, error) {
+func (handler *ConfigRESTHandler) GetConfigSetCanary(c *gin.Context) (domain.Canary, error) {
 	name, ok := c.Params.Get("name")
 
 	if !ok {
-		return nil, domain.ErrMissingParam("name")
+		return domain.Canary{}, domain.ErrMissingParam("name")
 	}
 
-	ageQuery := c.Query("maxAge")
-	age := domain.AnyAge
-	if ageQuery != "" {
-		var err error
-		age, err = strconv.Atoi(ageQuery)
+	output, err := handler.service.GetCanary(name)
+	if err != nil {
+		return domain.Canary{}, mapServiceError(err, name, "GetConfigSetCanary")
+	}
 
-		if err != nil {
-			return nil, domain.InvalidParam("maxAge")
-		}
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) StartConfigSetCanary(c *gin.Context) (domain.Canary, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.Canary{}, domain.ErrMissingParam("name")
 	}
 
-	output, err := handler.service.GetSetJson(name, age)
+	body, err := readCanaryBody(c)
 	if err != nil {
-		if err == ports.ErrConfigNotExists {
-			return nil, domain.ErrNotFound(name)
-		}
+		return domain.Canary{}, err
+	}
 
-		log.Error().Stack().Err(err).Msg("GetConfigJSON error")
-		return nil, &domain.ErrInternalError
+	if body.Items == nil {
+		return domain.Canary{}, domain.ErrMissingParam("items")
+	}
+
+	output, err := handler.serviceFor(c).StartCanary(name, body.Items, *body.Percent)
+	if err != nil {
+		return domain.Canary{}, mapServiceError(err, name, "StartConfigSetCanary")
 	}
 
-	output = append([]byte(`{"data":`), output...)
-	output = append(output, []byte("}")...)
 	return output, nil
 }
 
-func (handler *ConfigRESTHandler) CreateConfigSet(c *gin.Context) (domain.ConfigSet, error) {
+func (handler *ConfigRESTHandler) SetConfigSetCanaryPercent(c *gin.Context) (domain.Canary, error) {
 	name, ok := c.Params.Get("name")
 
 	if !ok {
-		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+		return domain.Canary{}, domain.ErrMissingParam("name")
 	}
 
-	output, err := handler.service.CreateSet(name)
+	body, err := readCanaryBody(c)
 	if err != nil {
-		if err == ports.ErrDuplicatedConfig {
-			return domain.ConfigSet{}, domain.ErrBadRequest(err.Error())
-		}
+		return domain.Canary{}, err
+	}
 
-		log.Error().Stack().Err(err).Msg("GetConfigSet error")
-		return domain.ConfigSet{}, &domain.ErrInternalError
+	output, err := handler.serviceFor(c).SetCanaryPercent(name, *body.Percent)
+	if err != nil {
+		return domain.Canary{}, mapServiceError(err, name, "SetConfigSetCanaryPercent")
 	}
 
 	return output, nil
 }
 
-func (handler *ConfigRESTHandler) RenameConfigSet(c *gin.Context) (domain.ConfigSet, error) {
+func (handler *ConfigRESTHandler) PromoteConfigSetCanary(c *gin.Context) (domain.ConfigSet, error) {
 	name, ok := c.Params.Get("name")
 
 	if !ok {
 		return domain.ConfigSet{}, domain.ErrMissingParam("name")
 	}
 
-	jsonData, err := ioutil.ReadAll(c.Request.Body)
+	output, err := handler.serviceFor(c).PromoteCanary(name)
 	if err != nil {
-		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+		return domain.ConfigSet{}, mapServiceError(err, name, "PromoteConfigSetCanary")
 	}
-	var body renameBody
-	err = json.Unmarshal(jsonData, &body)
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) AbortConfigSetCanary(c *gin.Context) (domain.Canary, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.Canary{}, domain.ErrMissingParam("name")
+	}
+
+	output, err := handler.serviceFor(c).AbortCanary(name)
 	if err != nil {
-		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+		return domain.Canary{}, mapServiceError(err, name, "AbortConfigSetCanary")
 	}
 
-	if body.Name == "" || body.Name == name {
-		return domain.ConfigSet{}, domain.ErrBadRequest("invalid new name")
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) GetScheduledConfigChanges(c *gin.Context) ([]domain.ScheduledChange, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return nil, domain.ErrMissingParam("name")
 	}
 
-	output, err := handler.service.RenameSet(name, body.Name)
+	output, err := handler.service.GetScheduledChanges(name)
 	if err != nil {
-		if err == ports.ErrConfigNotExists {
-			return domain.ConfigSet{}, domain.ErrNotFound(name)
-		}
+		return nil, mapServiceError(err, name, "GetScheduledConfigChanges")
+	}
 
-		log.Error().Stack().Err(err).Msg("GetConfigSet error")
-		return domain.ConfigSet{}, &domain.ErrInternalError
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) GetFailedConfigChanges(c *gin.Context) ([]domain.FailedChange, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return nil, domain.ErrMissingParam("name")
+	}
+
+	output, err := handler.service.GetFailedChanges(name)
+	if err != nil {
+		return nil, mapServiceError(err, name, "GetFailedConfigChanges")
 	}
 
 	return output, nil
 }
 
-func (handler *ConfigRESTHandler) GetConfigSet(c *gin.Context) (domain.ConfigSet, error) {
+func (handler *ConfigRESTHandler) ScheduleConfigChange(c *gin.Context) (domain.ScheduledChange, error) {
 	name, ok := c.Params.Get("name")
 
 	if !ok {
-		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+		return domain.ScheduledChange{}, domain.ErrMissingParam("name")
 	}
 
-	output, err := handler.service.GetSet(name)
+	jsonData, err := ioutil.ReadAll(c.Request.Body)
 	if err != nil {
-		if err == ports.ErrConfigNotExists {
-			return domain.ConfigSet{}, domain.ErrNotFound(name)
-		}
+		return domain.ScheduledChange{}, domain.ErrBadRequest("invalid body")
+	}
+	var body domain.ScheduledChange
+	err = domain.DecodeJSON(jsonData, &body)
+	if err != nil {
+		return domain.ScheduledChange{}, domain.ErrBadRequest("invalid body")
+	}
+
+	if body.EffectiveAt.IsZero() {
+		return domain.ScheduledChange{}, domain.ErrMissingParam("effectiveAt")
+	}
 
-		log.Error().Stack().Err(err).Msg("GetConfigSet error")
-		return domain.ConfigSet{}, &domain.ErrInternalError
+	body.Set = name
+	output, err := handler.serviceFor(c).ScheduleChange(body)
+	if err != nil {
+		return domain.ScheduledChange{}, mapServiceError(err, name, "ScheduleConfigChange")
 	}
 
 	return output, nil
 }
 
-func (handler *ConfigRESTHandler) DeleteConfigSet(c *gin.Context) (domain.ConfigSet, error) {
+func (handler *ConfigRESTHandler) CancelScheduledConfigChange(c *gin.Context) (domain.ScheduledChange, error) {
 	name, ok := c.Params.Get("name")
 
 	if !ok {
-		return domain.ConfigSet{}, domain.ErrMissingParam("name")
+		return domain.ScheduledChange{}, domain.ErrMissingParam("name")
+	}
+
+	id, ok := c.Params.Get("id")
+
+	if !ok {
+		return domain.ScheduledChange{}, domain.ErrMissingParam("id")
 	}
 
-	output, err := handler.service.DeleteSet(name)
+	output, err := handler.serviceFor(c).CancelScheduledChange(name, id)
 	if err != nil {
-		if err == ports.ErrConfigNotExists {
-			return domain.ConfigSet{}, domain.ErrNotFound(name)
+		if err == ports.ErrChangeNotExists {
+			return domain.ScheduledChange{}, domain.ErrNotFound(id)
 		}
 
-		log.Error().Stack().Err(err).Msg("GetConfigSet error")
-		return domain.ConfigSet{}, &domain.ErrInternalError
+		return domain.ScheduledChange{}, mapServiceError(err, name, "CancelScheduledConfigChange")
 	}
 
 	return output, nil
 }
 
-func (handler *ConfigRESTHandler) AddConfigItem(c *gin.Context) (domain.ConfigSet, error) {
+func (handler *ConfigRESTHandler) FreezeConfigSet(c *gin.Context) (domain.ConfigSet, error) {
 	name, ok := c.Params.Get("name")
 
 	if !ok {
@@ -270,76 +1288,119 @@ func (handler *ConfigRESTHandler) AddConfigItem(c *gin.Context) (domain.ConfigSe
 	if err != nil {
 		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
 	}
-	var body domain.ConfigItem
-	err = json.Unmarshal(jsonData, &body)
-	if err != nil {
-		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
-	}
 
-	output, err := handler.service.AddItem(body, name)
-	if err != nil {
-		if err == domain.ErrDuplicatedKey {
-			return domain.ConfigSet{}, domain.ErrBadRequest(err.Error())
+	var body freezeBody
+	if len(jsonData) > 0 {
+		err = domain.DecodeJSON(jsonData, &body)
+		if err != nil {
+			return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
 		}
+	}
 
-		log.Error().Stack().Err(err).Msg("GetConfigSet error")
-		return domain.ConfigSet{}, &domain.ErrInternalError
+	output, err := handler.serviceFor(c).FreezeSet(name, body.Reason, body.Until)
+	if err != nil {
+		return domain.ConfigSet{}, mapServiceError(err, name, "FreezeConfigSet")
 	}
 
 	return output, nil
 }
 
-func (handler *ConfigRESTHandler) UpdateConfigItem(c *gin.Context) (domain.ConfigSet, error) {
+func (handler *ConfigRESTHandler) UnfreezeConfigSet(c *gin.Context) (domain.ConfigSet, error) {
 	name, ok := c.Params.Get("name")
 
 	if !ok {
 		return domain.ConfigSet{}, domain.ErrMissingParam("name")
 	}
 
-	jsonData, err := ioutil.ReadAll(c.Request.Body)
+	output, err := handler.serviceFor(c).UnfreezeSet(name)
 	if err != nil {
-		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+		return domain.ConfigSet{}, mapServiceError(err, name, "UnfreezeConfigSet")
 	}
-	var body domain.ConfigItem
-	err = json.Unmarshal(jsonData, &body)
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) GetTrashedConfigSets(c *gin.Context) ([]domain.TrashedSet, error) {
+	limit, skip, err := pageParams(c)
 	if err != nil {
-		return domain.ConfigSet{}, domain.ErrBadRequest("invalid body")
+		return nil, err
 	}
 
-	output, err := handler.service.UpdateItem(body, name)
+	output, err := handler.service.GetTrashedSets(limit, skip)
 	if err != nil {
-		if err == domain.ErrKeyNotExists {
-			return domain.ConfigSet{}, domain.ErrNotFound(body.Key)
-		}
-
-		log.Error().Stack().Err(err).Msg("GetConfigSet error")
-		return domain.ConfigSet{}, &domain.ErrInternalError
+		return nil, mapServiceError(err, "", "GetTrashedConfigSets")
 	}
 
 	return output, nil
 }
 
-func (handler *ConfigRESTHandler) DeleteConfigItem(c *gin.Context) (domain.ConfigSet, error) {
+func (handler *ConfigRESTHandler) RestoreConfigSet(c *gin.Context) (domain.ConfigSet, error) {
 	name, ok := c.Params.Get("name")
 
 	if !ok {
 		return domain.ConfigSet{}, domain.ErrMissingParam("name")
 	}
 
-	key, ok := c.Params.Get("key")
+	output, err := handler.serviceFor(c).RestoreSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, mapServiceError(err, name, "RestoreConfigSet")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) PurgeConfigSet(c *gin.Context) (domain.TrashedSet, error) {
+	name, ok := c.Params.Get("name")
 
 	if !ok {
-		return domain.ConfigSet{}, domain.ErrMissingParam("key")
+		return domain.TrashedSet{}, domain.ErrMissingParam("name")
 	}
 
-	output, err := handler.service.RemoveItem(*domain.NewConfigItem(key, "", domain.Plain), name)
+	output, err := handler.serviceFor(c).PurgeSet(name)
 	if err != nil {
-		if err == domain.ErrKeyNotExists {
-			return domain.ConfigSet{}, domain.ErrNotFound(key)
-		}
+		return domain.TrashedSet{}, mapServiceError(err, name, "PurgeConfigSet")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) GetConfigOverrides(c *gin.Context) ([]domain.Override, error) {
+	output, err := handler.service.GetOverrides()
+	if err != nil {
+		return nil, mapServiceError(err, "", "GetConfigOverrides")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) ResealConfigSets(c *gin.Context) ([]string, error) {
+	output, err := handler.serviceFor(c).ResealSets()
+	if err != nil {
+		return nil, mapServiceError(err, "", "ResealConfigSets")
+	}
+
+	return output, nil
+}
 
-		log.Error().Stack().Err(err).Msg("GetConfigSet error")
-		return domain.ConfigSet{}, &domain.ErrInternalError
+func (handler *ConfigRESTHandler) GetGraph(c *gin.Context) (domain.ConfigGraph, error) {
+	output, err := handler.service.GetGraph()
+	if err != nil {
+		return domain.ConfigGraph{}, mapServiceError(err, "", "GetGraph")
+	}
+
+	return output, nil
+}
+
+func (handler *ConfigRESTHandler) GetConfigSetGraph(c *gin.Context) (domain.ConfigGraph, error) {
+	name, ok := c.Params.Get("name")
+
+	if !ok {
+		return domain.ConfigGraph{}, domain.ErrMissingParam("name")
+	}
+
+	output, err := handler.service.GetSetGraph(name)
+	if err != nil {
+		return domain.ConfigGraph{}, mapServiceError(err, name, "GetConfigSetGraph")
 	}
 
 	return output, nil
@@ -349,9 +1410,13 @@ func (handler *ConfigRESTHandler) DeleteConfigItem(c *gin.Context) (domain.Confi
 var getConfigJSONReqGroup singleflight.Group
 
 func (handler *ConfigRESTHandler) getConfigJSONSingleFlight(c *gin.Context) ([]byte, error) {
+	// Callers with different attributes get different renders of the same path
 	fp := fullPath(c)
+	if attrs, err := requestAttributes(c); err == nil && !attrs.Empty() {
+		fp = fp + "#" + attrs.String()
+	}
 	ch := getConfigJSONReqGroup.DoChan(fp, func() (interface{}, error) {
-		return handler.GetConfigJSON(c)
+		return handler.renderConfigJSON(c)
 	})
 
 	// Create our timeout
@@ -370,7 +1435,10 @@ func (handler *ConfigRESTHandler) getConfigJSONSingleFlight(c *gin.Context) ([]b
 		return nil, result.Err
 	}
 
-	return result.Val.([]byte), nil
+	rendered := result.Val.(renderedConfig)
+	writeConfigVersion(c, rendered.version)
+	writeDeprecationWarning(c, rendered.deprecations)
+	return rendered.data, nil
 }
 
 // Utils
@@ -386,6 +1454,236 @@ func handleError(err error, c *gin.Context) {
 	c.JSON(http.StatusInternalServerError, gin.H{"error": "internal server error"})
 }
 
+// readCanaryBody reads a canary request body, the percent is required
+func readCanaryBody(c *gin.Context) (canaryBody, error) {
+	var body canaryBody
+	jsonData, err := ioutil.ReadAll(c.Request.Body)
+	if err != nil {
+		return body, domain.ErrBadRequest("invalid body")
+	}
+
+	err = domain.DecodeJSON(jsonData, &body)
+	if err != nil {
+		return body, domain.ErrBadRequest("invalid body")
+	}
+
+	if body.Percent == nil {
+		return body, domain.ErrMissingParam("percent")
+	}
+
+	return body, nil
+}
+
+// mapServiceError converts an error returned by the service into the error sent to the client
+// name is the set the request is about, unknown errors are logged with the operation and hidden
+func mapServiceError(err error, name string, operation string) error {
+	switch err {
+	case ports.ErrConfigNotExists, ports.ErrTrashNotExists:
+		return domain.ErrNotFound(name)
+	case ports.ErrTrashExists:
+		return domain.ErrTrashConflict(name)
+	case ports.ErrCanaryNotExists:
+		return domain.ErrNotFound(name + " canary")
+	case ports.ErrDuplicatedConfig,
+		domain.ErrDuplicatedKey,
+		domain.ErrDeletedRevision,
+		domain.ErrInvalidSchema,
+		domain.ErrInvalidLabel,
+		domain.ErrNotGenerated,
+		domain.ErrInvalidCanaryPercent,
+		domain.ErrInvalidChangeOperation,
+		domain.ErrMissingChangeKey,
+		domain.ErrEffectiveAtInPast,
+		domain.ErrExpiresAtInPast,
+		domain.ErrRevertNotCancellable,
+		domain.ErrUntilInPast,
+		domain.ErrRefKeyValue,
+		domain.ErrInvalidGenerator,
+		domain.ErrMissingGenerator,
+		domain.ErrSealedKeyValue,
+		domain.ErrSealingDisabled,
+		domain.ErrSecretKeyValue,
+		domain.ErrVariantsNotSupported,
+		domain.ErrInvalidVariant,
+		domain.ErrInvalidReplacement:
+		return domain.ErrBadRequest(err.Error())
+	}
+
+	switch typedErr := err.(type) {
+	case *domain.PathNotFoundError:
+		return domain.ErrNotFound(typedErr.Segment())
+	case *domain.FrozenError:
+		return domain.ErrConfigFrozen(typedErr)
+	case *domain.CycleError:
+		return domain.ErrConfigCycle(typedErr)
+	case *domain.DepthError:
+		return domain.ErrNestingTooDeep(typedErr)
+	case *domain.ReferenceError:
+		return domain.ErrDanglingReference(typedErr)
+	case *domain.ReferencedError:
+		return domain.ErrSetReferenced(typedErr)
+	case *domain.ComputedError:
+		return domain.ErrInvalidExpression(typedErr)
+	case *domain.ValueTypeError:
+		return domain.ErrBadRequest(typedErr.Error())
+	case *domain.ValidationError:
+		return domain.ErrInvalidConfig(typedErr)
+	}
+
+	log.Error().Stack().Err(err).Msg(operation + " error")
+	return &domain.ErrInternalError
+}
+
+// Available graph output formats
+const (
+	jsonGraphFormat = "json"
+	dotGraphFormat  = "dot"
+)
+
+// graphFormat reads the format query param of graph endpoints, JSON by default
+func graphFormat(c *gin.Context) (string, error) {
+	format := c.DefaultQuery("format", jsonGraphFormat)
+	if format != jsonGraphFormat && format != dotGraphFormat {
+		return "", domain.InvalidParam("format")
+	}
+
+	return format, nil
+}
+
+// writeGraph sends the graph in the requested format
+func writeGraph(c *gin.Context, graph domain.ConfigGraph, format string) {
+	if format == dotGraphFormat {
+		c.Data(http.StatusOK, "text/vnd.graphviz; charset=utf-8", []byte(graph.DOT()))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": graph})
+}
+
+// pageParams reads the limit and skip query params used for pagination
+func pageParams(c *gin.Context) (int, int, error) {
+	limit := defaultPageSize
+	if limitQuery := c.Query("limit"); limitQuery != "" {
+		var err error
+		limit, err = strconv.Atoi(limitQuery)
+
+		if err != nil || limit <= 0 {
+			return 0, 0, domain.InvalidParam("limit")
+		}
+	}
+
+	skip := 0
+	if skipQuery := c.Query("skip"); skipQuery != "" {
+		var err error
+		skip, err = strconv.Atoi(skipQuery)
+
+		if err != nil || skip < 0 {
+			return 0, 0, domain.InvalidParam("skip")
+		}
+	}
+
+	return limit, skip, nil
+}
+
+// boolParam reads an optional boolean query param, missing params are false
+func boolParam(c *gin.Context, param string) (bool, error) {
+	query := c.Query(param)
+	if query == "" {
+		return false, nil
+	}
+
+	value, err := strconv.ParseBool(query)
+	if err != nil {
+		return false, domain.InvalidParam(param)
+	}
+
+	return value, nil
+}
+
+// revisionParam reads the revision number from the path params
+func revisionParam(c *gin.Context) (int, error) {
+	revisionStr, ok := c.Params.Get("revision")
+	if !ok {
+		return 0, domain.ErrMissingParam("revision")
+	}
+
+	revision, err := strconv.Atoi(revisionStr)
+	if err != nil || revision <= 0 {
+		return 0, domain.InvalidParam("revision")
+	}
+
+	return revision, nil
+}
+
+// expiryParam reads when a temporary item override expires, either as a ttl duration or an expiresAt time
+// The second value is false if the request has neither
+func expiryParam(c *gin.Context) (time.Time, bool, error) {
+	ttlQuery := c.Query("ttl")
+	expiresAtQuery := c.Query("expiresAt")
+	if ttlQuery != "" && expiresAtQuery != "" {
+		return time.Time{}, false, domain.ErrBadRequest("use either ttl or expiresAt")
+	}
+
+	if ttlQuery != "" {
+		ttl, err := time.ParseDuration(ttlQuery)
+		if err != nil || ttl <= 0 {
+			return time.Time{}, false, domain.InvalidParam("ttl")
+		}
+
+		return time.Now().UTC().Add(ttl), true, nil
+	}
+
+	if expiresAtQuery != "" {
+		expiresAt, err := time.Parse(time.RFC3339, expiresAtQuery)
+		if err != nil {
+			return time.Time{}, false, domain.InvalidParam("expiresAt")
+		}
+
+		return expiresAt, true, nil
+	}
+
+	return time.Time{}, false, nil
+}
+
+// requestAttributes reads the caller attributes used to pick item variants from the request headers
+// Labels are sent as a comma separated list of key=value pairs
+func requestAttributes(c *gin.Context) (domain.RequestAttributes, error) {
+	attrs := domain.RequestAttributes{
+		Client:   c.GetHeader(clientNameHeader),
+		Region:   c.GetHeader(clientRegionHeader),
+		Version:  c.GetHeader(clientVersionHeader),
+		ClientID: c.GetHeader(clientIDHeader),
+	}
+
+	if labels := c.GetHeader(clientLabelsHeader); labels != "" {
+		attrs.Labels = map[string]string{}
+		for _, pair := range strings.Split(labels, ",") {
+			parts := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+			if len(parts) != 2 || parts[0] == "" {
+				return attrs, domain.InvalidParam(clientLabelsHeader)
+			}
+
+			attrs.Labels[parts[0]] = parts[1]
+		}
+	}
+
+	return attrs, nil
+}
+
+// writeConfigVersion tells the client which version of the set it got, renders of the past have no version
+func writeConfigVersion(c *gin.Context, version domain.ConfigVersion) {
+	if version != "" {
+		c.Header(configVersionHeader, string(version))
+	}
+}
+
+// writeDeprecationWarning lists the deprecated items of a rendered set in a Warning header
+func writeDeprecationWarning(c *gin.Context, deprecations []domain.Deprecation) {
+	if len(deprecations) > 0 {
+		c.Header(warningHeader, domain.DeprecationWarning(deprecations))
+	}
+}
+
 func fullPath(c *gin.Context) string {
 	fullPath := c.Request.URL.Path
 	raw := c.Request.URL.RawQuery
@@ -2,12 +2,15 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/go-cmp/cmp"
@@ -47,7 +50,7 @@ func TestGetConfigJSON(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -78,10 +81,10 @@ func TestGetConfigJSON(t *testing.T) {
 			if maxAge != expectedMaxAge {
 				t.Errorf("Expected max age: %d, got: %d", expectedMaxAge, maxAge)
 			}
-			return []byte("{}"), nil
+			return []byte(`{"format":1,"values":{"cached":"value"}}`), nil
 		}
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -93,7 +96,7 @@ func TestGetConfigJSON(t *testing.T) {
 			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
 		}
 
-		expected := `{"data":{}}`
+		expected := `{"data":{"cached":"value"}}`
 		if got.Body.String() != expected {
 			t.Errorf("Expected config: %v got: %v", expected, got.Body.String())
 		}
@@ -103,6 +106,44 @@ func TestGetConfigJSON(t *testing.T) {
 		}
 	})
 
+	t.Run("Test getting a config json at a point in time", func(t *testing.T) {
+		router := gin.New()
+		name := "myConfig"
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet(name)
+		service.AddItem(*domain.NewConfigItem("myKey", "value", domain.Plain), name)
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		at := time.Now().Add(time.Hour).Format(time.RFC3339)
+		got := performRequest(router, "GET", "/api/config/"+name+"?at="+url.QueryEscape(at), nil)
+
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		expected := `{"data":{"myKey":"value"}}`
+		if got.Body.String() != expected {
+			t.Errorf("Expected config: %v got: %v", expected, got.Body.String())
+		}
+
+		at = time.Now().Add(-time.Hour).Format(time.RFC3339)
+		got = performRequest(router, "GET", "/api/config/"+name+"?at="+url.QueryEscape(at), nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/config/"+name+"?at=yesterday", nil)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+	})
+
 	t.Run("Test getting a non existing config json", func(t *testing.T) {
 		router := gin.New()
 		name := "myConfig"
@@ -110,7 +151,7 @@ func TestGetConfigJSON(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
 		handler.CreateRoutes(router)
@@ -136,7 +177,7 @@ func TestGetConfig(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		set, _ := service.GetSet(name)
@@ -163,7 +204,7 @@ func TestGetConfig(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
 		handler.CreateRoutes(router)
@@ -189,7 +230,7 @@ func TestCreateConfig(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
 		handler.CreateRoutes(router)
@@ -220,7 +261,7 @@ func TestCreateConfig(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -247,7 +288,7 @@ func TestRenameConfig(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -281,7 +322,7 @@ func TestRenameConfig(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
 		handler.CreateRoutes(router)
@@ -309,7 +350,7 @@ func TestDeleteConfig(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -334,7 +375,7 @@ func TestDeleteConfig(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
 		handler.CreateRoutes(router)
@@ -354,7 +395,7 @@ func TestAddConfigItem(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -394,7 +435,7 @@ func TestAddConfigItem(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -425,7 +466,7 @@ func TestUpdateConfigItem(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -470,7 +511,7 @@ func TestUpdateConfigItem(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -515,7 +556,7 @@ func TestDeleteConfigItem(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -553,7 +594,7 @@ func TestDeleteConfigItem(t *testing.T) {
 		mockRepo := mocks.NewMockRepo()
 		cacheRepo := mocks.NewMockRepo()
 		mockSecret := mocks.MockSecrets{}
-		service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 		service.CreateSet(name)
 		handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -571,6 +612,1590 @@ func TestDeleteConfigItem(t *testing.T) {
 	})
 }
 
+func TestConfigSetRevisions(t *testing.T) {
+	t.Run("Test listing config set revisions", func(t *testing.T) {
+		router := gin.New()
+		name := "myConfig"
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet(name)
+		service.AddItem(*domain.NewConfigItem("myKey", float64(100), domain.Plain), name)
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/configset/"+name+"/revisions?limit=1", nil)
+
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		var gotParsed struct {
+			Data []domain.ConfigRevision
+		}
+		err := json.Unmarshal(got.Body.Bytes(), &gotParsed)
+		if err != nil {
+			t.Errorf("Expected valid json got error: %v", err)
+		}
+
+		if len(gotParsed.Data) != 1 || gotParsed.Data[0].Revision != 2 {
+			t.Errorf("Expected only revision 2, got: %+v", gotParsed.Data)
+		}
+	})
+
+	t.Run("Test getting a config set revision", func(t *testing.T) {
+		router := gin.New()
+		name := "myConfig"
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet(name)
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/configset/"+name+"/revisions/1", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/configset/"+name+"/revisions/2", nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/configset/"+name+"/revisions/latest", nil)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+	})
+
+	t.Run("Test rolling back a config set", func(t *testing.T) {
+		router := gin.New()
+		name := "myConfig"
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet(name)
+		service.AddItem(*domain.NewConfigItem("myKey", float64(100), domain.Plain), name)
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "POST", "/api/configset/"+name+"/revisions/1/rollback", nil)
+
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		var gotParsed struct {
+			Data domain.ConfigSet
+		}
+		err := json.Unmarshal(got.Body.Bytes(), &gotParsed)
+		if err != nil {
+			t.Errorf("Expected valid json got error: %v", err)
+		}
+
+		if len(gotParsed.Data.Items) != 0 {
+			t.Errorf("Expected no items after rollback, got: %+v", gotParsed.Data.Items)
+		}
+	})
+
+	t.Run("Test rolling back a non existing config set", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "POST", "/api/configset/notExists/revisions/1/rollback", nil)
+
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+	})
+}
+
+func TestDiffConfigSet(t *testing.T) {
+	t.Run("Test comparing two config sets", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("dev")
+		service.AddItem(*domain.NewConfigItem("myKey", "value", domain.Plain), "dev")
+		service.CreateSet("prod")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/configset/dev/diff?against=prod", nil)
+
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		var gotParsed struct {
+			Data domain.ConfigDiff
+		}
+		err := json.Unmarshal(got.Body.Bytes(), &gotParsed)
+		if err != nil {
+			t.Errorf("Expected valid json got error: %v", err)
+		}
+
+		if len(gotParsed.Data.Added) != 1 || gotParsed.Data.Added[0].Key != "myKey" {
+			t.Errorf("Expected myKey to be added, got: %+v", gotParsed.Data)
+		}
+	})
+
+	t.Run("Test comparing without a target", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("dev")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/configset/dev/diff", nil)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/configset/dev/diff?against=prod", nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+	})
+}
+
+func TestConfigSetBase(t *testing.T) {
+	t.Run("Test setting the base of a config set", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("base")
+		service.AddItem(*domain.NewConfigItem("myKey", "base", domain.Plain), "base")
+		service.CreateSet("overlay")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "base": "base" }`
+		got := performRequest(router, "PUT", "/api/configset/overlay/base", &body)
+
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/config/overlay", nil)
+		expected := `{"data":{"myKey":"base"}}`
+		if got.Body.String() != expected {
+			t.Errorf("Expected config: %v got: %v", expected, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/configset/overlay/origins", nil)
+		expected = `{"data":{"myKey":"base"}}`
+		if got.Body.String() != expected {
+			t.Errorf("Expected origins: %v got: %v", expected, got.Body.String())
+		}
+
+		got = performRequest(router, "DELETE", "/api/configset/overlay/base", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+	})
+
+	t.Run("Test setting a base that creates a cycle", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("a")
+		service.CreateSet("b")
+		service.SetBase("b", "a")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "base": "b" }`
+		got := performRequest(router, "PUT", "/api/configset/a/base", &body)
+
+		if got.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusUnprocessableEntity, got.Code)
+		}
+
+		expected := fmt.Sprint(domain.ConfigCycle)
+		if !strings.Contains(got.Body.String(), expected) {
+			t.Errorf("Expected response to contain: %s got: %v", expected, got.Body.String())
+		}
+	})
+}
+
+func TestConfigSetSchema(t *testing.T) {
+	schema := `{ "type": "object", "properties": { "port": { "type": "integer" } }, "required": ["port"] }`
+	t.Run("Test invalid items are rejected with every violation", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("server")
+		service.AddItem(*domain.NewConfigItem("port", 80, domain.Plain), "server")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "PUT", "/api/configset/server/schema", &schema)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		body := `{ "key": "port", "value": "80", "type": "plain" }`
+		got = performRequest(router, "PATCH", "/api/configset/server/item", &body)
+		if got.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusUnprocessableEntity, got.Code)
+		}
+
+		var response struct {
+			Error domain.RestError `json:"error"`
+		}
+		json.Unmarshal(got.Body.Bytes(), &response)
+
+		expected := []string{"port: Invalid type. Expected: integer, given: string"}
+		if response.Error.Code != domain.InvalidConfig || !cmp.Equal(response.Error.Details, expected) {
+			t.Errorf("Expected error code: %d with details: %+v, got: %+v", domain.InvalidConfig, expected, response.Error)
+		}
+
+		got = performRequest(router, "DELETE", "/api/configset/server/schema", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		got = performRequest(router, "PATCH", "/api/configset/server/item", &body)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+	})
+
+	t.Run("Test an invalid schema is rejected", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("server")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "type": 10 }`
+		got := performRequest(router, "PUT", "/api/configset/server/schema", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		got = performRequest(router, "PUT", "/api/configset/missing/schema", &schema)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+	})
+
+	t.Run("Test existing sets are validated after a schema change", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("server")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		performRequest(router, "PUT", "/api/configset/server/schema", &schema)
+		got := performRequest(router, "GET", "/api/validate", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		expected := `{"data":[{"name":"server","schemaSet":"server","valid":false,"violations":["(root): port is required"]}]}`
+		if got.Body.String() != expected {
+			t.Errorf("Expected validation: %v got: %v", expected, got.Body.String())
+		}
+	})
+}
+
+func TestTypedConfigItem(t *testing.T) {
+	t.Run("Test typed items keep large numbers and reject invalid values", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("server")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "key": "id", "value": 9007199254740993, "type": "plain", "valueType": "int" }`
+		got := performRequest(router, "POST", "/api/configset/server/item", &body)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		body = `{ "key": "enabled", "value": "yes", "type": "plain", "valueType": "bool" }`
+		got = performRequest(router, "POST", "/api/configset/server/item", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/config/server", nil)
+		expected := `{"data":{"id":9007199254740993}}`
+		if got.Body.String() != expected {
+			t.Errorf("Expected config: %v got: %v", expected, got.Body.String())
+		}
+	})
+}
+
+func TestGetConfigValue(t *testing.T) {
+	t.Run("Test a value can be read by path", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("pool")
+		service.AddItem(*domain.NewConfigItem("max", 10, domain.Plain), "pool")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("pool", "pool", domain.Nested), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/config/app/pool/max", nil)
+		expected := `{"data":10}`
+		if got.Code != http.StatusOK || got.Body.String() != expected {
+			t.Errorf("Expected status: %d and body: %v got: %d and %v", http.StatusOK, expected, got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/config/app/pool/min", nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+
+		expected = `resource not found: \"min\"`
+		if !strings.Contains(got.Body.String(), expected) {
+			t.Errorf("Expected response to contain: %s got: %v", expected, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/config/missing/pool", nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+	})
+
+	t.Run("Test path reads pick variants and the canary", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("pool")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("pool", "pool", domain.Nested), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "key": "max", "value": 10, "type": "plain", "variants": [{ "when": { "regions": ["eu"] }, "value": 20 }] }`
+		got := performRequest(router, "POST", "/api/configset/pool/item", &body)
+		if got.Code != http.StatusOK {
+			t.Fatalf("Expected status code: %d, got: %d %s", http.StatusOK, got.Code, got.Body.String())
+		}
+
+		body = `{ "key": "db", "value": { "host": "primary" }, "type": "plain", "variants": [{ "when": { "regions": ["eu"] }, "value": { "host": "replica" } }] }`
+		got = performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusOK {
+			t.Fatalf("Expected status code: %d, got: %d %s", http.StatusOK, got.Code, got.Body.String())
+		}
+
+		getValue := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("GET", path, nil)
+			for header, value := range headers {
+				req.Header.Set(header, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		cases := []struct {
+			path     string
+			headers  map[string]string
+			expected string
+		}{
+			{"/api/config/app/pool/max", map[string]string{}, `{"data":10}`},
+			{"/api/config/app/pool/max", map[string]string{"X-Client-Region": "eu"}, `{"data":20}`},
+			{"/api/config/app/db/host", map[string]string{}, `{"data":"primary"}`},
+			{"/api/config/app/db/host", map[string]string{"X-Client-Region": "eu"}, `{"data":"replica"}`},
+		}
+
+		for _, c := range cases {
+			w := getValue(c.path, c.headers)
+			if w.Code != http.StatusOK || w.Body.String() != c.expected {
+				t.Errorf("Expected response: %s for %s %v, got: %d %v", c.expected, c.path, c.headers, w.Code, w.Body.String())
+			}
+		}
+
+		w := getValue("/api/config/app/db/host", map[string]string{"X-Client-Labels": "tier"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, w.Code)
+		}
+
+		body = `{ "items": { "db": { "value": { "host": "canary" }, "type": "plain" } }, "percent": 100 }`
+		got = performRequest(router, "PUT", "/api/configset/app/canary", &body)
+		if got.Code != http.StatusOK {
+			t.Fatalf("Expected status code: %d, got: %d %s", http.StatusOK, got.Code, got.Body.String())
+		}
+
+		w = getValue("/api/config/app/db/host", map[string]string{"X-Client-ID": "client-1"})
+		if w.Header().Get("X-Config-Version") != "canary" || w.Body.String() != `{"data":"canary"}` {
+			t.Errorf("Expected canary value, got %s: %s", w.Header().Get("X-Config-Version"), w.Body.String())
+		}
+	})
+}
+
+func TestConfigReferences(t *testing.T) {
+	t.Run("Test references are resolved and dangling ones reported", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "app")
+		service.AddItem(*domain.NewConfigItem("url", "http://${self:host}", domain.Plain), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/config/app/url", nil)
+		expected := `{"data":"http://localhost"}`
+		if got.Body.String() != expected {
+			t.Errorf("Expected config: %v got: %v", expected, got.Body.String())
+		}
+
+		got = performRequest(router, "DELETE", "/api/configset/app/item/host", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/config/app", nil)
+		if got.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusUnprocessableEntity, got.Code)
+		}
+
+		expected = `app:url -\u003e app:host`
+		if !strings.Contains(got.Body.String(), expected) {
+			t.Errorf("Expected response to contain: %s got: %v", expected, got.Body.String())
+		}
+	})
+}
+
+func TestInlineSecrets(t *testing.T) {
+	t.Run("Test inline secrets are only resolved in the rendered config", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{
+			Values: map[string]string{"db/password": "s3cr3t"},
+		}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("dsn", "postgres://app:${secret:db/password}@db", domain.Plain), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/config/app", nil)
+		expected := `{"data":{"dsn":"postgres://app:s3cr3t@db"}}`
+		if got.Body.String() != expected {
+			t.Errorf("Expected config: %v got: %v", expected, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/configset/app", nil)
+		if strings.Contains(got.Body.String(), "s3cr3t") {
+			t.Errorf("Expected config set to keep the placeholder, got: %v", got.Body.String())
+		}
+	})
+}
+
+func TestNestedCycle(t *testing.T) {
+	t.Run("Test a nested item creating a cycle is rejected", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("a")
+		service.CreateSet("b")
+		service.AddItem(*domain.NewConfigItem("b", "b", domain.Nested), "a")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "key": "a", "value": "a", "type": "nested" }`
+		got := performRequest(router, "POST", "/api/configset/b/item", &body)
+		if got.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusUnprocessableEntity, got.Code)
+		}
+
+		expected := `b -\u003e a -\u003e b`
+		if !strings.Contains(got.Body.String(), expected) {
+			t.Errorf("Expected response to contain: %s got: %v", expected, got.Body.String())
+		}
+	})
+}
+
+func TestConfigSetReferrers(t *testing.T) {
+	t.Run("Test deleting a referenced set is rejected unless forced", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "DELETE", "/api/configset/db", nil)
+		if got.Code != http.StatusConflict {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusConflict, got.Code)
+		}
+
+		expected := `"details":["app"]`
+		if !strings.Contains(got.Body.String(), expected) {
+			t.Errorf("Expected response to contain: %s got: %v", expected, got.Body.String())
+		}
+
+		got = performRequest(router, "DELETE", "/api/configset/db?force=invalid", nil)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		got = performRequest(router, "DELETE", "/api/configset/db?force=true", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+	})
+
+	t.Run("Test renaming a referenced set with rewrite", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "name": "postgres" }`
+		got := performRequest(router, "POST", "/api/configset/db", &body)
+		if got.Code != http.StatusConflict {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusConflict, got.Code)
+		}
+
+		got = performRequest(router, "POST", "/api/configset/db?rewrite=true", &body)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/configset/postgres/referrers", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		expected := `{"data":[{"name":"app","keys":["database"]}]}`
+		if got.Body.String() != expected {
+			t.Errorf("Expected response: %s got: %v", expected, got.Body.String())
+		}
+	})
+}
+
+func TestGraph(t *testing.T) {
+	t.Run("Test graph in JSON and DOT formats", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("database", "db", domain.Nested), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/graph", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		expected := `"edges":[{"from":"set:app","to":"set:db","kind":"nested","key":"database"}]`
+		if !strings.Contains(got.Body.String(), expected) {
+			t.Errorf("Expected response to contain: %s got: %v", expected, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/configset/db/graph?format=dot", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		expected = `"set:app" -> "set:db" [label="database"];`
+		if !strings.Contains(got.Body.String(), expected) {
+			t.Errorf("Expected response to contain: %s got: %v", expected, got.Body.String())
+		}
+
+		if !strings.HasPrefix(got.Header().Get("Content-Type"), "text/vnd.graphviz") {
+			t.Errorf("Expected DOT content type, got: %s", got.Header().Get("Content-Type"))
+		}
+	})
+
+	t.Run("Test graph with invalid params", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/graph?format=svg", nil)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/configset/notExists/graph", nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+	})
+}
+
+func TestRefConfigItem(t *testing.T) {
+	t.Run("Test adding ref items", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db")
+		service.CreateSet("app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "key": "host", "value": "db", "type": "ref" }`
+		got := performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		body = `{ "key": "host", "value": "db:port", "type": "ref" }`
+		got = performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusUnprocessableEntity, got.Code)
+		}
+
+		body = `{ "key": "host", "value": "db:host", "type": "ref" }`
+		got = performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/config/app", nil)
+		expected := `{"data":{"host":"localhost"}}`
+		if got.Body.String() != expected {
+			t.Errorf("Expected response: %s got: %v", expected, got.Body.String())
+		}
+	})
+}
+
+func TestGeneratedConfigItem(t *testing.T) {
+	t.Run("Test adding and regenerating generated items", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "key": "token", "type": "generated", "generator": { "kind": "alphanumeric" } }`
+		got := performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		body = `{ "key": "token", "type": "generated", "generator": { "kind": "uuid" } }`
+		got = performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		set, _ := service.GetSet("app")
+		token := set.Items["token"].Value
+
+		got = performRequest(router, "POST", "/api/configset/app/item/token/regenerate", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		set, _ = service.GetSet("app")
+		if set.Items["token"].Value == token {
+			t.Errorf("Expected a new value, got the same: %v", token)
+		}
+
+		got = performRequest(router, "POST", "/api/configset/app/item/missing/regenerate", nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+	})
+}
+
+func TestSealedConfigItem(t *testing.T) {
+	t.Run("Test sealed values are only shown in clear when rendered", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		config.SealKeys = map[string]string{"k1": "MDEyMzQ1Njc4OWFiY2RlZjAxMjM0NTY3ODlhYmNkZWY="}
+		config.ActiveSealKey = "k1"
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "key": "password", "value": "s3cr3t", "type": "sealed" }`
+		got := performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		if strings.Contains(got.Body.String(), "s3cr3t") {
+			t.Errorf("Expected response to not contain the cleartext, got: %v", got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/configset/app", nil)
+		if strings.Contains(got.Body.String(), "s3cr3t") || !strings.Contains(got.Body.String(), "sealed:k1:") {
+			t.Errorf("Expected raw set to contain only the sealed value, got: %v", got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/config/app", nil)
+		expected := `{"data":{"password":"s3cr3t"}}`
+		if got.Body.String() != expected {
+			t.Errorf("Expected response: %s got: %v", expected, got.Body.String())
+		}
+
+		got = performRequest(router, "POST", "/api/reseal", nil)
+		expected = `{"data":[]}`
+		if got.Code != http.StatusOK || got.Body.String() != expected {
+			t.Errorf("Expected response: %s got: %d %v", expected, got.Code, got.Body.String())
+		}
+	})
+}
+
+func TestComputedConfigItem(t *testing.T) {
+	t.Run("Test adding computed items", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("workers", 4, domain.Plain), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "key": "threads", "value": "workers * \"2\"", "type": "computed" }`
+		got := performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusUnprocessableEntity, got.Code)
+		}
+
+		expected := fmt.Sprint(domain.InvalidExpression)
+		if !strings.Contains(got.Body.String(), expected) {
+			t.Errorf("Expected response to contain: %s got: %v", expected, got.Body.String())
+		}
+
+		body = `{ "key": "threads", "value": "workers * 2", "type": "computed" }`
+		got = performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/config/app", nil)
+		expected = `{"data":{"threads":8,"workers":4}}`
+		if got.Body.String() != expected {
+			t.Errorf("Expected response: %s got: %v", expected, got.Body.String())
+		}
+	})
+}
+
+func TestConfigVariants(t *testing.T) {
+	t.Run("Test variants are picked from the client headers", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{
+			"key": "timeout",
+			"value": 10,
+			"type": "plain",
+			"variants": [
+				{ "when": { "regions": ["eu"], "labels": { "tier": "beta" } }, "value": 20 },
+				{ "when": { "clients": ["web"], "minVersion": "2.0" }, "value": 30 }
+			]
+		}`
+		got := performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusOK {
+			t.Fatalf("Expected status code: %d, got: %d %s", http.StatusOK, got.Code, got.Body.String())
+		}
+
+		cases := []struct {
+			headers  map[string]string
+			code     int
+			expected string
+		}{
+			{map[string]string{}, http.StatusOK, `{"data":{"timeout":10}}`},
+			{map[string]string{"X-Client-Region": "eu", "X-Client-Labels": "team=core, tier=beta"}, http.StatusOK, `{"data":{"timeout":20}}`},
+			{map[string]string{"X-Client-Name": "web", "X-Client-Version": "2.3.1"}, http.StatusOK, `{"data":{"timeout":30}}`},
+			{map[string]string{"X-Client-Name": "web", "X-Client-Version": "1.9"}, http.StatusOK, `{"data":{"timeout":10}}`},
+			{map[string]string{"X-Client-Labels": "tier"}, http.StatusBadRequest, ""},
+		}
+
+		for _, c := range cases {
+			req, _ := http.NewRequest("GET", "/api/config/app", nil)
+			for header, value := range c.headers {
+				req.Header.Set(header, value)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != c.code {
+				t.Errorf("Expected status code: %d for %v, got: %d", c.code, c.headers, w.Code)
+			}
+
+			if c.expected != "" && w.Body.String() != c.expected {
+				t.Errorf("Expected response: %s for %v, got: %v", c.expected, c.headers, w.Body.String())
+			}
+		}
+	})
+
+	t.Run("Test invalid variants are rejected", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "key": "timeout", "value": 10, "type": "plain", "variants": [{ "when": {}, "value": 20 }] }`
+		got := performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+	})
+}
+
+func TestConfigSetCanary(t *testing.T) {
+	t.Run("Test staging, widening and promoting a canary", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/configset/app/canary", nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+
+		body := `{ "items": { "timeout": { "value": "30s", "type": "plain" } } }`
+		got = performRequest(router, "PUT", "/api/configset/app/canary", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		body = `{ "items": { "timeout": { "value": "30s", "type": "plain" } }, "percent": 0 }`
+		got = performRequest(router, "PUT", "/api/configset/app/canary", &body)
+		if got.Code != http.StatusOK {
+			t.Fatalf("Expected status code: %d, got: %d %s", http.StatusOK, got.Code, got.Body.String())
+		}
+
+		getConfig := func(clientID string) *httptest.ResponseRecorder {
+			req, _ := http.NewRequest("GET", "/api/config/app", nil)
+			req.Header.Set("X-Client-ID", clientID)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			return w
+		}
+
+		w := getConfig("client-1")
+		if w.Header().Get("X-Config-Version") != "stable" || w.Body.String() != `{"data":{"timeout":"10s"}}` {
+			t.Errorf("Expected stable config, got %s: %s", w.Header().Get("X-Config-Version"), w.Body.String())
+		}
+
+		body = `{ "percent": 100 }`
+		got = performRequest(router, "PATCH", "/api/configset/app/canary", &body)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		w = getConfig("client-1")
+		if w.Header().Get("X-Config-Version") != "canary" || w.Body.String() != `{"data":{"timeout":"30s"}}` {
+			t.Errorf("Expected canary config, got %s: %s", w.Header().Get("X-Config-Version"), w.Body.String())
+		}
+
+		body = `{ "percent": 150 }`
+		got = performRequest(router, "PATCH", "/api/configset/app/canary", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		got = performRequest(router, "POST", "/api/configset/app/canary/promote", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		got = performRequest(router, "GET", "/api/config/app", nil)
+		if got.Body.String() != `{"data":{"timeout":"30s"}}` {
+			t.Errorf("Expected promoted config, got: %s", got.Body.String())
+		}
+
+		got = performRequest(router, "DELETE", "/api/configset/app/canary", nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+	})
+}
+
+func TestScheduledConfigChanges(t *testing.T) {
+	t.Run("Test scheduling, listing and cancelling changes", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		effectiveAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		body := `{ "operation": "add", "item": { "key": "banner", "value": "on", "type": "plain" } }`
+		got := performRequest(router, "POST", "/api/configset/app/scheduled", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		body = `{ "operation": "add", "item": { "key": "banner", "value": "on", "type": "plain" }, "effectiveAt": "2001-01-01T00:00:00Z" }`
+		got = performRequest(router, "POST", "/api/configset/app/scheduled", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		body = fmt.Sprintf(`{ "operation": "add", "item": { "key": "banner", "value": "on", "type": "plain" }, "effectiveAt": %q }`, effectiveAt)
+		got = performRequest(router, "POST", "/api/configset/missing/scheduled", &body)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+
+		got = performRequest(router, "POST", "/api/configset/app/scheduled", &body)
+		if got.Code != http.StatusOK {
+			t.Fatalf("Expected status code: %d, got: %d %s", http.StatusOK, got.Code, got.Body.String())
+		}
+
+		var created struct {
+			Data domain.ScheduledChange `json:"data"`
+		}
+		json.Unmarshal(got.Body.Bytes(), &created)
+
+		got = performRequest(router, "GET", "/api/configset/app/scheduled", nil)
+		if got.Code != http.StatusOK || !strings.Contains(got.Body.String(), created.Data.ID) {
+			t.Errorf("Expected the change to be listed, got: %d %s", got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "DELETE", "/api/configset/app/scheduled/"+created.Data.ID, nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		got = performRequest(router, "DELETE", "/api/configset/app/scheduled/"+created.Data.ID, nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+	})
+
+	t.Run("Test failed changes are listed", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		mockRepo.AddScheduledChange(domain.ScheduledChange{
+			ID:          "missing-key",
+			Set:         "app",
+			Operation:   domain.UpdateChange,
+			Item:        *domain.NewConfigItem("banner", "off", domain.Plain),
+			EffectiveAt: time.Now().Add(-time.Minute).UTC(),
+		})
+		service.ApplyDueChanges()
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/configset/app/scheduled/failed", nil)
+		var failed struct {
+			Data []domain.FailedChange `json:"data"`
+		}
+		json.Unmarshal(got.Body.Bytes(), &failed)
+		if got.Code != http.StatusOK || len(failed.Data) != 1 || failed.Data[0].ID != "missing-key" || failed.Data[0].Error == "" {
+			t.Errorf("Expected the failed change to be listed, got: %d %s", got.Code, got.Body.String())
+		}
+	})
+}
+
+func TestConfigOverrides(t *testing.T) {
+	t.Run("Test temporary item overrides are listed", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "key": "timeout", "value": "30s", "type": "plain" }`
+		for _, query := range []string{"?ttl=soon", "?ttl=-1h", "?expiresAt=tomorrow", "?expiresAt=2001-01-01T00:00:00Z", "?ttl=1h&expiresAt=2001-01-01T00:00:00Z"} {
+			got := performRequest(router, "PATCH", "/api/configset/app/item"+query, &body)
+			if got.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code: %d for %s, got: %d", http.StatusBadRequest, query, got.Code)
+			}
+		}
+
+		got := performRequest(router, "PATCH", "/api/configset/app/item?ttl=30m", &body)
+		if got.Code != http.StatusOK {
+			t.Fatalf("Expected status code: %d, got: %d %s", http.StatusOK, got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/overrides", nil)
+		var overrides struct {
+			Data []domain.Override `json:"data"`
+		}
+		json.Unmarshal(got.Body.Bytes(), &overrides)
+		if got.Code != http.StatusOK || len(overrides.Data) != 1 || overrides.Data[0].Previous.Value != "10s" {
+			t.Errorf("Expected the override to be listed, got: %d %s", got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/config/app", nil)
+		if !strings.Contains(got.Body.String(), `"timeout":"30s"`) {
+			t.Errorf("Expected the overridden value, got: %s", got.Body.String())
+		}
+
+		got = performRequest(router, "DELETE", "/api/configset/app/scheduled/"+overrides.Data[0].ID, nil)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+	})
+}
+
+func TestConfigFreeze(t *testing.T) {
+	t.Run("Test frozen sets reject changes", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "reason": "peak sales", "until": "2001-01-01T00:00:00Z" }`
+		got := performRequest(router, "PUT", "/api/configset/app/freeze", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		body = `{ "reason": "peak sales" }`
+		got = performRequest(router, "PUT", "/api/configset/missing/freeze", &body)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+
+		got = performRequest(router, "PUT", "/api/configset/app/freeze", &body)
+		if got.Code != http.StatusOK {
+			t.Fatalf("Expected status code: %d, got: %d %s", http.StatusOK, got.Code, got.Body.String())
+		}
+
+		body = `{ "key": "timeout", "value": "30s", "type": "plain" }`
+		got = performRequest(router, "PATCH", "/api/configset/app/item", &body)
+		if got.Code != http.StatusLocked || !strings.Contains(got.Body.String(), "peak sales") {
+			t.Errorf("Expected status code: %d, got: %d %s", http.StatusLocked, got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "DELETE", "/api/configset/app", nil)
+		if got.Code != http.StatusLocked {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusLocked, got.Code)
+		}
+
+		got = performRequest(router, "DELETE", "/api/configset/app/freeze", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		got = performRequest(router, "PATCH", "/api/configset/app/item", &body)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d %s", http.StatusOK, got.Code, got.Body.String())
+		}
+	})
+
+	t.Run("Test freeze windows reject changes without the override token", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		now := time.Now().UTC()
+		config.FreezeWindows = []domain.FreezeWindow{{Start: now.Add(-time.Hour), End: now.Add(time.Hour), Reason: "Black Friday"}}
+		config.FreezeOverrideToken = "admin-token"
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.WithFreezeOverride().CreateSet("app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "key": "timeout", "value": "10s", "type": "plain" }`
+		got := performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusLocked || !strings.Contains(got.Body.String(), "Black Friday") {
+			t.Errorf("Expected status code: %d, got: %d %s", http.StatusLocked, got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/configset/app", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected reads to be allowed, got: %d", got.Code)
+		}
+
+		for token, expected := range map[string]int{"wrong": http.StatusLocked, "admin-token": http.StatusOK} {
+			req, _ := http.NewRequest("POST", "/api/configset/app/item", strings.NewReader(body))
+			req.Header.Set(freezeOverrideHeader, token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != expected {
+				t.Errorf("Expected status code: %d with token %q, got: %d", expected, token, w.Code)
+			}
+		}
+	})
+}
+
+func TestConfigTrash(t *testing.T) {
+	t.Run("Test deleted sets can be listed, restored and purged", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "app")
+		service.CreateSet("api")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		performRequest(router, "DELETE", "/api/configset/app", nil)
+		performRequest(router, "DELETE", "/api/configset/api", nil)
+
+		got := performRequest(router, "GET", "/api/trash?limit=1", nil)
+		var trashed struct {
+			Data []domain.TrashedSet `json:"data"`
+		}
+		json.Unmarshal(got.Body.Bytes(), &trashed)
+		if got.Code != http.StatusOK || len(trashed.Data) != 1 {
+			t.Errorf("Expected a page of trashed sets, got: %d %s", got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "POST", "/api/trash/app/restore", nil)
+		if got.Code != http.StatusOK {
+			t.Fatalf("Expected status code: %d, got: %d %s", http.StatusOK, got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/config/app", nil)
+		if got.Code != http.StatusOK || !strings.Contains(got.Body.String(), `"timeout":"10s"`) {
+			t.Errorf("Expected the restored set, got: %d %s", got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "POST", "/api/trash/app/restore", nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+
+		service.CreateSet("api")
+		got = performRequest(router, "POST", "/api/trash/api/restore", nil)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		got = performRequest(router, "DELETE", "/api/trash/api", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		got = performRequest(router, "DELETE", "/api/trash/api", nil)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+	})
+
+	t.Run("Test deletes conflict with a trashed set with the same name", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		service.DeleteSet("app", false)
+		service.CreateSet("app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "DELETE", "/api/configset/app", nil)
+		if got.Code != http.StatusConflict {
+			t.Errorf("Expected status code: %d, got: %d %s", http.StatusConflict, got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/configset/app", nil)
+		if got.Code != http.StatusOK {
+			t.Errorf("Expected the set to be kept, got: %d", got.Code)
+		}
+	})
+}
+
+func TestCloneConfigSet(t *testing.T) {
+	t.Run("Test sets are cloned with their nested sets", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		service.AddItem(*domain.NewConfigItem("host", "localhost", domain.Plain), "db")
+		service.CreateSet("app")
+		service.AddItem(*domain.NewConfigItem("db", "db", domain.Nested), "app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		for _, body := range []string{`{}`, `{ "name": "app" }`, `not json`} {
+			got := performRequest(router, "POST", "/api/configset/app/clone", &body)
+			if got.Code != http.StatusBadRequest {
+				t.Errorf("Expected status code: %d for %s, got: %d", http.StatusBadRequest, body, got.Code)
+			}
+		}
+
+		body := `{ "name": "staging-app", "prefix": "staging-" }`
+		got := performRequest(router, "POST", "/api/configset/missing/clone", &body)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+
+		got = performRequest(router, "POST", "/api/configset/app/clone", &body)
+		if got.Code != http.StatusOK || !strings.Contains(got.Body.String(), `"value":"staging-db"`) {
+			t.Fatalf("Expected the clone, got: %d %s", got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/config/staging-app", nil)
+		if !strings.Contains(got.Body.String(), `"host":"localhost"`) {
+			t.Errorf("Expected the clone to render, got: %s", got.Body.String())
+		}
+
+		got = performRequest(router, "POST", "/api/configset/app/clone", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+	})
+}
+
+func TestConfigSetMetadata(t *testing.T) {
+	t.Run("Test sets are described and listed by label", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("checkout")
+		service.CreateSet("search")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "labels": { "team": "a,b" } }`
+		got := performRequest(router, "PUT", "/api/configset/checkout/metadata", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		body = `{ "description": "Checkout flow", "owner": "payments", "contact": "#payments", "labels": { "team": "payments", "env": "prod" } }`
+		got = performRequest(router, "PUT", "/api/configset/missing/metadata", &body)
+		if got.Code != http.StatusNotFound {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusNotFound, got.Code)
+		}
+
+		got = performRequest(router, "PUT", "/api/configset/checkout/metadata", &body)
+		if got.Code != http.StatusOK || !strings.Contains(got.Body.String(), `"owner":"payments"`) {
+			t.Fatalf("Expected the metadata to be saved, got: %d %s", got.Code, got.Body.String())
+		}
+
+		cases := []struct {
+			query    string
+			code     int
+			expected string
+		}{
+			{"", http.StatusOK, `{"data":["checkout","search"]}`},
+			{"?labels=team=payments,env=prod", http.StatusOK, `{"data":["checkout"]}`},
+			{"?labels=team=discovery", http.StatusOK, `{"data":[]}`},
+			{"?labels=team", http.StatusBadRequest, ""},
+		}
+
+		for _, c := range cases {
+			got = performRequest(router, "GET", "/api/configsets"+c.query, nil)
+			if got.Code != c.code || (c.expected != "" && got.Body.String() != c.expected) {
+				t.Errorf("Expected %d %s for %q, got: %d %s", c.code, c.expected, c.query, got.Code, got.Body.String())
+			}
+		}
+	})
+}
+
+func TestConfigItemMetadata(t *testing.T) {
+	t.Run("Test deprecated keys are listed in a Warning header", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("app")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		got := performRequest(router, "GET", "/api/config/app", nil)
+		if got.Header().Get("Warning") != "" {
+			t.Errorf("Expected no warning, got: %s", got.Header().Get("Warning"))
+		}
+
+		body := `{ "key": "timeout", "value": "10s", "type": "plain", "replacedBy": "requestTimeout" }`
+		got = performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusBadRequest {
+			t.Errorf("Expected status code: %d, got: %d", http.StatusBadRequest, got.Code)
+		}
+
+		body = `{ "key": "timeout", "value": "10s", "type": "plain", "description": "Request timeout", "deprecated": true, "replacedBy": "requestTimeout" }`
+		got = performRequest(router, "POST", "/api/configset/app/item", &body)
+		if got.Code != http.StatusOK || !strings.Contains(got.Body.String(), `"description":"Request timeout"`) {
+			t.Fatalf("Expected the item to be documented, got: %d %s", got.Code, got.Body.String())
+		}
+
+		got = performRequest(router, "GET", "/api/config/app", nil)
+		expected := `299 - "Deprecated config keys: timeout (use requestTimeout)"`
+		if got.Code != http.StatusOK || got.Body.String() != `{"data":{"timeout":"10s"}}` {
+			t.Errorf("Expected deprecated keys to be rendered, got: %d %s", got.Code, got.Body.String())
+		}
+
+		if got.Header().Get("Warning") != expected {
+			t.Errorf("Expected warning: %s, got: %s", expected, got.Header().Get("Warning"))
+		}
+
+		at := url.QueryEscape(time.Now().Add(time.Minute).UTC().Format(time.RFC3339))
+		got = performRequest(router, "GET", "/api/config/app?at="+at, nil)
+		if got.Code != http.StatusOK || got.Header().Get("Warning") != expected {
+			t.Errorf("Expected warning: %s for a render at a time, got: %d %s", expected, got.Code, got.Header().Get("Warning"))
+		}
+	})
+
+	t.Run("Test sensitive values are masked in the raw set views", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		body := `{ "key": "password", "value": "hunter2", "type": "plain", "sensitive": true }`
+		got := performRequest(router, "POST", "/api/configset/db/item", &body)
+		if got.Code != http.StatusOK {
+			t.Fatalf("Expected status code: %d, got: %d", http.StatusOK, got.Code)
+		}
+
+		for _, path := range []string{"/api/configset/db", "/api/configset/db/revisions", "/api/configset/db/revisions/2"} {
+			got = performRequest(router, "GET", path, nil)
+			if got.Code != http.StatusOK || strings.Contains(got.Body.String(), "hunter2") || !strings.Contains(got.Body.String(), domain.MaskedValue) {
+				t.Errorf("Expected a masked password in %s, got: %d %s", path, got.Code, got.Body.String())
+			}
+		}
+
+		got = performRequest(router, "GET", "/api/config/db", nil)
+		if got.Body.String() != `{"data":{"password":"hunter2"}}` {
+			t.Errorf("Expected the rendered set to hold the password, got: %s", got.Body.String())
+		}
+	})
+
+	t.Run("Test sensitive values are masked in every response holding items", func(t *testing.T) {
+		router := gin.New()
+		config := domain.DefaultConfig()
+		mockRepo := mocks.NewMockRepo()
+		cacheRepo := mocks.NewMockRepo()
+		mockSecret := mocks.MockSecrets{}
+		service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+
+		service.CreateSet("db")
+		handler := NewConfigRESTHandler(&config, toogleRepo, service)
+		handler.CreateRoutes(router)
+
+		failed := domain.ScheduledChange{
+			ID:        "failed",
+			Set:       "db",
+			Operation: domain.UpdateChange,
+			Item:      domain.ConfigItem{Key: "password", Value: "hunter2-failed", Type: domain.Plain, ItemMetadata: domain.ItemMetadata{Sensitive: true}},
+		}
+		mockRepo.Failed[failed.ID] = domain.NewFailedChange(failed, errors.New("failed"), time.Now())
+
+		effectiveAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+		requests := []struct {
+			method string
+			path   string
+			body   string
+		}{
+			{"POST", "/api/configset/db/item", `{ "key": "password", "value": "hunter2", "type": "plain", "sensitive": true }`},
+			{"PATCH", "/api/configset/db/item", `{ "key": "password", "value": "hunter2-new", "type": "plain", "sensitive": true }`},
+			{"POST", "/api/configset/db/item", `{ "key": "user", "value": "admin", "type": "plain" }`},
+			{"DELETE", "/api/configset/db/item/user", ""},
+			{"GET", "/api/configset/db/diff?against=2", ""},
+			{"PUT", "/api/configset/db/canary", `{ "items": { "password": { "value": "hunter2-canary", "type": "plain", "sensitive": true } }, "percent": 0 }`},
+			{"GET", "/api/configset/db/canary", ""},
+			{"PATCH", "/api/configset/db/canary", `{ "percent": 50 }`},
+			{"POST", "/api/configset/db/canary/promote", ""},
+			{"POST", "/api/configset/db/scheduled", fmt.Sprintf(`{ "operation": "update", "item": { "key": "password", "value": "hunter2-later", "type": "plain", "sensitive": true }, "effectiveAt": %q }`, effectiveAt)},
+			{"GET", "/api/configset/db/scheduled", ""},
+			{"GET", "/api/configset/db/scheduled/failed", ""},
+			{"PATCH", "/api/configset/db/item?ttl=30m", `{ "key": "password", "value": "hunter2-override", "type": "plain", "sensitive": true }`},
+			{"GET", "/api/overrides", ""},
+			{"POST", "/api/configset/db/revisions/2/rollback", ""},
+			{"PUT", "/api/configset/db/freeze", `{ "reason": "release" }`},
+			{"DELETE", "/api/configset/db/freeze", ""},
+			{"POST", "/api/configset/db/clone", `{ "name": "copy" }`},
+			{"DELETE", "/api/configset/copy", ""},
+			{"GET", "/api/trash", ""},
+			{"POST", "/api/trash/copy/restore", ""},
+			{"DELETE", "/api/configset/copy", ""},
+			{"DELETE", "/api/trash/copy", ""},
+		}
+
+		for _, request := range requests {
+			body := request.body
+			got := performRequest(router, request.method, request.path, &body)
+			if got.Code != http.StatusOK || strings.Contains(got.Body.String(), "hunter2") || !strings.Contains(got.Body.String(), domain.MaskedValue) {
+				t.Errorf("Expected a masked password in %s %s, got: %d %s", request.method, request.path, got.Code, got.Body.String())
+			}
+		}
+	})
+}
+
 // Benchmarks
 
 func BenchmarkGetJSONWithoutSingleflight(b *testing.B) {
@@ -580,7 +2205,7 @@ func BenchmarkGetJSONWithoutSingleflight(b *testing.B) {
 	mockRepo := mocks.NewMockRepo()
 	cacheRepo := mocks.NewMockRepo()
 	mockSecret := mocks.MockSecrets{}
-	service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+	service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 	service.CreateSet(name)
 	handler := NewConfigRESTHandler(&config, toogleRepo, service)
@@ -598,7 +2223,7 @@ func BenchmarkGetJSONWithSingleflight(b *testing.B) {
 	mockRepo := mocks.NewMockRepo()
 	cacheRepo := mocks.NewMockRepo()
 	mockSecret := mocks.MockSecrets{}
-	service := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
+	service, _ := service.NewConfigService(&config, mockRepo, cacheRepo, &mockSecret)
 
 	service.CreateSet(name)
 	toogleRepo := mocks.NewToggleFlagRepo(map[string]domain.ToggleFlag{
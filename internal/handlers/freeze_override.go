@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+// Request header allowing changes during freeze windows, must match the configured token
+const freezeOverrideHeader = "X-Freeze-Override"
+
+// serviceFor returns the service handling a request changing data,
+// requests carrying the freeze override token may change sets during freeze windows
+func (handler *ConfigRESTHandler) serviceFor(c *gin.Context) ports.ConfigService {
+	token := c.GetHeader(freezeOverrideHeader)
+	if token == "" || handler.config.FreezeOverrideToken == "" {
+		return handler.service
+	}
+
+	if subtle.ConstantTimeCompare([]byte(token), []byte(handler.config.FreezeOverrideToken)) != 1 {
+		return handler.service
+	}
+
+	log.Warn().Msgf("Freeze windows overridden by %s %s", c.Request.Method, c.Request.URL.Path)
+	return handler.service.WithFreezeOverride()
+}
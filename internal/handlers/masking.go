@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+// writeData writes the response of a route that doesn't serve rendered config,
+// the values of sensitive items are masked in every response
+func writeData(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusOK, gin.H{"data": masked(data)})
+}
+
+// masked returns a copy of data with the values of sensitive items masked,
+// data without config items is returned as is
+func masked(data interface{}) interface{} {
+	switch value := data.(type) {
+	case domain.ConfigSet:
+		return value.Masked()
+	case domain.ConfigRevision:
+		return value.Masked()
+	case []domain.ConfigRevision:
+		output := make([]domain.ConfigRevision, len(value))
+		for i, revision := range value {
+			output[i] = revision.Masked()
+		}
+		return output
+	case domain.ConfigDiff:
+		return value.Masked()
+	case domain.Canary:
+		return value.Masked()
+	case domain.ScheduledChange:
+		return value.Masked()
+	case []domain.ScheduledChange:
+		output := make([]domain.ScheduledChange, len(value))
+		for i, change := range value {
+			output[i] = change.Masked()
+		}
+		return output
+	case []domain.FailedChange:
+		output := make([]domain.FailedChange, len(value))
+		for i, failed := range value {
+			output[i] = failed.Masked()
+		}
+		return output
+	case domain.TrashedSet:
+		return value.Masked()
+	case []domain.TrashedSet:
+		output := make([]domain.TrashedSet, len(value))
+		for i, trashed := range value {
+			output[i] = trashed.Masked()
+		}
+		return output
+	case []domain.Override:
+		output := make([]domain.Override, len(value))
+		for i, override := range value {
+			output[i] = override.Masked()
+		}
+		return output
+	}
+
+	return data
+}
@@ -0,0 +1,53 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+const CanaryPrefix string = "canary:"
+
+func (repo *RedisRepo) SaveCanary(canary domain.Canary) (domain.Canary, error) {
+	ctx := context.Background()
+	jsonBytes, err := json.Marshal(canary)
+	if err != nil {
+		return domain.Canary{}, err
+	}
+
+	err = repo.db.Client.Set(ctx, CanaryPrefix+canary.Set, jsonBytes, 0).Err()
+	if err != nil {
+		return domain.Canary{}, err
+	}
+
+	return canary, nil
+}
+
+func (repo *RedisRepo) GetCanary(name string) (domain.Canary, error) {
+	ctx := context.Background()
+	cmd := repo.db.Client.Get(ctx, CanaryPrefix+name)
+	if cmd.Err() != nil {
+		if cmd.Err() == redis.Nil {
+			return domain.Canary{}, ports.ErrCanaryNotExists
+		}
+		return domain.Canary{}, cmd.Err()
+	}
+
+	var canary domain.Canary
+	err := domain.DecodeJSON([]byte(cmd.Val()), &canary)
+	return canary, err
+}
+
+func (repo *RedisRepo) DeleteCanary(name string) (domain.Canary, error) {
+	canary, err := repo.GetCanary(name)
+	if err != nil {
+		return canary, err
+	}
+
+	ctx := context.Background()
+	err = repo.db.Client.Del(ctx, CanaryPrefix+name).Err()
+	return canary, err
+}
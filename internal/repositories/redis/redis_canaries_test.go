@@ -0,0 +1,55 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+func TestCanaries(t *testing.T) {
+	config := domain.DefaultConfig()
+	config.Redis.DB = DB
+	db, _ := GetRedisDB(&config)
+	defer db.Client.FlushDB(context.Background())
+
+	t.Run("Test canaries can be saved, read and deleted", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		items := domain.ConfigItemMap{"timeout": *domain.NewConfigItem("timeout", "30s", domain.Plain)}
+		canary, _ := domain.NewCanary("app", items, 10)
+		_, err := repo.SaveCanary(*canary)
+		if err != nil {
+			t.Fatalf("Expected canary to be saved without errors, got: %v", err)
+		}
+
+		canary.SetPercent(50)
+		repo.SaveCanary(*canary)
+
+		got, err := repo.GetCanary("app")
+		if err != nil {
+			t.Fatalf("Expected canary to be read without errors, got: %v", err)
+		}
+
+		if got.Percent != 50 || len(got.History) != 2 || got.Items["timeout"].Value != "30s" {
+			t.Errorf("Expected canary: %+v, got: %+v", *canary, got)
+		}
+
+		_, err = repo.DeleteCanary("app")
+		if err != nil {
+			t.Errorf("Expected canary to be deleted without errors, got: %v", err)
+		}
+
+		_, err = repo.GetCanary("app")
+		if err != ports.ErrCanaryNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrCanaryNotExists, err)
+		}
+
+		_, err = repo.DeleteCanary("app")
+		if err != ports.ErrCanaryNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrCanaryNotExists, err)
+		}
+	})
+}
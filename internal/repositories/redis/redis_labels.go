@@ -0,0 +1,82 @@
+package redis
+
+import (
+	"context"
+	"sort"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+// LabelPrefix keys a set with the names of the ConfigSets having each key=value label
+const LabelPrefix string = "label:"
+
+// labelKeys returns the index keys of the given labels
+func labelKeys(labels map[string]string) []string {
+	keys := domain.LabelPairs(labels)
+	for i, pair := range keys {
+		keys[i] = LabelPrefix + pair
+	}
+
+	return keys
+}
+
+// indexLabels adds the set with the given name to the index of each label
+func indexLabels(ctx context.Context, p redis.Pipeliner, name string, labels map[string]string) {
+	for _, key := range labelKeys(labels) {
+		p.SAdd(ctx, key, name)
+	}
+}
+
+// unindexLabels removes the set with the given name from the index of each label
+func unindexLabels(ctx context.Context, p redis.Pipeliner, name string, labels map[string]string) {
+	for _, key := range labelKeys(labels) {
+		p.SRem(ctx, key, name)
+	}
+}
+
+func (repo *RedisRepo) GetSetNamesByLabels(labels map[string]string, limit int, skip int) ([]string, error) {
+	ctx := context.Background()
+	names, err := repo.db.Client.SInter(ctx, labelKeys(labels)...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	// Use the order of the names list so both listings page the same way
+	scores := make([]*redis.FloatCmd, len(names))
+	_, err = repo.db.Client.Pipelined(ctx, func(p redis.Pipeliner) error {
+		for i, name := range names {
+			scores[i] = p.ZScore(ctx, CfgSetNames, CfgSetPrefix+name)
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	created := map[string]float64{}
+	listed := make([]string, 0, len(names))
+	for i, name := range names {
+		if scores[i].Err() != nil {
+			// The set was removed from the names list after its labels were read
+			continue
+		}
+
+		created[name] = scores[i].Val()
+		listed = append(listed, name)
+	}
+
+	sort.Slice(listed, func(i, j int) bool {
+		return created[listed[i]] < created[listed[j]]
+	})
+
+	if skip >= len(listed) {
+		return []string{}, nil
+	}
+
+	if limit > len(listed)-skip {
+		limit = len(listed) - skip
+	}
+
+	return listed[skip : skip+limit], nil
+}
@@ -0,0 +1,90 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+func TestSetLabels(t *testing.T) {
+	config := domain.DefaultConfig()
+	config.Redis.DB = DB
+	db, _ := GetRedisDB(&config)
+	defer db.Client.FlushDB(context.Background())
+
+	t.Run("Test sets are found by their labels", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		// Listed in creation order like the unfiltered names
+		for _, labeled := range []struct {
+			name   string
+			labels map[string]string
+		}{
+			{"search", map[string]string{"team": "discovery", "env": "prod"}},
+			{"refunds", map[string]string{"team": "payments", "env": "dev"}},
+			{"checkout", map[string]string{"team": "payments", "env": "prod"}},
+		} {
+			set := domain.NewConfigSet(labeled.name)
+			set.Labels = labeled.labels
+			repo.CreateSet(*set)
+		}
+
+		cases := []struct {
+			labels   map[string]string
+			limit    int
+			skip     int
+			expected []string
+		}{
+			{map[string]string{"team": "payments"}, 10, 0, []string{"refunds", "checkout"}},
+			{map[string]string{"team": "payments", "env": "prod"}, 10, 0, []string{"checkout"}},
+			{map[string]string{"env": "prod"}, 1, 1, []string{"checkout"}},
+			{map[string]string{"env": "prod"}, 10, 5, []string{}},
+			{map[string]string{"team": "billing"}, 10, 0, []string{}},
+		}
+
+		for _, c := range cases {
+			got, err := repo.GetSetNamesByLabels(c.labels, c.limit, c.skip)
+			if err != nil || !cmp.Equal(got, c.expected) {
+				t.Errorf("Expected %v for %v, got: %v, %v", c.expected, c.labels, got, err)
+			}
+		}
+	})
+
+	t.Run("Test the label index follows set writes", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		set := domain.NewConfigSet("checkout")
+		set.Labels = map[string]string{"team": "payments"}
+		repo.CreateSet(*set)
+
+		set.Labels = map[string]string{"team": "billing"}
+		repo.ReplaceSet(*set)
+
+		expectNames := func(labels map[string]string, expected []string) {
+			t.Helper()
+			got, _ := repo.GetSetNamesByLabels(labels, 10, 0)
+			if !cmp.Equal(got, expected) {
+				t.Errorf("Expected %v for %v, got: %v", expected, labels, got)
+			}
+		}
+
+		expectNames(map[string]string{"team": "payments"}, []string{})
+		expectNames(map[string]string{"team": "billing"}, []string{"checkout"})
+
+		repo.AddItem(*domain.NewConfigItem("timeout", "10s", domain.Plain), "checkout")
+		expectNames(map[string]string{"team": "billing"}, []string{"checkout"})
+
+		repo.TrashSet("checkout", nil)
+		expectNames(map[string]string{"team": "billing"}, []string{})
+
+		repo.RestoreSet("checkout")
+		expectNames(map[string]string{"team": "billing"}, []string{"checkout"})
+
+		repo.DeleteSet("checkout")
+		expectNames(map[string]string{"team": "billing"}, []string{})
+	})
+}
@@ -0,0 +1,30 @@
+package redis
+
+import (
+	"context"
+	"sort"
+)
+
+const ReferrersPrefix string = "refs:"
+
+func (repo *RedisRepo) AddReferrer(target string, referrer string) error {
+	ctx := context.Background()
+	return repo.db.Client.SAdd(ctx, ReferrersPrefix+target, referrer).Err()
+}
+
+func (repo *RedisRepo) RemoveReferrer(target string, referrer string) error {
+	ctx := context.Background()
+	return repo.db.Client.SRem(ctx, ReferrersPrefix+target, referrer).Err()
+}
+
+func (repo *RedisRepo) GetReferrers(target string) ([]string, error) {
+	ctx := context.Background()
+	cmd := repo.db.Client.SMembers(ctx, ReferrersPrefix+target)
+	if cmd.Err() != nil {
+		return nil, cmd.Err()
+	}
+
+	names := cmd.Val()
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,47 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+)
+
+func TestReferrers(t *testing.T) {
+	config := domain.DefaultConfig()
+	config.Redis.DB = DB
+	db, _ := GetRedisDB(&config)
+	defer db.Client.FlushDB(context.Background())
+
+	t.Run("Test referrers can be added, listed and removed", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		repo.AddReferrer("db", "payments")
+		repo.AddReferrer("db", "billing")
+		repo.AddReferrer("db", "billing")
+
+		got, err := repo.GetReferrers("db")
+		if err != nil {
+			t.Errorf("Expected referrers to be read without errors, got: %v", err)
+		}
+
+		expected := []string{"billing", "payments"}
+		if !cmp.Equal(got, expected) {
+			t.Errorf("Expected referrers: %v, got: %v", expected, got)
+		}
+
+		repo.RemoveReferrer("db", "billing")
+		got, _ = repo.GetReferrers("db")
+		expected = []string{"payments"}
+		if !cmp.Equal(got, expected) {
+			t.Errorf("Expected referrers: %v, got: %v", expected, got)
+		}
+
+		got, err = repo.GetReferrers("notReferenced")
+		if err != nil || len(got) != 0 {
+			t.Errorf("Expected no referrers and no error, got: %v, %v", got, err)
+		}
+	})
+}
@@ -53,10 +53,21 @@ func (repo *RedisRepo) SaveJSON(json []byte, key string, ttl int) error {
 		return nil
 	})
 
-	log.Info().Msgf("CACHE: Save JSON commands: %+v", cmds)
+	// The commands hold the rendered values, only their names are logged
+	log.Info().Msgf("CACHE: Save JSON of %q commands: %s", key, commandNames(cmds))
 	return err
 }
 
+// commandNames lists the names of the given commands without their arguments
+func commandNames(cmds []redis.Cmder) []string {
+	names := make([]string, len(cmds))
+	for i, cmd := range cmds {
+		names[i] = cmd.Name()
+	}
+
+	return names
+}
+
 func (repo *RedisRepo) GetJSON(key string, maxAge int) ([]byte, error) {
 	ctx := context.Background()
 
@@ -137,6 +148,7 @@ func (repo *RedisRepo) CreateSet(set domain.ConfigSet) (domain.ConfigSet, error)
 			return cmdName.Err()
 		}
 
+		indexLabels(ctx, p, set.Name, set.Labels)
 		return nil
 	})
 
@@ -155,7 +167,7 @@ func (repo *RedisRepo) GetSet(name string) (*domain.ConfigSet, error) {
 	}
 
 	var set domain.ConfigSet
-	json.Unmarshal([]byte(cmd.Val()), &set)
+	domain.DecodeJSON([]byte(cmd.Val()), &set)
 	return &set, nil
 }
 
@@ -175,6 +187,50 @@ func (repo *RedisRepo) GetSetNames(limit int, skip int) ([]string, error) {
 	return cmd.Val(), nil
 }
 
+func (repo *RedisRepo) ListSets(limit int, skip int) ([]domain.ConfigSet, error) {
+	ctx := context.Background()
+	start := skip
+	end := skip + limit - 1
+	keysCmd := repo.db.Client.ZRange(ctx, CfgSetNames, int64(start), int64(end))
+	if keysCmd.Err() != nil {
+		if keysCmd.Err() == redis.Nil {
+			return []domain.ConfigSet{}, nil
+		}
+
+		return nil, keysCmd.Err()
+	}
+
+	// Set names are stored with the key prefix
+	keys := keysCmd.Val()
+	if len(keys) == 0 {
+		return []domain.ConfigSet{}, nil
+	}
+
+	cmd := repo.db.Client.MGet(ctx, keys...)
+	if cmd.Err() != nil {
+		return nil, cmd.Err()
+	}
+
+	sets := make([]domain.ConfigSet, 0, len(keys))
+	for _, val := range cmd.Val() {
+		str, ok := val.(string)
+		if !ok {
+			// The set was deleted between both commands
+			continue
+		}
+
+		var set domain.ConfigSet
+		err := domain.DecodeJSON([]byte(str), &set)
+		if err != nil {
+			return nil, err
+		}
+
+		sets = append(sets, set)
+	}
+
+	return sets, nil
+}
+
 func (repo *RedisRepo) DeleteSet(name string) (domain.ConfigSet, error) {
 	ctx := context.Background()
 	key := CfgSetPrefix + name
@@ -192,7 +248,7 @@ func (repo *RedisRepo) DeleteSet(name string) (domain.ConfigSet, error) {
 	}
 
 	var set domain.ConfigSet
-	json.Unmarshal([]byte(cmd.Val()), &set)
+	domain.DecodeJSON([]byte(cmd.Val()), &set)
 
 	cmds, err := repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
 		cmdDel := repo.db.Client.Del(ctx, key)
@@ -206,6 +262,7 @@ func (repo *RedisRepo) DeleteSet(name string) (domain.ConfigSet, error) {
 			return cmdName.Err()
 		}
 
+		unindexLabels(ctx, p, set.Name, set.Labels)
 		return nil
 	})
 
@@ -224,7 +281,7 @@ func (repo *RedisRepo) AddItem(item domain.ConfigItem, setName string) (domain.C
 	}
 
 	var set domain.ConfigSet
-	err := json.Unmarshal([]byte(cmd.Val()), &set)
+	err := domain.DecodeJSON([]byte(cmd.Val()), &set)
 	if err != nil {
 		return domain.ConfigSet{}, err
 	}
@@ -258,7 +315,7 @@ func (repo *RedisRepo) UpdateItem(item domain.ConfigItem, setName string) (domai
 	}
 
 	var set domain.ConfigSet
-	err := json.Unmarshal([]byte(cmd.Val()), &set)
+	err := domain.DecodeJSON([]byte(cmd.Val()), &set)
 	if err != nil {
 		return domain.ConfigSet{}, err
 	}
@@ -292,7 +349,7 @@ func (repo *RedisRepo) RemoveItem(item domain.ConfigItem, setName string) (domai
 	}
 
 	var set domain.ConfigSet
-	err := json.Unmarshal([]byte(cmd.Val()), &set)
+	err := domain.DecodeJSON([]byte(cmd.Val()), &set)
 	if err != nil {
 		return domain.ConfigSet{}, err
 	}
@@ -314,3 +371,39 @@ func (repo *RedisRepo) RemoveItem(item domain.ConfigItem, setName string) (domai
 
 	return set, nil
 }
+
+func (repo *RedisRepo) ReplaceSet(set domain.ConfigSet) (domain.ConfigSet, error) {
+	ctx := context.Background()
+	key := CfgSetPrefix + set.Name
+	cmd := repo.db.Client.Get(ctx, key)
+	if cmd.Err() != nil {
+		if cmd.Err() == redis.Nil {
+			return domain.ConfigSet{}, ports.ErrConfigNotExists
+		}
+		return domain.ConfigSet{}, cmd.Err()
+	}
+
+	var current domain.ConfigSet
+	err := domain.DecodeJSON([]byte(cmd.Val()), &current)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	jsonBytes, err := json.Marshal(set)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	_, err = repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, key, jsonBytes, redis.KeepTTL)
+		unindexLabels(ctx, p, set.Name, current.Labels)
+		indexLabels(ctx, p, set.Name, set.Labels)
+		return nil
+	})
+
+	if err != nil {
+		return set, err
+	}
+
+	return set, nil
+}
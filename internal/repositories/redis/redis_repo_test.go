@@ -2,6 +2,7 @@ package redis
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
@@ -205,6 +206,44 @@ func TestReadSet(t *testing.T) {
 			t.Errorf("Expected names length of: 0, got: %d", len(names))
 		}
 	})
+
+	t.Run("Test sets can be listed and paginated", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		for i := 0; i < 5; i++ {
+			repo.CreateSet(*domain.NewConfigSet(
+				fmt.Sprintf("TestListSetPage%d", i),
+				*domain.NewConfigItem("index", i, domain.Plain),
+			))
+		}
+
+		sets, err := repo.ListSets(2, 3)
+		if err != nil {
+			t.Errorf("Expected sets to be listed without errors, got: %v", err)
+		}
+
+		if len(sets) != 2 {
+			t.Fatalf("Expected sets length of: 2, got: %d", len(sets))
+		}
+
+		if sets[0].Name != "TestListSetPage3" || sets[1].Name != "TestListSetPage4" {
+			t.Errorf("Expected sets TestListSetPage3 and TestListSetPage4, got: %q and %q", sets[0].Name, sets[1].Name)
+		}
+
+		if _, err := sets[0].Get("index"); err != nil {
+			t.Errorf("Expected listed sets to include their items, got: %v", err)
+		}
+
+		sets, err = repo.ListSets(2, 5)
+		if err != nil {
+			t.Errorf("Expected sets to be listed without errors, got: %v", err)
+		}
+
+		if len(sets) != 0 {
+			t.Errorf("Expected sets length of: 0, got: %d", len(sets))
+		}
+	})
 }
 
 func TestDeleteSet(t *testing.T) {
@@ -267,7 +306,7 @@ func TestAddItemToSet(t *testing.T) {
 		key := "TestAddItemToSetOKKey"
 		newItem := domain.ConfigItem{
 			Key:   key,
-			Value: float64(100),
+			Value: json.Number("100"),
 			Type:  domain.Plain,
 		}
 		_, err := repo.AddItem(newItem, name)
@@ -298,6 +337,23 @@ func TestAddItemToSet(t *testing.T) {
 		}
 	})
 
+	t.Run("Test numbers are stored without precision loss", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		name := "TestAddItemToSetPrecision"
+		repo.CreateSet(*domain.NewConfigSet(name))
+
+		var bigID int64 = 9007199254740993
+		repo.AddItem(*domain.NewConfigItem("id", bigID, domain.Plain), name)
+
+		got, _ := repo.GetSet(name)
+		gotItem, _ := got.Get("id")
+		if gotItem.Value != json.Number("9007199254740993") {
+			t.Errorf("Expected value: %d, got: %v", bigID, gotItem.Value)
+		}
+	})
+
 	t.Run("Test a duplicated key can't be added", func(t *testing.T) {
 		repo := NewRedisRepo(&config, db)
 		db.Client.FlushDB(context.Background())
@@ -0,0 +1,142 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+const (
+	RevisionPrefix     string = "rev:"
+	RevisionSeqPrefix  string = "revseq:"
+	RevisionTimePrefix string = "revtime:"
+)
+
+// revisionMember formats revision numbers so their lexicographical order matches the numerical one
+// Redis sorts members with the same score lexicographically, and several revisions can share a date
+func revisionMember(revision int) string {
+	return fmt.Sprintf("%012d", revision)
+}
+
+func (repo *RedisRepo) AddRevision(rev domain.ConfigRevision) (domain.ConfigRevision, error) {
+	ctx := context.Background()
+	name := rev.Set.Name
+	seqCmd := repo.db.Client.Incr(ctx, RevisionSeqPrefix+name)
+	if seqCmd.Err() != nil {
+		return domain.ConfigRevision{}, seqCmd.Err()
+	}
+
+	rev.Revision = int(seqCmd.Val())
+	rev.Date = datetime.UnixUTCNow()
+	jsonBytes, err := json.Marshal(rev)
+	if err != nil {
+		return domain.ConfigRevision{}, err
+	}
+
+	_, err = repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		addCmd := p.ZAdd(ctx, RevisionPrefix+name, &redis.Z{
+			Score:  float64(rev.Revision),
+			Member: jsonBytes,
+		})
+
+		if addCmd.Err() != nil {
+			return addCmd.Err()
+		}
+
+		timeCmd := p.ZAdd(ctx, RevisionTimePrefix+name, &redis.Z{
+			Score:  float64(rev.Date.Unix()),
+			Member: revisionMember(rev.Revision),
+		})
+
+		if timeCmd.Err() != nil {
+			return timeCmd.Err()
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return domain.ConfigRevision{}, err
+	}
+
+	return rev, nil
+}
+
+func (repo *RedisRepo) GetRevisions(name string, limit int, skip int) ([]domain.ConfigRevision, error) {
+	ctx := context.Background()
+	start := skip
+	end := skip + limit - 1
+	cmd := repo.db.Client.ZRevRange(ctx, RevisionPrefix+name, int64(start), int64(end))
+	if cmd.Err() != nil {
+		if cmd.Err() == redis.Nil {
+			return []domain.ConfigRevision{}, nil
+		}
+
+		return nil, cmd.Err()
+	}
+
+	revs := make([]domain.ConfigRevision, 0, len(cmd.Val()))
+	for _, val := range cmd.Val() {
+		var rev domain.ConfigRevision
+		err := domain.DecodeJSON([]byte(val), &rev)
+		if err != nil {
+			return nil, err
+		}
+
+		revs = append(revs, rev)
+	}
+
+	return revs, nil
+}
+
+func (repo *RedisRepo) GetRevision(name string, revision int) (domain.ConfigRevision, error) {
+	ctx := context.Background()
+	score := strconv.Itoa(revision)
+	cmd := repo.db.Client.ZRangeByScore(ctx, RevisionPrefix+name, &redis.ZRangeBy{
+		Min: score,
+		Max: score,
+	})
+
+	if cmd.Err() != nil && cmd.Err() != redis.Nil {
+		return domain.ConfigRevision{}, cmd.Err()
+	}
+
+	if len(cmd.Val()) == 0 {
+		return domain.ConfigRevision{}, ports.ErrRevisionNotExists
+	}
+
+	var rev domain.ConfigRevision
+	err := domain.DecodeJSON([]byte(cmd.Val()[0]), &rev)
+	return rev, err
+}
+
+func (repo *RedisRepo) GetRevisionAt(name string, at time.Time) (domain.ConfigRevision, error) {
+	ctx := context.Background()
+	cmd := repo.db.Client.ZRevRangeByScore(ctx, RevisionTimePrefix+name, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(at.Unix(), 10),
+		Count: 1,
+	})
+
+	if cmd.Err() != nil && cmd.Err() != redis.Nil {
+		return domain.ConfigRevision{}, cmd.Err()
+	}
+
+	if len(cmd.Val()) == 0 {
+		return domain.ConfigRevision{}, ports.ErrRevisionNotExists
+	}
+
+	revision, err := strconv.Atoi(cmd.Val()[0])
+	if err != nil {
+		return domain.ConfigRevision{}, err
+	}
+
+	return repo.GetRevision(name, revision)
+}
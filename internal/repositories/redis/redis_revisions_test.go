@@ -0,0 +1,147 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+func TestRevisions(t *testing.T) {
+	config := domain.DefaultConfig()
+	config.Redis.DB = DB
+	db, _ := GetRedisDB(&config)
+	defer db.Client.FlushDB(context.Background())
+
+	t.Run("Test revisions are numbered and read newest first", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		name := "TestRevisionsOK"
+		set := domain.NewConfigSet(name)
+		for _, action := range []domain.RevisionAction{domain.CreateAction, domain.AddItemAction, domain.RemoveItemAction} {
+			rev, err := repo.AddRevision(domain.ConfigRevision{
+				Action: action,
+				Set:    *set,
+			})
+
+			if err != nil {
+				t.Errorf("Expected revision to be added without errors, got: %v", err)
+			}
+
+			if rev.Date.IsZero() {
+				t.Errorf("Expected revision date to be set")
+			}
+		}
+
+		revs, err := repo.GetRevisions(name, 2, 0)
+		if err != nil {
+			t.Errorf("Expected revisions to be read without errors, got: %v", err)
+		}
+
+		if len(revs) != 2 {
+			t.Fatalf("Expected 2 revisions, got: %d", len(revs))
+		}
+
+		if revs[0].Revision != 3 || revs[0].Action != domain.RemoveItemAction {
+			t.Errorf("Expected revision 3 first, got: %+v", revs[0])
+		}
+
+		rev, err := repo.GetRevision(name, 2)
+		if err != nil {
+			t.Errorf("Expected revision to be read without errors, got: %v", err)
+		}
+
+		if rev.Revision != 2 || rev.Action != domain.AddItemAction || rev.Set.Name != name {
+			t.Errorf("Expected revision 2, got: %+v", rev)
+		}
+	})
+
+	t.Run("Test a non existing revision can't be read", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		_, err := repo.GetRevision("TestRevisionsNotFound", 1)
+		if err != ports.ErrRevisionNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrRevisionNotExists, err)
+		}
+
+		revs, err := repo.GetRevisions("TestRevisionsNotFound", 10, 0)
+		if err != nil || len(revs) != 0 {
+			t.Errorf("Expected no revisions without errors, got: %v, %v", revs, err)
+		}
+	})
+}
+
+func TestReplaceSet(t *testing.T) {
+	config := domain.DefaultConfig()
+	config.Redis.DB = DB
+	db, _ := GetRedisDB(&config)
+	defer db.Client.FlushDB(context.Background())
+
+	t.Run("Test a set can be replaced", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		name := "TestReplaceSetOK"
+		repo.CreateSet(*domain.NewConfigSet(name))
+
+		_, err := repo.ReplaceSet(*domain.NewConfigSet(name, *domain.NewConfigItem("key", "value", domain.Plain)))
+		if err != nil {
+			t.Errorf("Expected set to be replaced without errors, got: %v", err)
+		}
+
+		got, _ := repo.GetSet(name)
+		if _, err := got.Get("key"); err != nil {
+			t.Errorf("Expected replaced set to contain key, got: %v", err)
+		}
+	})
+
+	t.Run("Test a non existing set can't be replaced", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		_, err := repo.ReplaceSet(*domain.NewConfigSet("TestReplaceSetNotFound"))
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+	})
+}
+
+func TestGetRevisionAt(t *testing.T) {
+	config := domain.DefaultConfig()
+	config.Redis.DB = DB
+	db, _ := GetRedisDB(&config)
+	defer db.Client.FlushDB(context.Background())
+
+	t.Run("Test the latest revision at a given time is returned", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		name := "TestGetRevisionAtOK"
+		set := domain.NewConfigSet(name)
+		for i := 0; i < 11; i++ {
+			repo.AddRevision(domain.ConfigRevision{
+				Action: domain.AddItemAction,
+				Set:    *set,
+			})
+		}
+
+		// All revisions share the same second, the highest number must win
+		rev, err := repo.GetRevisionAt(name, time.Now().Add(time.Minute))
+		if err != nil {
+			t.Errorf("Expected revision to be read without errors, got: %v", err)
+		}
+
+		if rev.Revision != 11 {
+			t.Errorf("Expected revision: %d, got: %d", 11, rev.Revision)
+		}
+
+		_, err = repo.GetRevisionAt(name, time.Now().Add(-time.Minute))
+		if err != ports.ErrRevisionNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrRevisionNotExists, err)
+		}
+	})
+}
@@ -0,0 +1,297 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+const (
+	ScheduledPrefix     string = "sched:"
+	ScheduledDueKey     string = "sched:due"
+	ScheduledLeaseKey   string = "sched:leases"
+	FailedChangesPrefix string = "schedfailed:"
+	scheduledIDSplit    string = "/"
+)
+
+// dueMember identifies a change in the due sorted set, ids never contain the separator
+func dueMember(name string, id string) string {
+	return name + scheduledIDSplit + id
+}
+
+// dueScore returns the sorted set score of a time, in milliseconds so it fits a float64 exactly
+func dueScore(at time.Time) float64 {
+	return float64(at.UnixNano() / int64(time.Millisecond))
+}
+
+func (repo *RedisRepo) AddScheduledChange(change domain.ScheduledChange) (domain.ScheduledChange, error) {
+	ctx := context.Background()
+	jsonBytes, err := json.Marshal(change)
+	if err != nil {
+		return domain.ScheduledChange{}, err
+	}
+
+	_, err = repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.HSet(ctx, ScheduledPrefix+change.Set, change.ID, jsonBytes)
+		p.ZAdd(ctx, ScheduledDueKey, &redis.Z{
+			Score:  dueScore(change.EffectiveAt),
+			Member: dueMember(change.Set, change.ID),
+		})
+		return nil
+	})
+
+	if err != nil {
+		return domain.ScheduledChange{}, err
+	}
+
+	return change, nil
+}
+
+func (repo *RedisRepo) GetScheduledChanges(name string) ([]domain.ScheduledChange, error) {
+	ctx := context.Background()
+	cmd := repo.db.Client.HVals(ctx, ScheduledPrefix+name)
+	if cmd.Err() != nil {
+		return nil, cmd.Err()
+	}
+
+	changes := make([]domain.ScheduledChange, 0, len(cmd.Val()))
+	for _, value := range cmd.Val() {
+		var change domain.ScheduledChange
+		err := domain.DecodeJSON([]byte(value), &change)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].EffectiveAt.Equal(changes[j].EffectiveAt) {
+			return changes[i].CreateDate.Before(changes[j].CreateDate)
+		}
+
+		return changes[i].EffectiveAt.Before(changes[j].EffectiveAt)
+	})
+
+	return changes, nil
+}
+
+func (repo *RedisRepo) RemoveScheduledChange(name string, id string) (domain.ScheduledChange, error) {
+	ctx := context.Background()
+	// Removing the member is atomic, so only one caller can take the change
+	removed, err := repo.db.Client.ZRem(ctx, ScheduledDueKey, dueMember(name, id)).Result()
+	if err != nil {
+		return domain.ScheduledChange{}, err
+	}
+
+	if removed == 0 {
+		return domain.ScheduledChange{}, ports.ErrChangeNotExists
+	}
+
+	return repo.takeScheduledChange(ctx, name, id)
+}
+
+func (repo *RedisRepo) ClaimDueChanges(at time.Time, leaseUntil time.Time, limit int) ([]domain.ScheduledChange, error) {
+	ctx := context.Background()
+	changes := []domain.ScheduledChange{}
+	// Changes left by replicas that stopped before acknowledging them go first
+	expired, err := repo.dueMembers(ctx, ScheduledLeaseKey, at, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range expired {
+		claimed, err := repo.reclaimLease(ctx, member, at, leaseUntil)
+		if err != nil {
+			return changes, err
+		}
+
+		if claimed {
+			changes, err = repo.appendClaimed(ctx, changes, member)
+			if err != nil {
+				return changes, err
+			}
+		}
+	}
+
+	if len(changes) >= limit {
+		return changes, nil
+	}
+
+	due, err := repo.dueMembers(ctx, ScheduledDueKey, at, limit-len(changes))
+	if err != nil {
+		return changes, err
+	}
+
+	for _, member := range due {
+		var removed *redis.IntCmd
+		// Moving the member is atomic, so only the caller that removed it from the due set owns the lease
+		_, err := repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			removed = p.ZRem(ctx, ScheduledDueKey, member)
+			p.ZAdd(ctx, ScheduledLeaseKey, &redis.Z{Score: dueScore(leaseUntil), Member: member})
+			return nil
+		})
+		if err != nil {
+			return changes, err
+		}
+
+		if removed.Val() == 0 {
+			// Claimed by another replica
+			continue
+		}
+
+		changes, err = repo.appendClaimed(ctx, changes, member)
+		if err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, nil
+}
+
+func (repo *RedisRepo) AckScheduledChange(name string, id string) error {
+	ctx := context.Background()
+	_, err := repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZRem(ctx, ScheduledLeaseKey, dueMember(name, id))
+		p.HDel(ctx, ScheduledPrefix+name, id)
+		return nil
+	})
+
+	return err
+}
+
+func (repo *RedisRepo) FailScheduledChange(change domain.FailedChange) error {
+	ctx := context.Background()
+	jsonBytes, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZRem(ctx, ScheduledLeaseKey, dueMember(change.Set, change.ID))
+		p.HDel(ctx, ScheduledPrefix+change.Set, change.ID)
+		p.HSet(ctx, FailedChangesPrefix+change.Set, change.ID, jsonBytes)
+		return nil
+	})
+
+	return err
+}
+
+func (repo *RedisRepo) GetFailedChanges(name string) ([]domain.FailedChange, error) {
+	ctx := context.Background()
+	cmd := repo.db.Client.HVals(ctx, FailedChangesPrefix+name)
+	if cmd.Err() != nil {
+		return nil, cmd.Err()
+	}
+
+	changes := make([]domain.FailedChange, 0, len(cmd.Val()))
+	for _, value := range cmd.Val() {
+		var change domain.FailedChange
+		err := domain.DecodeJSON([]byte(value), &change)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].FailDate.After(changes[j].FailDate)
+	})
+
+	return changes, nil
+}
+
+// dueMembers returns up to limit members of the given sorted set with a score at or before the given time
+func (repo *RedisRepo) dueMembers(ctx context.Context, key string, at time.Time, limit int) ([]string, error) {
+	return repo.db.Client.ZRangeByScore(ctx, key, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatFloat(dueScore(at), 'f', 0, 64),
+		Count: int64(limit),
+	}).Result()
+}
+
+// reclaimLease extends the lease of a member if it's still expired at the given time,
+// returns false if another replica changed the leases first
+func (repo *RedisRepo) reclaimLease(ctx context.Context, member string, at time.Time, leaseUntil time.Time) (bool, error) {
+	claimed := false
+	err := repo.db.Client.Watch(ctx, func(tx *redis.Tx) error {
+		score, err := tx.ZScore(ctx, ScheduledLeaseKey, member).Result()
+		if err == redis.Nil || (err == nil && score > dueScore(at)) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.TxPipelined(ctx, func(p redis.Pipeliner) error {
+			p.ZAdd(ctx, ScheduledLeaseKey, &redis.Z{Score: dueScore(leaseUntil), Member: member})
+			return nil
+		})
+		claimed = err == nil
+		return err
+	}, ScheduledLeaseKey)
+
+	if err == redis.TxFailedErr {
+		return false, nil
+	}
+
+	return claimed, err
+}
+
+// appendClaimed reads the change of a claimed member and appends it to changes,
+// leases left without a change are dropped
+func (repo *RedisRepo) appendClaimed(ctx context.Context, changes []domain.ScheduledChange, member string) ([]domain.ScheduledChange, error) {
+	separator := strings.LastIndex(member, scheduledIDSplit)
+	if separator < 0 {
+		return changes, repo.db.Client.ZRem(ctx, ScheduledLeaseKey, member).Err()
+	}
+
+	name, id := member[:separator], member[separator+1:]
+	value, err := repo.db.Client.HGet(ctx, ScheduledPrefix+name, id).Result()
+	if err == redis.Nil {
+		return changes, repo.db.Client.ZRem(ctx, ScheduledLeaseKey, member).Err()
+	}
+
+	if err != nil {
+		return changes, err
+	}
+
+	var change domain.ScheduledChange
+	err = domain.DecodeJSON([]byte(value), &change)
+	if err != nil {
+		return changes, err
+	}
+
+	return append(changes, change), nil
+}
+
+// takeScheduledChange reads and deletes the stored change once its due member was removed
+func (repo *RedisRepo) takeScheduledChange(ctx context.Context, name string, id string) (domain.ScheduledChange, error) {
+	var getCmd *redis.StringCmd
+	_, err := repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		getCmd = p.HGet(ctx, ScheduledPrefix+name, id)
+		p.HDel(ctx, ScheduledPrefix+name, id)
+		return nil
+	})
+
+	if err == redis.Nil {
+		return domain.ScheduledChange{}, ports.ErrChangeNotExists
+	}
+
+	if err != nil {
+		return domain.ScheduledChange{}, err
+	}
+
+	var change domain.ScheduledChange
+	err = domain.DecodeJSON([]byte(getCmd.Val()), &change)
+	return change, err
+}
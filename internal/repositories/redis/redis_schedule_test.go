@@ -0,0 +1,167 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+func TestScheduledChanges(t *testing.T) {
+	config := domain.DefaultConfig()
+	config.Redis.DB = DB
+	db, _ := GetRedisDB(&config)
+	defer db.Client.FlushDB(context.Background())
+
+	t.Run("Test scheduled changes can be listed, claimed and removed", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		now := datetime.UnixUTCNow()
+		for i, offset := range []time.Duration{time.Hour, -time.Minute, -time.Hour} {
+			repo.AddScheduledChange(domain.ScheduledChange{
+				ID:          fmt.Sprintf("change-%d", i),
+				Set:         "app",
+				Operation:   domain.UpdateChange,
+				Item:        *domain.NewConfigItem("banner", "on", domain.Plain),
+				EffectiveAt: now.Add(offset),
+			})
+		}
+
+		changes, err := repo.GetScheduledChanges("app")
+		if err != nil || len(changes) != 3 || changes[0].ID != "change-2" || changes[2].ID != "change-0" {
+			t.Errorf("Expected changes sorted by effective time, got: %+v, %v", changes, err)
+		}
+
+		leaseUntil := now.Add(time.Minute)
+		claimed, err := repo.ClaimDueChanges(now, leaseUntil, 10)
+		if err != nil || len(claimed) != 2 || claimed[0].ID != "change-2" || claimed[1].ID != "change-1" {
+			t.Errorf("Expected the due changes to be claimed, got: %+v, %v", claimed, err)
+		}
+
+		claimed, _ = repo.ClaimDueChanges(now, leaseUntil, 10)
+		if len(claimed) != 0 {
+			t.Errorf("Expected changes to be claimed only once, got: %+v", claimed)
+		}
+
+		changes, _ = repo.GetScheduledChanges("app")
+		if len(changes) != 3 {
+			t.Errorf("Expected claimed changes to be listed until acknowledged, got: %+v", changes)
+		}
+
+		_, err = repo.RemoveScheduledChange("app", "change-1")
+		if err != ports.ErrChangeNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrChangeNotExists, err)
+		}
+
+		// A replica that stopped midway leaves its changes to be claimed again
+		claimed, _ = repo.ClaimDueChanges(leaseUntil, leaseUntil.Add(time.Minute), 10)
+		if len(claimed) != 2 {
+			t.Errorf("Expected expired leases to be claimed again, got: %+v", claimed)
+		}
+
+		err = repo.AckScheduledChange("app", "change-2")
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+
+		failing := claimed[0]
+		if failing.ID != "change-1" {
+			failing = claimed[1]
+		}
+
+		err = repo.FailScheduledChange(domain.NewFailedChange(failing, ports.ErrConfigNotExists, now))
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+
+		failed, err := repo.GetFailedChanges("app")
+		if err != nil || len(failed) != 1 || failed[0].ID != "change-1" || failed[0].Error != ports.ErrConfigNotExists.Error() {
+			t.Errorf("Expected the failed change to be kept, got: %+v, %v", failed, err)
+		}
+
+		claimed, _ = repo.ClaimDueChanges(leaseUntil.Add(time.Hour), leaseUntil.Add(2*time.Hour), 10)
+		if len(claimed) != 1 || claimed[0].ID != "change-0" {
+			t.Errorf("Expected acknowledged and failed changes to be gone, got: %+v", claimed)
+		}
+		repo.AckScheduledChange("app", "change-0")
+
+		changes, _ = repo.GetScheduledChanges("app")
+		if len(changes) != 0 {
+			t.Errorf("Expected no pending changes, got: %+v", changes)
+		}
+	})
+
+	t.Run("Test pending changes can be removed until claimed", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		now := datetime.UnixUTCNow()
+		repo.AddScheduledChange(domain.ScheduledChange{
+			ID:          "change-0",
+			Set:         "app",
+			Operation:   domain.RemoveChange,
+			Item:        domain.ConfigItem{Key: "banner"},
+			EffectiveAt: now.Add(time.Hour),
+		})
+
+		removed, err := repo.RemoveScheduledChange("app", "change-0")
+		if err != nil || removed.ID != "change-0" {
+			t.Errorf("Expected change to be removed, got: %+v, %v", removed, err)
+		}
+
+		_, err = repo.RemoveScheduledChange("app", "change-0")
+		if err != ports.ErrChangeNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrChangeNotExists, err)
+		}
+	})
+
+	t.Run("Test concurrent claims return each change once", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		now := datetime.UnixUTCNow()
+		total := 50
+		for i := 0; i < total; i++ {
+			repo.AddScheduledChange(domain.ScheduledChange{
+				ID:          fmt.Sprintf("change-%d", i),
+				Set:         "app",
+				Operation:   domain.RemoveChange,
+				Item:        domain.ConfigItem{Key: "banner"},
+				EffectiveAt: now.Add(-time.Minute),
+			})
+		}
+
+		var lock sync.Mutex
+		var wg sync.WaitGroup
+		seen := map[string]int{}
+		for replica := 0; replica < 4; replica++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				claimed, _ := NewRedisRepo(&config, db).ClaimDueChanges(now, now.Add(time.Minute), total)
+				lock.Lock()
+				defer lock.Unlock()
+				for _, change := range claimed {
+					seen[change.ID]++
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(seen) != total {
+			t.Errorf("Expected %d claimed changes, got: %d", total, len(seen))
+		}
+
+		for id, count := range seen {
+			if count != 1 {
+				t.Errorf("Expected %q to be claimed once, got: %d", id, count)
+			}
+		}
+	})
+}
@@ -0,0 +1,244 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+const (
+	TrashPrefix string = "trash:set:"
+	TrashNames  string = "trash:names"
+)
+
+// purgeScore returns the sorted set score of a purge date, sets without one are sorted last
+func purgeScore(purgeDate *time.Time) float64 {
+	if purgeDate == nil {
+		return math.Inf(1)
+	}
+
+	return dueScore(*purgeDate)
+}
+
+func (repo *RedisRepo) TrashSet(name string, purgeDate *time.Time) (domain.TrashedSet, error) {
+	ctx := context.Background()
+	key := CfgSetPrefix + name
+	cmd := repo.db.Client.Get(ctx, key)
+	if cmd.Err() != nil {
+		if cmd.Err() == redis.Nil {
+			return domain.TrashedSet{}, ports.ErrConfigNotExists
+		}
+		return domain.TrashedSet{}, cmd.Err()
+	}
+
+	var set domain.ConfigSet
+	err := domain.DecodeJSON([]byte(cmd.Val()), &set)
+	if err != nil {
+		return domain.TrashedSet{}, err
+	}
+
+	trashed := domain.TrashedSet{Set: set, DeleteDate: datetime.UnixUTCNow(), PurgeDate: purgeDate}
+	jsonBytes, err := json.Marshal(trashed)
+	if err != nil {
+		return domain.TrashedSet{}, err
+	}
+
+	// Only one trashed set can have the name, a concurrent delete can't replace it either
+	saved, err := repo.db.Client.SetNX(ctx, TrashPrefix+name, jsonBytes, 0).Result()
+	if err != nil {
+		return domain.TrashedSet{}, err
+	}
+
+	if !saved {
+		return domain.TrashedSet{}, ports.ErrTrashExists
+	}
+
+	_, err = repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZAdd(ctx, TrashNames, &redis.Z{Score: purgeScore(purgeDate), Member: name})
+		p.Del(ctx, key)
+		p.ZRem(ctx, CfgSetNames, key)
+		unindexLabels(ctx, p, name, set.Labels)
+		return nil
+	})
+
+	if err != nil {
+		repo.db.Client.Del(ctx, TrashPrefix+name)
+		return domain.TrashedSet{}, err
+	}
+
+	return trashed, nil
+}
+
+func (repo *RedisRepo) GetTrashedSets(limit int, skip int) ([]domain.TrashedSet, error) {
+	ctx := context.Background()
+	names, err := repo.db.Client.ZRange(ctx, TrashNames, int64(skip), int64(skip+limit-1)).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	trashed := make([]domain.TrashedSet, 0, len(names))
+	if len(names) == 0 {
+		return trashed, nil
+	}
+
+	keys := make([]string, len(names))
+	for i, name := range names {
+		keys[i] = TrashPrefix + name
+	}
+
+	values, err := repo.db.Client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, value := range values {
+		// Purged since the names were read
+		str, ok := value.(string)
+		if !ok {
+			continue
+		}
+
+		var set domain.TrashedSet
+		err := domain.DecodeJSON([]byte(str), &set)
+		if err != nil {
+			return nil, err
+		}
+
+		trashed = append(trashed, set)
+	}
+
+	return trashed, nil
+}
+
+func (repo *RedisRepo) RestoreSet(name string) (domain.ConfigSet, error) {
+	ctx := context.Background()
+	key := CfgSetPrefix + name
+	exists, err := repo.db.Client.Exists(ctx, key).Result()
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	if exists == 1 {
+		return domain.ConfigSet{}, ports.ErrDuplicatedConfig
+	}
+
+	trashed, err := repo.PurgeSet(name)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	jsonBytes, err := json.Marshal(trashed.Set)
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	created, err := repo.db.Client.SetNX(ctx, key, jsonBytes, 0).Result()
+	if err == nil && !created {
+		err = ports.ErrDuplicatedConfig
+	}
+
+	if err != nil {
+		// Created concurrently, the trashed set must not be lost
+		repo.putTrash(ctx, trashed)
+		return domain.ConfigSet{}, err
+	}
+
+	_, err = repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		// Keep the position the set had in the names list
+		p.ZAdd(ctx, CfgSetNames, &redis.Z{
+			Score:  float64(trashed.Set.CreateDate.UnixNano()),
+			Member: key,
+		})
+		indexLabels(ctx, p, name, trashed.Set.Labels)
+		return nil
+	})
+	if err != nil {
+		return domain.ConfigSet{}, err
+	}
+
+	return trashed.Set, nil
+}
+
+func (repo *RedisRepo) PurgeSet(name string) (domain.TrashedSet, error) {
+	ctx := context.Background()
+	// Removing the member is atomic, so only one caller can take the set
+	removed, err := repo.db.Client.ZRem(ctx, TrashNames, name).Result()
+	if err != nil {
+		return domain.TrashedSet{}, err
+	}
+
+	if removed == 0 {
+		return domain.TrashedSet{}, ports.ErrTrashNotExists
+	}
+
+	var getCmd *redis.StringCmd
+	_, err = repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		getCmd = p.Get(ctx, TrashPrefix+name)
+		p.Del(ctx, TrashPrefix+name)
+		return nil
+	})
+
+	if err == redis.Nil {
+		return domain.TrashedSet{}, ports.ErrTrashNotExists
+	}
+
+	if err != nil {
+		return domain.TrashedSet{}, err
+	}
+
+	var trashed domain.TrashedSet
+	err = domain.DecodeJSON([]byte(getCmd.Val()), &trashed)
+	return trashed, err
+}
+
+func (repo *RedisRepo) PurgeExpiredSets(at time.Time, limit int) ([]domain.TrashedSet, error) {
+	ctx := context.Background()
+	names, err := repo.db.Client.ZRangeByScore(ctx, TrashNames, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatFloat(dueScore(at), 'f', 0, 64),
+		Count: int64(limit),
+	}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	purged := []domain.TrashedSet{}
+	for _, name := range names {
+		trashed, err := repo.PurgeSet(name)
+		if err == ports.ErrTrashNotExists {
+			// Purged or restored by another caller
+			continue
+		}
+
+		if err != nil {
+			return purged, err
+		}
+
+		purged = append(purged, trashed)
+	}
+
+	return purged, nil
+}
+
+// putTrash saves a trashed set again after a failed restore
+func (repo *RedisRepo) putTrash(ctx context.Context, trashed domain.TrashedSet) error {
+	jsonBytes, err := json.Marshal(trashed)
+	if err != nil {
+		return err
+	}
+
+	_, err = repo.db.Client.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.Set(ctx, TrashPrefix+trashed.Set.Name, jsonBytes, 0)
+		p.ZAdd(ctx, TrashNames, &redis.Z{Score: purgeScore(trashed.PurgeDate), Member: trashed.Set.Name})
+		return nil
+	})
+
+	return err
+}
@@ -0,0 +1,143 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sy-software/minerva-go-utils/datetime"
+	"github.com/sy-software/minerva-olive/internal/core/domain"
+	"github.com/sy-software/minerva-olive/internal/core/ports"
+)
+
+func TestTrash(t *testing.T) {
+	config := domain.DefaultConfig()
+	config.Redis.DB = DB
+	db, _ := GetRedisDB(&config)
+	defer db.Client.FlushDB(context.Background())
+
+	t.Run("Test trashed sets can be listed and restored", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		repo.CreateSet(*domain.NewConfigSet("app", *domain.NewConfigItem("timeout", "10s", domain.Plain)))
+		repo.CreateSet(*domain.NewConfigSet("api"))
+
+		purgeDate := datetime.UnixUTCNow().Add(time.Hour)
+		trashed, err := repo.TrashSet("app", &purgeDate)
+		if err != nil || trashed.Set.Name != "app" {
+			t.Fatalf("Expected set to be trashed, got: %+v, %v", trashed, err)
+		}
+
+		repo.TrashSet("api", nil)
+		_, err = repo.GetSet("app")
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+
+		names, _ := repo.GetSetNames(10, 0)
+		if len(names) != 0 {
+			t.Errorf("Expected trashed sets to be unlisted, got: %v", names)
+		}
+
+		list, err := repo.GetTrashedSets(10, 0)
+		if err != nil || len(list) != 2 || list[0].Set.Name != "app" || list[1].Set.Name != "api" {
+			t.Errorf("Expected trashed sets sorted by purge date, got: %+v, %v", list, err)
+		}
+
+		set, err := repo.RestoreSet("app")
+		if err != nil || set.Items["timeout"].Value != "10s" {
+			t.Errorf("Expected set to be restored, got: %+v, %v", set, err)
+		}
+
+		names, _ = repo.GetSetNames(10, 0)
+		if len(names) != 1 || names[0] != CfgSetPrefix+"app" {
+			t.Errorf("Expected restored set to be listed, got: %v", names)
+		}
+
+		_, err = repo.RestoreSet("app")
+		if err != ports.ErrDuplicatedConfig {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrDuplicatedConfig, err)
+		}
+
+		repo.CreateSet(*domain.NewConfigSet("api"))
+		_, err = repo.RestoreSet("api")
+		if err != ports.ErrDuplicatedConfig {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrDuplicatedConfig, err)
+		}
+
+		list, _ = repo.GetTrashedSets(10, 0)
+		if len(list) != 1 || list[0].Set.Name != "api" {
+			t.Errorf("Expected a conflicting set to stay in the trash, got: %+v", list)
+		}
+	})
+
+	t.Run("Test trashed sets are purged once", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		now := datetime.UnixUTCNow()
+		expired := now.Add(-time.Minute)
+		later := now.Add(time.Hour)
+		for name, purgeDate := range map[string]*time.Time{"expired": &expired, "later": &later, "kept": nil} {
+			repo.CreateSet(*domain.NewConfigSet(name))
+			repo.TrashSet(name, purgeDate)
+		}
+
+		purged, err := repo.PurgeExpiredSets(now, 10)
+		if err != nil || len(purged) != 1 || purged[0].Set.Name != "expired" {
+			t.Errorf("Expected the expired set to be purged, got: %+v, %v", purged, err)
+		}
+
+		purged, _ = repo.PurgeExpiredSets(now, 10)
+		if len(purged) != 0 {
+			t.Errorf("Expected sets to be purged only once, got: %+v", purged)
+		}
+
+		_, err = repo.PurgeSet("kept")
+		if err != nil {
+			t.Errorf("Expected no error, got: %v", err)
+		}
+
+		_, err = repo.PurgeSet("kept")
+		if err != ports.ErrTrashNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrTrashNotExists, err)
+		}
+
+		_, err = repo.RestoreSet("kept")
+		if err != ports.ErrTrashNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrTrashNotExists, err)
+		}
+
+		_, err = repo.TrashSet("missing", nil)
+		if err != ports.ErrConfigNotExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrConfigNotExists, err)
+		}
+	})
+
+	t.Run("Test trashed sets are not replaced by a later delete", func(t *testing.T) {
+		repo := NewRedisRepo(&config, db)
+		db.Client.FlushDB(context.Background())
+
+		first := *domain.NewConfigSet("app")
+		first.Items["timeout"] = *domain.NewConfigItem("timeout", "10s", domain.Plain)
+		repo.CreateSet(first)
+		repo.TrashSet("app", nil)
+
+		repo.CreateSet(*domain.NewConfigSet("app"))
+		_, err := repo.TrashSet("app", nil)
+		if err != ports.ErrTrashExists {
+			t.Errorf("Expected error: %v, got: %v", ports.ErrTrashExists, err)
+		}
+
+		_, err = repo.GetSet("app")
+		if err != nil {
+			t.Errorf("Expected the set to be kept, got: %v", err)
+		}
+
+		list, _ := repo.GetTrashedSets(10, 0)
+		if len(list) != 1 || list[0].Set.Items["timeout"].Value != "10s" {
+			t.Errorf("Expected the first trashed set to be kept, got: %+v", list)
+		}
+	})
+}
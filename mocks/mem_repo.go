@@ -16,16 +16,53 @@ type CacheItem struct {
 }
 
 type MemRepo struct {
-	Sets  map[string]*domain.ConfigSet
-	Cache map[string]CacheItem
-
-	CreateSetInterceptor   func(set domain.ConfigSet) (domain.ConfigSet, error)
-	GetSetInterceptor      func(name string) (*domain.ConfigSet, error)
-	GetSetNamesInterceptor func(count int, skip int) ([]string, error)
-	DeleteSetInterceptor   func(name string) (domain.ConfigSet, error)
-	AddItemInterceptor     func(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
-	UpdateItemInterceptor  func(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
-	RemoveItemInterceptor  func(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
+	Sets      map[string]*domain.ConfigSet
+	Cache     map[string]CacheItem
+	Revisions map[string][]domain.ConfigRevision
+	Referrers map[string]map[string]bool
+	Canaries  map[string]domain.Canary
+	Scheduled map[string]domain.ScheduledChange
+	Leases    map[string]time.Time
+	Failed    map[string]domain.FailedChange
+	Trash     map[string]domain.TrashedSet
+
+	CreateSetInterceptor           func(set domain.ConfigSet) (domain.ConfigSet, error)
+	GetSetInterceptor              func(name string) (*domain.ConfigSet, error)
+	GetSetNamesInterceptor         func(count int, skip int) ([]string, error)
+	GetSetNamesByLabelsInterceptor func(labels map[string]string, count int, skip int) ([]string, error)
+	ListSetsInterceptor            func(count int, skip int) ([]domain.ConfigSet, error)
+	DeleteSetInterceptor           func(name string) (domain.ConfigSet, error)
+	AddItemInterceptor             func(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
+	UpdateItemInterceptor          func(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
+	RemoveItemInterceptor          func(item domain.ConfigItem, setName string) (domain.ConfigSet, error)
+	ReplaceSetInterceptor          func(set domain.ConfigSet) (domain.ConfigSet, error)
+
+	AddRevisionInterceptor   func(rev domain.ConfigRevision) (domain.ConfigRevision, error)
+	GetRevisionsInterceptor  func(name string, limit int, skip int) ([]domain.ConfigRevision, error)
+	GetRevisionInterceptor   func(name string, revision int) (domain.ConfigRevision, error)
+	GetRevisionAtInterceptor func(name string, at time.Time) (domain.ConfigRevision, error)
+
+	AddReferrerInterceptor    func(target string, referrer string) error
+	RemoveReferrerInterceptor func(target string, referrer string) error
+	GetReferrersInterceptor   func(target string) ([]string, error)
+
+	SaveCanaryInterceptor   func(canary domain.Canary) (domain.Canary, error)
+	GetCanaryInterceptor    func(name string) (domain.Canary, error)
+	DeleteCanaryInterceptor func(name string) (domain.Canary, error)
+
+	AddScheduledChangeInterceptor    func(change domain.ScheduledChange) (domain.ScheduledChange, error)
+	GetScheduledChangesInterceptor   func(name string) ([]domain.ScheduledChange, error)
+	RemoveScheduledChangeInterceptor func(name string, id string) (domain.ScheduledChange, error)
+	ClaimDueChangesInterceptor       func(at time.Time, leaseUntil time.Time, limit int) ([]domain.ScheduledChange, error)
+	AckScheduledChangeInterceptor    func(name string, id string) error
+	FailScheduledChangeInterceptor   func(change domain.FailedChange) error
+	GetFailedChangesInterceptor      func(name string) ([]domain.FailedChange, error)
+
+	TrashSetInterceptor         func(name string, purgeDate *time.Time) (domain.TrashedSet, error)
+	GetTrashedSetsInterceptor   func(limit int, skip int) ([]domain.TrashedSet, error)
+	RestoreSetInterceptor       func(name string) (domain.ConfigSet, error)
+	PurgeSetInterceptor         func(name string) (domain.TrashedSet, error)
+	PurgeExpiredSetsInterceptor func(at time.Time, limit int) ([]domain.TrashedSet, error)
 
 	SaveJSONInterceptor   func(json []byte, key string, ttl int) error
 	GetJSONInterceptor    func(key string, maxAge int) ([]byte, error)
@@ -34,8 +71,15 @@ type MemRepo struct {
 
 func NewMockRepo() *MemRepo {
 	return &MemRepo{
-		Sets:  make(map[string]*domain.ConfigSet),
-		Cache: make(map[string]CacheItem),
+		Sets:      make(map[string]*domain.ConfigSet),
+		Cache:     make(map[string]CacheItem),
+		Revisions: make(map[string][]domain.ConfigRevision),
+		Referrers: make(map[string]map[string]bool),
+		Canaries:  make(map[string]domain.Canary),
+		Scheduled: make(map[string]domain.ScheduledChange),
+		Leases:    make(map[string]time.Time),
+		Failed:    make(map[string]domain.FailedChange),
+		Trash:     make(map[string]domain.TrashedSet),
 	}
 }
 
@@ -92,6 +136,48 @@ func (repo *MemRepo) GetSetNames(limit int, skip int) ([]string, error) {
 	return keys[skip : skip+capLimit], nil
 }
 
+func (repo *MemRepo) GetSetNamesByLabels(labels map[string]string, limit int, skip int) ([]string, error) {
+	if repo.GetSetNamesByLabelsInterceptor != nil {
+		return repo.GetSetNamesByLabelsInterceptor(labels, limit, skip)
+	}
+
+	names := []string{}
+	for name, set := range repo.Sets {
+		if set.HasLabels(labels) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	if skip >= len(names) {
+		return []string{}, nil
+	}
+
+	if limit > len(names)-skip {
+		limit = len(names) - skip
+	}
+
+	return names[skip : skip+limit], nil
+}
+
+func (repo *MemRepo) ListSets(limit int, skip int) ([]domain.ConfigSet, error) {
+	if repo.ListSetsInterceptor != nil {
+		return repo.ListSetsInterceptor(limit, skip)
+	}
+
+	names, err := repo.GetSetNames(limit, skip)
+	if err != nil {
+		return nil, err
+	}
+
+	sets := make([]domain.ConfigSet, 0, len(names))
+	for _, name := range names {
+		sets = append(sets, *repo.Sets[name])
+	}
+
+	return sets, nil
+}
+
 func (repo *MemRepo) DeleteSet(name string) (domain.ConfigSet, error) {
 	if repo.DeleteSetInterceptor != nil {
 		return repo.DeleteSetInterceptor(name)
@@ -166,6 +252,77 @@ func (repo *MemRepo) RemoveItem(item domain.ConfigItem, setName string) (domain.
 	return *set, nil
 }
 
+func (repo *MemRepo) ReplaceSet(set domain.ConfigSet) (domain.ConfigSet, error) {
+	if repo.ReplaceSetInterceptor != nil {
+		return repo.ReplaceSetInterceptor(set)
+	}
+
+	_, exists := repo.Sets[set.Name]
+	if !exists {
+		return domain.ConfigSet{}, ports.ErrConfigNotExists
+	}
+
+	repo.Sets[set.Name] = &set
+	return set, nil
+}
+
+// Revisions
+
+func (repo *MemRepo) AddRevision(rev domain.ConfigRevision) (domain.ConfigRevision, error) {
+	if repo.AddRevisionInterceptor != nil {
+		return repo.AddRevisionInterceptor(rev)
+	}
+
+	name := rev.Set.Name
+	rev.Revision = len(repo.Revisions[name]) + 1
+	rev.Date = datetime.UnixUTCNow()
+	rev.Set = rev.Set.Copy()
+	repo.Revisions[name] = append(repo.Revisions[name], rev)
+	return rev, nil
+}
+
+func (repo *MemRepo) GetRevisions(name string, limit int, skip int) ([]domain.ConfigRevision, error) {
+	if repo.GetRevisionsInterceptor != nil {
+		return repo.GetRevisionsInterceptor(name, limit, skip)
+	}
+
+	revs := repo.Revisions[name]
+	result := []domain.ConfigRevision{}
+	for i := len(revs) - 1 - skip; i >= 0 && len(result) < limit; i-- {
+		result = append(result, revs[i])
+	}
+
+	return result, nil
+}
+
+func (repo *MemRepo) GetRevision(name string, revision int) (domain.ConfigRevision, error) {
+	if repo.GetRevisionInterceptor != nil {
+		return repo.GetRevisionInterceptor(name, revision)
+	}
+
+	revs := repo.Revisions[name]
+	if revision < 1 || revision > len(revs) {
+		return domain.ConfigRevision{}, ports.ErrRevisionNotExists
+	}
+
+	return revs[revision-1], nil
+}
+
+func (repo *MemRepo) GetRevisionAt(name string, at time.Time) (domain.ConfigRevision, error) {
+	if repo.GetRevisionAtInterceptor != nil {
+		return repo.GetRevisionAtInterceptor(name, at)
+	}
+
+	revs := repo.Revisions[name]
+	for i := len(revs) - 1; i >= 0; i-- {
+		if !revs[i].Date.After(at) {
+			return revs[i], nil
+		}
+	}
+
+	return domain.ConfigRevision{}, ports.ErrRevisionNotExists
+}
+
 // Cache
 
 func (repo *MemRepo) SaveJSON(json []byte, key string, ttl int) error {
@@ -212,3 +369,300 @@ func (repo *MemRepo) RemoveJSON(key string) error {
 
 	return nil
 }
+
+func (repo *MemRepo) AddReferrer(target string, referrer string) error {
+	if repo.AddReferrerInterceptor != nil {
+		return repo.AddReferrerInterceptor(target, referrer)
+	}
+
+	if repo.Referrers[target] == nil {
+		repo.Referrers[target] = map[string]bool{}
+	}
+
+	repo.Referrers[target][referrer] = true
+	return nil
+}
+
+func (repo *MemRepo) RemoveReferrer(target string, referrer string) error {
+	if repo.RemoveReferrerInterceptor != nil {
+		return repo.RemoveReferrerInterceptor(target, referrer)
+	}
+
+	delete(repo.Referrers[target], referrer)
+	return nil
+}
+
+func (repo *MemRepo) GetReferrers(target string) ([]string, error) {
+	if repo.GetReferrersInterceptor != nil {
+		return repo.GetReferrersInterceptor(target)
+	}
+
+	names := []string{}
+	for name := range repo.Referrers[target] {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+func (repo *MemRepo) SaveCanary(canary domain.Canary) (domain.Canary, error) {
+	if repo.SaveCanaryInterceptor != nil {
+		return repo.SaveCanaryInterceptor(canary)
+	}
+
+	repo.Canaries[canary.Set] = canary
+	return canary, nil
+}
+
+func (repo *MemRepo) GetCanary(name string) (domain.Canary, error) {
+	if repo.GetCanaryInterceptor != nil {
+		return repo.GetCanaryInterceptor(name)
+	}
+
+	canary, exists := repo.Canaries[name]
+	if !exists {
+		return domain.Canary{}, ports.ErrCanaryNotExists
+	}
+
+	return canary, nil
+}
+
+func (repo *MemRepo) DeleteCanary(name string) (domain.Canary, error) {
+	if repo.DeleteCanaryInterceptor != nil {
+		return repo.DeleteCanaryInterceptor(name)
+	}
+
+	canary, exists := repo.Canaries[name]
+	if !exists {
+		return domain.Canary{}, ports.ErrCanaryNotExists
+	}
+
+	delete(repo.Canaries, name)
+	return canary, nil
+}
+
+func (repo *MemRepo) AddScheduledChange(change domain.ScheduledChange) (domain.ScheduledChange, error) {
+	if repo.AddScheduledChangeInterceptor != nil {
+		return repo.AddScheduledChangeInterceptor(change)
+	}
+
+	repo.Scheduled[change.ID] = change
+	return change, nil
+}
+
+func (repo *MemRepo) GetScheduledChanges(name string) ([]domain.ScheduledChange, error) {
+	if repo.GetScheduledChangesInterceptor != nil {
+		return repo.GetScheduledChangesInterceptor(name)
+	}
+
+	changes := []domain.ScheduledChange{}
+	for _, change := range repo.sortedChanges() {
+		if change.Set == name {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+func (repo *MemRepo) RemoveScheduledChange(name string, id string) (domain.ScheduledChange, error) {
+	if repo.RemoveScheduledChangeInterceptor != nil {
+		return repo.RemoveScheduledChangeInterceptor(name, id)
+	}
+
+	change, exists := repo.Scheduled[id]
+	_, leased := repo.Leases[id]
+	if !exists || leased || change.Set != name {
+		return domain.ScheduledChange{}, ports.ErrChangeNotExists
+	}
+
+	delete(repo.Scheduled, id)
+	return change, nil
+}
+
+func (repo *MemRepo) ClaimDueChanges(at time.Time, leaseUntil time.Time, limit int) ([]domain.ScheduledChange, error) {
+	if repo.ClaimDueChangesInterceptor != nil {
+		return repo.ClaimDueChangesInterceptor(at, leaseUntil, limit)
+	}
+
+	changes := []domain.ScheduledChange{}
+	for _, change := range repo.sortedChanges() {
+		if len(changes) == limit {
+			break
+		}
+
+		lease, leased := repo.Leases[change.ID]
+		if (leased && lease.After(at)) || (!leased && change.EffectiveAt.After(at)) {
+			continue
+		}
+
+		repo.Leases[change.ID] = leaseUntil
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+func (repo *MemRepo) AckScheduledChange(name string, id string) error {
+	if repo.AckScheduledChangeInterceptor != nil {
+		return repo.AckScheduledChangeInterceptor(name, id)
+	}
+
+	delete(repo.Leases, id)
+	delete(repo.Scheduled, id)
+	return nil
+}
+
+func (repo *MemRepo) FailScheduledChange(change domain.FailedChange) error {
+	if repo.FailScheduledChangeInterceptor != nil {
+		return repo.FailScheduledChangeInterceptor(change)
+	}
+
+	delete(repo.Leases, change.ID)
+	delete(repo.Scheduled, change.ID)
+	repo.Failed[change.ID] = change
+	return nil
+}
+
+func (repo *MemRepo) GetFailedChanges(name string) ([]domain.FailedChange, error) {
+	if repo.GetFailedChangesInterceptor != nil {
+		return repo.GetFailedChangesInterceptor(name)
+	}
+
+	changes := []domain.FailedChange{}
+	for _, change := range repo.Failed {
+		if change.Set == name {
+			changes = append(changes, change)
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].FailDate.After(changes[j].FailDate)
+	})
+
+	return changes, nil
+}
+
+func (repo *MemRepo) sortedChanges() []domain.ScheduledChange {
+	changes := make([]domain.ScheduledChange, 0, len(repo.Scheduled))
+	for _, change := range repo.Scheduled {
+		changes = append(changes, change)
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].EffectiveAt.Equal(changes[j].EffectiveAt) {
+			return changes[i].CreateDate.Before(changes[j].CreateDate)
+		}
+
+		return changes[i].EffectiveAt.Before(changes[j].EffectiveAt)
+	})
+
+	return changes
+}
+
+func (repo *MemRepo) TrashSet(name string, purgeDate *time.Time) (domain.TrashedSet, error) {
+	if repo.TrashSetInterceptor != nil {
+		return repo.TrashSetInterceptor(name, purgeDate)
+	}
+
+	if _, exists := repo.Trash[name]; exists {
+		return domain.TrashedSet{}, ports.ErrTrashExists
+	}
+
+	set, err := repo.DeleteSet(name)
+	if err != nil {
+		return domain.TrashedSet{}, err
+	}
+
+	trashed := domain.TrashedSet{Set: set, DeleteDate: datetime.UnixUTCNow(), PurgeDate: purgeDate}
+	repo.Trash[name] = trashed
+	return trashed, nil
+}
+
+func (repo *MemRepo) GetTrashedSets(limit int, skip int) ([]domain.TrashedSet, error) {
+	if repo.GetTrashedSetsInterceptor != nil {
+		return repo.GetTrashedSetsInterceptor(limit, skip)
+	}
+
+	trashed := repo.sortedTrash()
+	if skip >= len(trashed) {
+		return []domain.TrashedSet{}, nil
+	}
+
+	if limit > len(trashed)-skip {
+		limit = len(trashed) - skip
+	}
+
+	return trashed[skip : skip+limit], nil
+}
+
+func (repo *MemRepo) RestoreSet(name string) (domain.ConfigSet, error) {
+	if repo.RestoreSetInterceptor != nil {
+		return repo.RestoreSetInterceptor(name)
+	}
+
+	trashed, exists := repo.Trash[name]
+	if !exists {
+		return domain.ConfigSet{}, ports.ErrTrashNotExists
+	}
+
+	set, err := repo.CreateSet(trashed.Set)
+	if err != nil {
+		return set, err
+	}
+
+	delete(repo.Trash, name)
+	return set, nil
+}
+
+func (repo *MemRepo) PurgeSet(name string) (domain.TrashedSet, error) {
+	if repo.PurgeSetInterceptor != nil {
+		return repo.PurgeSetInterceptor(name)
+	}
+
+	trashed, exists := repo.Trash[name]
+	if !exists {
+		return domain.TrashedSet{}, ports.ErrTrashNotExists
+	}
+
+	delete(repo.Trash, name)
+	return trashed, nil
+}
+
+func (repo *MemRepo) PurgeExpiredSets(at time.Time, limit int) ([]domain.TrashedSet, error) {
+	if repo.PurgeExpiredSetsInterceptor != nil {
+		return repo.PurgeExpiredSetsInterceptor(at, limit)
+	}
+
+	purged := []domain.TrashedSet{}
+	for _, trashed := range repo.sortedTrash() {
+		if len(purged) == limit || !trashed.Expired(at) {
+			break
+		}
+
+		delete(repo.Trash, trashed.Set.Name)
+		purged = append(purged, trashed)
+	}
+
+	return purged, nil
+}
+
+// sortedTrash returns the trashed sets with the next to be purged first
+func (repo *MemRepo) sortedTrash() []domain.TrashedSet {
+	trashed := make([]domain.TrashedSet, 0, len(repo.Trash))
+	for _, set := range repo.Trash {
+		trashed = append(trashed, set)
+	}
+
+	sort.Slice(trashed, func(i, j int) bool {
+		left, right := trashed[i].PurgeDate, trashed[j].PurgeDate
+		if left == nil || right == nil {
+			return right == nil && (left != nil || trashed[i].Set.Name < trashed[j].Set.Name)
+		}
+
+		return left.Before(*right)
+	})
+
+	return trashed
+}